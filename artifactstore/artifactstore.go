@@ -0,0 +1,718 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+// Use of this source code is governed by the MIT license that can be
+// found in the LICENSE file.
+
+// Package artifactstore runs the artifact store in-process.
+//
+// Where the client package talks to a separate artifact server, this package
+// opens a data directory directly and offers the same Write/Read/VFS API
+// without a network hop. The data directory has the layout of the server's
+// -data-dir, so a store can be moved between an embedding program and a
+// standalone server.
+//
+// # Serving the Store
+//
+// A Store can also serve its artifacts to others, so that a single binary
+// replaces the artifact server:
+//
+//   - Mount adds the Connect/gRPC ArtifactService (and share links, if
+//     configured) to an http.ServeMux, for client.Client and other SDKs,
+//     together with gRPC health checking, reflection and the /healthz and
+//     /readyz probes, the web dashboard, the HTTP API under /v1/, WebDAV
+//     under /webdav/ and, with WithMetrics, /metrics.
+//   - RegisterTools adds the MCP tools and the VFS usage prompt to an
+//     mcp-go server, RegisterResources the artifacts as MCP resources.
+//     AddSessionHooks binds MCP sessions to the principal that opened them,
+//     see WithCredentials and Authenticate.
+//   - MountMCP serves such an MCP server on the same mux as Mount, over
+//     streamable HTTP and the legacy SSE transport.
+//
+// # Example
+//
+//	st, err := artifactstore.Open("./artifacts")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	meta, err := st.Write(ctx, "report.md", data, artifactstore.WriteOptions{VirtualPath: "/reports/q1.md"})
+//
+//	mux := http.NewServeMux()
+//	st.Mount(mux)
+//
+//	s := server.NewMCPServer("my-server", "1.0.0")
+//	st.RegisterTools(s)
+package artifactstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/internal/audit"
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/dashboard"
+	"github.com/hmsoft0815/mlcartifact/internal/dav"
+	artifactgrpc "github.com/hmsoft0815/mlcartifact/internal/grpc"
+	"github.com/hmsoft0815/mlcartifact/internal/health"
+	"github.com/hmsoft0815/mlcartifact/internal/httpapi"
+	artifactmcp "github.com/hmsoft0815/mlcartifact/internal/mcp"
+	"github.com/hmsoft0815/mlcartifact/internal/metrics"
+	"github.com/hmsoft0815/mlcartifact/internal/middleware"
+	"github.com/hmsoft0815/mlcartifact/internal/ratelimit"
+	"github.com/hmsoft0815/mlcartifact/internal/share"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/hmsoft0815/mlcartifact/internal/tracing"
+	"github.com/hmsoft0815/mlcartifact/internal/webhook"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Types shared with the store implementation.
+type (
+	ArtifactMetadata  = storage.ArtifactMetadata
+	WriteOptions      = storage.WriteOptions
+	ReadOptions       = storage.ReadOptions
+	OpenOptions       = storage.OpenOptions
+	ReplaceOptions    = storage.ReplaceOptions
+	SetExpiryOptions  = storage.SetExpiryOptions
+	ListOptions       = storage.ListOptions
+	FindOptions       = storage.FindOptions
+	PatchOptions      = storage.PatchOptions
+	DeleteOptions     = storage.DeleteOptions
+	StatOptions       = storage.StatOptions
+	MoveOptions       = storage.MoveOptions
+	DeleteTreeOptions = storage.DeleteTreeOptions
+	Error             = storage.Error
+	Event             = storage.Event
+	EventType         = storage.EventType
+	WatchFilter       = storage.WatchFilter
+	Subscription      = storage.Subscription
+
+	// Subscriptions serves resources/subscribe, see RegisterResources.
+	Subscriptions = artifactmcp.Subscriptions
+
+	// Credential is a token accepted by WithCredentials.
+	Credential = auth.Credential
+	// Principal is the caller a Credential authenticates.
+	Principal = auth.Principal
+
+	// MCPToolConfig selects the MCP tools, see WithMCPTools.
+	MCPToolConfig = artifactmcp.ToolConfig
+	// MCPToolMode selects MCP tools by what they may do to the store.
+	MCPToolMode = artifactmcp.ToolMode
+
+	// ProgressFunc receives the progress of a store operation, see
+	// WithProgress.
+	ProgressFunc = storage.ProgressFunc
+
+	// RateLimits configures WithRateLimits.
+	RateLimits = ratelimit.Config
+	// RateLimit is a token bucket of RateLimits.
+	RateLimit = ratelimit.Limit
+
+	// Webhook is an endpoint of WithWebhooks.
+	Webhook = webhook.Config
+
+	// MetricsOption configures WithMetrics.
+	MetricsOption = metrics.Option
+
+	// TracingConfig selects the span exporter of WithTracing.
+	TracingConfig = tracing.Config
+)
+
+// Modes of MCPToolConfig.
+const (
+	MCPToolsFull     = artifactmcp.ModeFull
+	MCPToolsSafe     = artifactmcp.ModeSafe
+	MCPToolsReadOnly = artifactmcp.ModeReadOnly
+)
+
+// Errors returned by the store, wrapped in an *Error. Test for them with
+// errors.Is.
+var (
+	ErrNotFound        = storage.ErrNotFound
+	ErrAlreadyExists   = storage.ErrAlreadyExists
+	ErrInvalidPath     = storage.ErrInvalidPath
+	ErrInvalidArgument = storage.ErrInvalidArgument
+	ErrQuotaExceeded   = storage.ErrQuotaExceeded
+	ErrConflict        = storage.ErrConflict
+)
+
+// WithProgress returns a context that makes Read, List, Find and DeleteTree
+// report their progress to fn: bytes read, or artifacts listed, found or
+// deleted so far.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return storage.WithProgress(ctx, fn)
+}
+
+// Reason returns the machine-readable reason of err ("NOT_FOUND", ...), the
+// same one RPC and MCP clients see.
+func Reason(err error) string {
+	return storage.Reason(err)
+}
+
+// Store is an artifact store opened in-process. It is safe for concurrent
+// use.
+type Store struct {
+	store   *storage.Store
+	signer  *share.Signer
+	limiter *ratelimit.Limiter
+	auth    *auth.Authenticator
+	metrics *metrics.Metrics
+	tools   MCPToolConfig
+
+	auditDir string
+	audit    *audit.Logger
+
+	webhooks      []Webhook
+	deadLetterDir string
+	deadLetter    *os.File
+	stopWebhooks  func()
+
+	tracing     *TracingConfig
+	stopTracing func(context.Context) error
+}
+
+// Option is a functional option for configuring a Store.
+type Option func(*Store)
+
+// WithShareLinks enables signed share links for Mount and the
+// create_share_link tool. Links are signed with secret and point to baseURL,
+// the externally reachable address of the mux passed to Mount.
+func WithShareLinks(secret []byte, baseURL string) Option {
+	return func(s *Store) {
+		s.signer = share.NewSigner(secret, baseURL)
+	}
+}
+
+// WithMaxArtifactSize limits the content of an artifact to n bytes for all
+// writes, in-process and through Mount or the MCP tools. Larger artifacts
+// fail with ErrQuotaExceeded.
+func WithMaxArtifactSize(n int64) Option {
+	return func(s *Store) {
+		s.store.SetMaxArtifactSize(n)
+	}
+}
+
+// WithRateLimits rate limits the RPCs of Mount and the MCP tools per
+// principal, or per client host if no credentials are configured, and
+// limits their request size. In-process calls are not limited.
+func WithRateLimits(cfg RateLimits) Option {
+	return func(s *Store) {
+		s.limiter = ratelimit.New(cfg)
+	}
+}
+
+// WithCredentials requires one of creds for the RPCs of Mount and for the MCP
+// HTTP transports wrapped with Authenticate. Callers are bound to the user
+// scope of their principal unless it is an admin.
+func WithCredentials(creds []Credential) Option {
+	return func(s *Store) {
+		s.auth = auth.NewAuthenticator(creds)
+	}
+}
+
+// WithAuditLog records every operation on the artifacts in a rotating JSONL
+// audit log in dir, whether it comes through Mount, the MCP tools or the
+// in-process API, and enables the QueryAudit RPC for admins. Close the
+// store to close the log.
+func WithAuditLog(dir string) Option {
+	return func(s *Store) {
+		s.auditDir = dir
+	}
+}
+
+// WithWebhooks POSTs the lifecycle events of the artifacts to hooks, see
+// LoadWebhooks, from the moment Open returns until the store is closed.
+// Events that cannot be delivered are appended to the dead-letter log
+// webhooks-dead-letter.jsonl in deadLetterDir, or only logged if
+// deadLetterDir is empty.
+func WithWebhooks(hooks []Webhook, deadLetterDir string) Option {
+	return func(s *Store) {
+		s.webhooks = hooks
+		s.deadLetterDir = deadLetterDir
+	}
+}
+
+// LoadWebhooks reads the webhooks of WithWebhooks from a JSON file of the
+// form
+//
+//	{"webhooks": [{"url": "https://...", "secret": "...", "path_prefix": "/projects"}]}
+func LoadWebhooks(path string) ([]Webhook, error) {
+	return webhook.LoadFile(path)
+}
+
+// WithMetrics exports Prometheus metrics of the RPCs, the MCP tool calls
+// and the store at /metrics of Mount. The endpoint needs the credentials of
+// WithCredentials like the other endpoints.
+func WithMetrics(opts ...MetricsOption) Option {
+	return func(s *Store) {
+		s.metrics = metrics.New(s.store, opts...)
+	}
+}
+
+// WithCollapsedScopes reports the storage metrics of all user scopes as the
+// single scope "user", for stores with many users.
+func WithCollapsedScopes() MetricsOption {
+	return metrics.WithCollapsedScopes()
+}
+
+// WithTracing installs the OpenTelemetry exporter of cfg as the global
+// tracer provider, see TracingConfigFromEnv, and traces the RPCs of Mount
+// and the MCP tools of RegisterTools, continuing the trace of the caller.
+// The storage operations behind them are traced as well. Close the store to
+// flush pending spans.
+func WithTracing(cfg TracingConfig) Option {
+	return func(s *Store) {
+		s.tracing = &cfg
+	}
+}
+
+// TracingConfigFromEnv reads the configuration of WithTracing from
+// ARTIFACT_TRACE_EXPORTER, ARTIFACT_TRACE_FILE, ARTIFACT_TRACE_ENDPOINT and
+// the standard OTEL_* variables.
+func TracingConfigFromEnv() TracingConfig {
+	return tracing.ConfigFromEnv()
+}
+
+// WithMCPTools selects the tools RegisterTools adds, e.g. only the read-only
+// ones, and whether destructive calls need a confirmation token. By default
+// all tools are added without confirmation.
+func WithMCPTools(cfg MCPToolConfig) Option {
+	return func(s *Store) {
+		s.tools = cfg
+	}
+}
+
+// Open opens the store in dir, creating the directory if needed.
+func Open(dir string, opts ...Option) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	s := &Store{store: storage.NewStore(dir)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.tools.Validate(); err != nil {
+		return nil, err
+	}
+	if s.tracing != nil {
+		shutdown, err := tracing.Setup(context.Background(), *s.tracing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up tracing: %w", err)
+		}
+		s.stopTracing = shutdown
+	}
+	if s.auditDir != "" {
+		l, err := audit.NewLogger(s.auditDir)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.audit = l
+		l.Observe(s.store)
+	}
+	if len(s.webhooks) > 0 {
+		if err := s.startWebhooks(); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// startWebhooks starts the dispatcher of WithWebhooks.
+func (s *Store) startWebhooks() error {
+	var opts []webhook.Option
+	if s.deadLetterDir != "" {
+		if err := os.MkdirAll(s.deadLetterDir, 0750); err != nil {
+			return fmt.Errorf("failed to create dead-letter directory: %w", err)
+		}
+		f, err := os.OpenFile(filepath.Join(s.deadLetterDir, webhook.DeadLetterFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+		if err != nil {
+			return fmt.Errorf("failed to open dead-letter log: %w", err)
+		}
+		s.deadLetter = f
+		opts = append(opts, webhook.WithDeadLetter(f))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := webhook.NewDispatcher(s.store, s.webhooks, opts...)
+	if err := d.Start(ctx); err != nil {
+		cancel()
+		if s.deadLetter != nil {
+			s.deadLetter.Close()
+		}
+		return err
+	}
+	s.stopWebhooks = func() {
+		cancel()
+		d.Wait()
+	}
+	return nil
+}
+
+// Close releases the resources of the store: it stops the webhooks of
+// WithWebhooks, dead-lettering events still being retried, closes the
+// audit log of WithAuditLog and flushes the spans of WithTracing. The store
+// must not be used afterwards.
+func (s *Store) Close() error {
+	var errs []error
+	if s.stopWebhooks != nil {
+		s.stopWebhooks()
+	}
+	if s.deadLetter != nil {
+		errs = append(errs, s.deadLetter.Close())
+	}
+	errs = append(errs, s.audit.Close())
+	if s.stopTracing != nil {
+		errs = append(errs, s.stopTracing(context.Background()))
+	}
+	return errors.Join(errs...)
+}
+
+// Dir returns the data directory of the store.
+func (s *Store) Dir() string {
+	return s.store.BaseDir
+}
+
+// Write saves content as a new artifact.
+func (s *Store) Write(ctx context.Context, filename string, content []byte, opts WriteOptions) (*ArtifactMetadata, error) {
+	return s.store.Write(ctx, filename, content, opts)
+}
+
+// WriteStream is like Write but copies the content from r.
+func (s *Store) WriteStream(ctx context.Context, filename string, r io.Reader, opts WriteOptions) (*ArtifactMetadata, error) {
+	return s.store.WriteStream(ctx, filename, r, opts)
+}
+
+// Read returns the content and metadata of the artifact with the given ID,
+// filename or virtual path.
+func (s *Store) Read(ctx context.Context, idOrPath string, opts ReadOptions) ([]byte, *ArtifactMetadata, error) {
+	return s.store.Read(ctx, idOrPath, opts)
+}
+
+// Open opens the content of an artifact for streaming. The caller must close
+// the returned file.
+func (s *Store) Open(ctx context.Context, idOrPath string, opts OpenOptions) (*os.File, *ArtifactMetadata, error) {
+	return s.store.Open(ctx, idOrPath, opts)
+}
+
+// Replace overwrites the content of an existing artifact, keeping its ID and
+// metadata.
+func (s *Store) Replace(ctx context.Context, idOrPath string, r io.Reader, opts ReplaceOptions) (*ArtifactMetadata, error) {
+	return s.store.Replace(ctx, idOrPath, r, opts)
+}
+
+// SetExpiry changes the time-to-live of an artifact.
+func (s *Store) SetExpiry(ctx context.Context, idOrPath string, expiresHours int, opts SetExpiryOptions) (*ArtifactMetadata, error) {
+	return s.store.SetExpiry(ctx, idOrPath, expiresHours, opts)
+}
+
+// List returns all artifacts of a user scope or the entries of a virtual
+// directory.
+func (s *Store) List(ctx context.Context, opts ListOptions) ([]*ArtifactMetadata, error) {
+	return s.store.List(ctx, opts)
+}
+
+// Find returns the artifacts whose virtual path matches pattern.
+func (s *Store) Find(ctx context.Context, pattern string, opts FindOptions) ([]*ArtifactMetadata, error) {
+	return s.store.Find(ctx, pattern, opts)
+}
+
+// Patch replaces lines of an artifact or appends to it and returns the new
+// size.
+func (s *Store) Patch(ctx context.Context, idOrPath string, content []byte, opts PatchOptions) (int64, error) {
+	return s.store.Patch(ctx, idOrPath, content, opts)
+}
+
+// Delete removes an artifact. It reports false if there was none.
+func (s *Store) Delete(ctx context.Context, idOrPath string, opts DeleteOptions) (bool, error) {
+	return s.store.Delete(ctx, idOrPath, opts)
+}
+
+// Stat returns the file information and metadata of an artifact without
+// reading its content.
+func (s *Store) Stat(ctx context.Context, idOrPath string, opts StatOptions) (os.FileInfo, *ArtifactMetadata, error) {
+	return s.store.Stat(ctx, idOrPath, opts)
+}
+
+// Mkdir creates an empty virtual directory.
+func (s *Store) Mkdir(userID string, dirPath string) error {
+	return s.store.Mkdir(userID, dirPath)
+}
+
+// IsDir reports whether dirPath is a virtual directory.
+func (s *Store) IsDir(userID string, dirPath string) bool {
+	return s.store.IsDir(userID, dirPath)
+}
+
+// Move renames an artifact or virtual directory.
+func (s *Store) Move(ctx context.Context, oldPath string, newPath string, opts MoveOptions) error {
+	return s.store.Move(ctx, oldPath, newPath, opts)
+}
+
+// DeleteTree removes an artifact or every artifact below a virtual directory
+// and returns the number deleted.
+func (s *Store) DeleteTree(ctx context.Context, dirPath string, opts DeleteTreeOptions) (int, error) {
+	return s.store.DeleteTree(ctx, dirPath, opts)
+}
+
+// Cleanup removes expired artifacts. Embedding programs should call it
+// periodically.
+func (s *Store) Cleanup(ctx context.Context) error {
+	return s.store.Cleanup(ctx)
+}
+
+// Watch subscribes to artifact changes matching filter. If cursor is empty,
+// only events from now on are delivered; otherwise delivery resumes right
+// after the event with that cursor. The subscription ends when ctx is
+// cancelled.
+func (s *Store) Watch(ctx context.Context, filter WatchFilter, cursor string) (*Subscription, error) {
+	return s.store.Watch(ctx, filter, cursor)
+}
+
+// Handler returns the path and handler of the Connect/gRPC ArtifactService,
+// for callers that mount it themselves. Calls get the spans of WithTracing,
+// request IDs, panic recovery, default deadlines, an access log on the
+// default slog logger and the limits of WithRateLimits; interceptors in opts
+// run inside these.
+func (s *Store) Handler(opts ...connect.HandlerOption) (string, http.Handler) {
+	var serverOpts []artifactgrpc.ServerOption
+	if s.signer != nil {
+		serverOpts = append(serverOpts, artifactgrpc.WithShareSigner(s.signer))
+	}
+	if s.audit != nil {
+		serverOpts = append(serverOpts, artifactgrpc.WithAuditLog(s.audit))
+	}
+	var stack []connect.HandlerOption
+	if s.tracing != nil {
+		stack = append(stack, connect.WithInterceptors(tracing.Interceptor()))
+	}
+	stack = append(stack, connect.WithInterceptors(middleware.Interceptors(middleware.Config{})...))
+	if s.metrics != nil {
+		stack = append(stack, connect.WithInterceptors(s.metrics.Interceptor()))
+	}
+	if s.auth != nil {
+		stack = append(stack, connect.WithInterceptors(s.auth.Interceptor()))
+	}
+	if s.limiter != nil {
+		stack = append(stack, connect.WithInterceptors(s.limiter.Interceptor()))
+		stack = append(stack, s.limiter.HandlerOptions()...)
+	}
+	opts = append(stack, opts...)
+	return protoconnect.NewArtifactServiceHandler(artifactgrpc.NewConnectServer(s.store, serverOpts...), opts...)
+}
+
+// Ready returns nil if the store can serve requests: its VFS index has been
+// built and the data directory is writable. Otherwise it returns the reason.
+func (s *Store) Ready(ctx context.Context) error {
+	return health.New(s.store).Ready(ctx)
+}
+
+// Mount adds the ArtifactService to mux, and the share link downloads if
+// share links are enabled. It also mounts the gRPC health service, whose
+// status follows Ready, gRPC server reflection, and the HTTP probes
+// /healthz (liveness) and /readyz (readiness). gRPC clients need HTTP/2, so
+// serve mux with TLS or h2c.
+//
+// Next to the RPCs, Mount serves the web dashboard at /dashboard/, the HTTP
+// API at /v1/, WebDAV at /webdav/ and, with WithMetrics, Prometheus metrics
+// at /metrics. They get request IDs and an access log and need the
+// credentials of WithCredentials; the dashboard itself is public, but signs
+// in before it calls the authenticated RPCs.
+func (s *Store) Mount(mux *http.ServeMux, opts ...connect.HandlerOption) {
+	mux.Handle(s.Handler(opts...))
+	health.New(s.store).Mount(mux)
+	if s.signer != nil {
+		mux.Handle(share.PathPrefix, s.signer.Handler(s.store))
+	}
+
+	wrap := func(h http.Handler) http.Handler {
+		return middleware.Handler(middleware.Config{}, h)
+	}
+	mux.Handle(dashboard.PathPrefix, wrap(dashboard.Handler(s.auth)))
+	mux.Handle(httpapi.PathPrefix, wrap(httpapi.NewHandler(s.store, s.auth)))
+	mux.Handle(dav.PathPrefix, wrap(dav.Handler(s.store, s.auth)))
+	if s.metrics != nil {
+		mux.Handle(metrics.Path, wrap(s.auth.Middleware(s.metrics.Handler())))
+	}
+}
+
+// RegisterTools adds the artifact MCP tools selected with WithMCPTools and
+// the VFS usage prompt to srv.
+// Like the RPC handler, tool calls get the spans of WithTracing, panic
+// recovery, default deadlines and an access log. The user_id argument of the tools is resolved against the
+// caller's principal, see AddSessionHooks: it defaults to the principal's
+// scope, and other scopes are denied to non-admins.
+//
+// The MCP tools serve a single store per process: registering the tools of
+// another Store switches all registered tools over to it.
+func (s *Store) RegisterTools(srv *server.MCPServer) {
+	artifactmcp.SetStore(s.store)
+	artifactmcp.SetShareSigner(s.signer)
+	var mw []server.ToolHandlerMiddleware
+	if s.tracing != nil {
+		mw = append(mw, tracing.ToolMiddleware())
+	}
+	mw = append(mw, middleware.ToolMiddleware(middleware.Config{}), artifactmcp.ScopeTools())
+	if s.metrics != nil {
+		mw = append(mw, s.metrics.ToolMiddleware())
+	}
+	if s.limiter != nil {
+		mw = append(mw, s.limiter.ToolMiddleware())
+	}
+	artifactmcp.Register(srv, s.tools, mw...)
+}
+
+// RegisterResources exposes the artifacts to srv as MCP resources: every
+// artifact with a virtual path is listed as artifact://{user}/{path}, and the
+// artifact://{user}/{+path} template reads any artifact by path or ID. The
+// list follows the changes to the store until ctx is cancelled.
+//
+// Resources are listed to the sessions of AddSessionHooks, each only with
+// the scopes its principal may access. SSE and streamable HTTP sessions get
+// a list of their own; stdio sessions share the server's list.
+//
+// Clients can subscribe to artifacts and virtual directories through the
+// returned Subscriptions, once its Handler wraps the MCP HTTP transport or
+// its Stdin feeds the stdio transport.
+//
+// Like the tools, resources serve a single store and MCP server per process.
+func (s *Store) RegisterResources(ctx context.Context, srv *server.MCPServer) (*Subscriptions, error) {
+	artifactmcp.SetStore(s.store)
+	subs, err := artifactmcp.NewSubscriptions(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	if err := artifactmcp.RegisterResources(ctx, srv); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// Authenticate wraps an MCP HTTP transport, streamable HTTP or SSE, so that
+// its requests need one of the credentials of WithCredentials. It passes
+// all requests as an anonymous admin if none are configured. Wrap the
+// Handler of the Subscriptions in it, so that subscriptions are scoped too.
+func (s *Store) Authenticate(next http.Handler) http.Handler {
+	return s.auth.Middleware(next)
+}
+
+// AddSessionHooks adds to h the hooks that bind every MCP session to the
+// principal that opened it, which Authenticate puts into the request
+// context. Requests of a session authenticated as another principal are
+// denied, resource lists only show the scopes the principal may access,
+// and artifacts written with write_artifact's "session" TTL class are
+// deleted when the session ends. Pass h to server.NewMCPServer with
+// server.WithHooks:
+//
+//	hooks := &server.Hooks{}
+//	st.AddSessionHooks(hooks)
+//	s := server.NewMCPServer("my-server", "1.0.0", server.WithHooks(hooks))
+//	st.RegisterTools(s)
+//	st.MountMCP(mux, s)
+func (s *Store) AddSessionHooks(h *server.Hooks) {
+	artifactmcp.SetStore(s.store)
+	artifactmcp.AddSessionHooks(h)
+}
+
+// CompletionOptions returns the server options that answer MCP
+// completion/complete from the store's VFS index: virtual paths, directory
+// children and recent artifact IDs for the resource template and prompt
+// arguments, scoped to the user of the session. Pass them to
+// server.NewMCPServer:
+//
+//	s := server.NewMCPServer("my-server", "1.0.0", append(st.CompletionOptions(), server.WithHooks(hooks))...)
+func (s *Store) CompletionOptions() []server.ServerOption {
+	artifactmcp.SetStore(s.store)
+	return artifactmcp.CompletionOptions()
+}
+
+// Endpoints of MountMCP.
+const (
+	MCPPath        = "/mcp"     // Streamable HTTP transport
+	MCPSSEPath     = "/sse"     // Legacy SSE transport: event stream
+	MCPMessagePath = "/message" // Legacy SSE transport: client messages
+)
+
+// DefaultMCPSessionIdleTTL is how long MountMCP keeps an idle streamable
+// HTTP session.
+const DefaultMCPSessionIdleTTL = 30 * time.Minute
+
+// MCPOption configures MountMCP.
+type MCPOption func(*mcpConfig)
+
+type mcpConfig struct {
+	subs    *Subscriptions
+	origins []string
+	idleTTL time.Duration
+}
+
+// WithSubscriptions lets the clients of MountMCP subscribe to resources,
+// see RegisterResources.
+func WithSubscriptions(subs *Subscriptions) MCPOption {
+	return func(c *mcpConfig) {
+		c.subs = subs
+	}
+}
+
+// WithAllowedOrigins allows browser pages from origins, such as
+// "https://app.example.com", to call the MCP endpoints with the browser's
+// credentials; "*" allows every other origin to call them without
+// credentials. By default, requests with an Origin header are rejected.
+func WithAllowedOrigins(origins ...string) MCPOption {
+	return func(c *mcpConfig) {
+		c.origins = append(c.origins, origins...)
+	}
+}
+
+// WithMCPSessionIdleTTL ends streamable HTTP sessions that have been idle
+// for d, instead of DefaultMCPSessionIdleTTL.
+func WithMCPSessionIdleTTL(d time.Duration) MCPOption {
+	return func(c *mcpConfig) {
+		c.idleTTL = d
+	}
+}
+
+// MountMCP serves srv on mux over streamable HTTP at MCPPath and over the
+// legacy SSE transport at MCPSSEPath and MCPMessagePath, so that one
+// listener serves the Connect API of Mount and MCP.
+//
+// Both transports get request IDs and an access log, CORS for the origins
+// of WithAllowedOrigins, the credentials of WithCredentials (see
+// Authenticate) and, with WithSubscriptions, resource subscriptions.
+//
+// Streamable HTTP sessions can be resumed: a client whose connection broke
+// sends its Mcp-Session-Id again, also to reopen the GET event stream, and
+// keeps its session. A session ends when the client deletes it or after it
+// has been idle for the TTL of WithMCPSessionIdleTTL; an SSE session ends
+// with its event stream.
+func (s *Store) MountMCP(mux *http.ServeMux, srv *server.MCPServer, opts ...MCPOption) {
+	cfg := mcpConfig{idleTTL: DefaultMCPSessionIdleTTL}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	wrap := func(h http.Handler) http.Handler {
+		if cfg.subs != nil {
+			h = cfg.subs.Handler(h)
+		}
+		return middleware.Handler(middleware.Config{}, middleware.CORS(cfg.origins, s.Authenticate(h)))
+	}
+
+	mux.Handle(MCPPath, wrap(server.NewStreamableHTTPServer(srv,
+		server.WithEndpointPath(MCPPath),
+		server.WithSessionIdleTTL(cfg.idleTTL),
+	)))
+	sse := wrap(server.NewSSEServer(srv,
+		server.WithSSEEndpoint(MCPSSEPath),
+		server.WithMessageEndpoint(MCPMessagePath),
+	))
+	mux.Handle(MCPSSEPath, sse)
+	mux.Handle(MCPMessagePath, sse)
+}
@@ -0,0 +1,501 @@
+package artifactstore_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/artifactstore"
+	"github.com/hmsoft0815/mlcartifact/client"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStore_InProcess(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir() + "/data")
+	require.NoError(t, err)
+
+	meta, err := st.Write(t.Context(), "plan.md", []byte("# Plan"), artifactstore.WriteOptions{UserID: "u1", VirtualPath: "/docs/plan.md"})
+	require.NoError(t, err)
+
+	data, got, err := st.Read(t.Context(), "/docs/plan.md", artifactstore.ReadOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan", string(data))
+	assert.Equal(t, meta.ID, got.ID)
+
+	items, err := st.List(t.Context(), artifactstore.ListOptions{UserID: "u1", DirPath: "/docs"})
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	_, _, err = st.Read(t.Context(), "/docs/missing.md", artifactstore.ReadOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, artifactstore.ErrNotFound)
+	assert.Equal(t, "NOT_FOUND", artifactstore.Reason(err))
+}
+
+func TestStore_Mount(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir(), artifactstore.WithShareLinks([]byte("secret"), "http://example.com"))
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	st.Mount(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := client.NewClientWithAddr(srv.URL, client.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+
+	resp, err := c.Write(t.Context(), "a.txt", []byte("hello"))
+	require.NoError(t, err)
+
+	// Written over the network, visible in-process
+	data, _, err := st.Read(t.Context(), resp.Id, artifactstore.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	link, err := c.CreateShareLink(t.Context(), resp.Id)
+	require.NoError(t, err)
+	assert.Contains(t, link.Url, "http://example.com/share/")
+
+	require.NoError(t, st.Ready(t.Context()))
+	res, err := srv.Client().Get(srv.URL + "/readyz")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestStore_MountFrontEnds(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir(), artifactstore.WithMetrics(), artifactstore.WithCredentials([]artifactstore.Credential{
+		{Token: "alice-token", Principal: artifactstore.Principal{Name: "alice", UserID: "alice"}},
+	}))
+	require.NoError(t, err)
+	_, err = st.Write(t.Context(), "plan.md", []byte("# Plan"), artifactstore.WriteOptions{UserID: "alice", VirtualPath: "/projects/plan.md"})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	st.Mount(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	get := func(method, path string, authorize bool) (int, string) {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.NoError(t, err)
+		if authorize {
+			req.SetBasicAuth("alice", "alice-token")
+		}
+		res, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	// The dashboard loads without credentials and signs in afterwards
+	status, _ := get(http.MethodGet, "/dashboard/", false)
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = get(http.MethodGet, "/dashboard/api/whoami", false)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	for _, path := range []string{"/v1/vfs/projects/plan.md", "/webdav/projects/plan.md", "/metrics"} {
+		status, _ = get(http.MethodGet, path, false)
+		assert.Equal(t, http.StatusUnauthorized, status, path)
+	}
+	status, body := get(http.MethodGet, "/v1/vfs/projects/plan.md", true)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "# Plan", body)
+	status, body = get(http.MethodGet, "/webdav/projects/plan.md", true)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "# Plan", body)
+	status, body = get(http.MethodGet, "/metrics", true)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "mlcartifact_artifacts")
+}
+
+func TestStore_AuditLog(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir(), artifactstore.WithAuditLog(t.TempDir()))
+	require.NoError(t, err)
+	defer st.Close()
+
+	mux := http.NewServeMux()
+	st.Mount(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Every front end ends up in the same log
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/v1/vfs/notes/a.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	res, err = srv.Client().Get(srv.URL + "/webdav/notes/a.txt")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	_, _, err = st.Read(t.Context(), "/notes/a.txt", artifactstore.ReadOptions{})
+	require.NoError(t, err)
+
+	c, err := client.NewClientWithAddr(srv.URL, client.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	audit, err := c.QueryAudit(t.Context())
+	require.NoError(t, err)
+	var ops []string
+	for _, e := range audit.Entries {
+		ops = append(ops, e.Operation+":"+e.Outcome)
+	}
+	assert.Equal(t, []string{"Write:ok", "Read:ok", "Read:ok"}, ops)
+	assert.Equal(t, "/notes/a.txt", audit.Entries[0].VirtualPath)
+	assert.Equal(t, int64(5), audit.Entries[1].Bytes)
+}
+
+func TestStore_Webhooks(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		delivered []string
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered = append(delivered, r.Header.Get("X-Artifact-Event"))
+	}))
+	defer receiver.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+
+	deadLetterDir := t.TempDir()
+	st, err := artifactstore.Open(t.TempDir(), artifactstore.WithWebhooks([]artifactstore.Webhook{
+		{URL: receiver.URL, PathPrefix: "/reports"},
+		{URL: rejecting.URL},
+	}, deadLetterDir))
+	require.NoError(t, err)
+
+	_, err = st.Write(t.Context(), "q1.md", []byte("# Q1"), artifactstore.WriteOptions{VirtualPath: "/reports/q1.md"})
+	require.NoError(t, err)
+	_, err = st.Delete(t.Context(), "/reports/q1.md", artifactstore.DeleteOptions{})
+	require.NoError(t, err)
+
+	// The rejected deliveries are dead-lettered
+	deadLetters := func() string {
+		data, _ := os.ReadFile(filepath.Join(deadLetterDir, "webhooks-dead-letter.jsonl"))
+		return string(data)
+	}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(delivered) == 2 && strings.Count(deadLetters(), "\n") == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, st.Close())
+	assert.Equal(t, []string{"created", "deleted"}, delivered)
+	assert.Contains(t, deadLetters(), rejecting.URL)
+}
+
+func TestStore_Tracing(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	traces := filepath.Join(t.TempDir(), "traces.jsonl")
+	st, err := artifactstore.Open(t.TempDir(), artifactstore.WithTracing(artifactstore.TracingConfig{Exporter: "file", File: traces}))
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	st.Mount(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := client.NewClientWithAddr(srv.URL, client.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	_, err = c.Write(t.Context(), "a.txt", []byte("hello"))
+	require.NoError(t, err)
+
+	s := server.NewMCPServer("test", "1.0.0")
+	st.RegisterTools(s)
+	mc, err := mcpclient.NewInProcessClient(s)
+	require.NoError(t, err)
+	require.NoError(t, mc.Start(t.Context()))
+	_, err = mc.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "list_artifacts"
+	_, err = mc.CallTool(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+
+	// One exported span per line
+	f, err := os.Open(traces)
+	require.NoError(t, err)
+	defer f.Close()
+	spans := map[string]trace.SpanKind{}
+	for dec := json.NewDecoder(f); dec.More(); {
+		var span struct {
+			Name     string
+			SpanKind trace.SpanKind
+		}
+		require.NoError(t, dec.Decode(&span))
+		if span.SpanKind != trace.SpanKindClient {
+			spans[span.Name] = span.SpanKind
+		}
+	}
+	assert.Equal(t, trace.SpanKindServer, spans["artifact.v1.ArtifactService/Write"])
+	assert.Equal(t, trace.SpanKindServer, spans["mcp.tool/list_artifacts"])
+	assert.Contains(t, spans, "storage.Write")
+}
+
+func TestStore_RegisterTools(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir())
+	require.NoError(t, err)
+
+	s := server.NewMCPServer("test", "1.0.0")
+	st.RegisterTools(s)
+
+	c, err := mcpclient.NewInProcessClient(s)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	tools, err := c.ListTools(t.Context(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	assert.Contains(t, names, "write_artifact")
+	assert.Contains(t, names, "vfs_ls")
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "write_artifact"
+	req.Params.Arguments = map[string]any{"filename": "notes.txt", "content": "via MCP", "virtual_path": "/notes.txt"}
+	res, err := c.CallTool(t.Context(), req)
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+
+	data, _, err := st.Read(t.Context(), "/notes.txt", artifactstore.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "via MCP", string(data))
+}
+
+func TestStore_RegisterResources(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir())
+	require.NoError(t, err)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00")
+	_, err = st.Write(t.Context(), "plan.md", []byte("# Plan"), artifactstore.WriteOptions{UserID: "u1", VirtualPath: "/docs/plan.md"})
+	require.NoError(t, err)
+	_, err = st.Write(t.Context(), "logo.png", png, artifactstore.WriteOptions{VirtualPath: "/img/logo.png"})
+	require.NoError(t, err)
+	flat, err := st.Write(t.Context(), "flat.txt", []byte("no path"), artifactstore.WriteOptions{})
+	require.NoError(t, err)
+
+	// Resources are listed to the sessions of the hooks
+	hooks := &server.Hooks{}
+	st.AddSessionHooks(hooks)
+	s := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+	subs, err := st.RegisterResources(t.Context(), s)
+	require.NoError(t, err)
+	mux := http.NewServeMux()
+	st.MountMCP(mux, s, artifactstore.WithSubscriptions(subs))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c, err := mcpclient.NewStreamableHttpClient(srv.URL + artifactstore.MCPPath)
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	uris := func() []string {
+		list, err := c.ListResources(t.Context(), mcp.ListResourcesRequest{})
+		require.NoError(t, err)
+		var uris []string
+		for _, r := range list.Resources {
+			uris = append(uris, r.URI)
+		}
+		return uris
+	}
+	assert.ElementsMatch(t, []string{"artifact://u1/docs/plan.md", "artifact:///img/logo.png"}, uris())
+
+	templates, err := c.ListResourceTemplates(t.Context(), mcp.ListResourceTemplatesRequest{})
+	require.NoError(t, err)
+	require.Len(t, templates.ResourceTemplates, 1)
+	assert.Equal(t, "artifact://{user}/{+path}", templates.ResourceTemplates[0].URITemplate.Raw())
+
+	read := func(uri string) mcp.ResourceContents {
+		req := mcp.ReadResourceRequest{}
+		req.Params.URI = uri
+		res, err := c.ReadResource(t.Context(), req)
+		require.NoError(t, err)
+		require.Len(t, res.Contents, 1)
+		return res.Contents[0]
+	}
+	text, ok := read("artifact://u1/docs/plan.md").(mcp.TextResourceContents)
+	require.True(t, ok)
+	assert.Equal(t, "# Plan", text.Text)
+	assert.Equal(t, "text/markdown", text.MIMEType)
+
+	blob, ok := read("artifact:///img/logo.png").(mcp.BlobResourceContents)
+	require.True(t, ok)
+	assert.Equal(t, "image/png", blob.MIMEType)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(png), blob.Blob)
+
+	// Artifacts without a virtual path are not listed but readable by ID
+	text, ok = read(flat.URI()).(mcp.TextResourceContents)
+	require.True(t, ok)
+	assert.Equal(t, "no path", text.Text)
+
+	// The list follows the store
+	_, err = st.Write(t.Context(), "new.md", []byte("new"), artifactstore.WriteOptions{UserID: "u1", VirtualPath: "/docs/new.md"})
+	require.NoError(t, err)
+	_, err = st.Delete(t.Context(), "/img/logo.png", artifactstore.DeleteOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"artifact://u1/docs/new.md", "artifact://u1/docs/plan.md"}, uris())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStore_BinaryTools(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir())
+	require.NoError(t, err)
+	s := server.NewMCPServer("test", "1.0.0")
+	st.RegisterTools(s)
+	c, err := mcpclient.NewInProcessClient(s)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = name
+		req.Params.Arguments = args
+		res, err := c.CallTool(t.Context(), req)
+		require.NoError(t, err)
+		return res
+	}
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00")
+	pdf := []byte("%PDF-1.7\n\xe2\xe3\xcf\xd3\n")
+	for _, f := range []struct {
+		name, mimeType string
+		data           []byte
+	}{{"logo.png", "image/png", png}, {"doc.pdf", "application/pdf", pdf}} {
+		res := call("write_artifact", map[string]any{"filename": f.name, "mime_type": f.mimeType,
+			"content": base64.StdEncoding.EncodeToString(f.data), "encoding": "base64"})
+		require.False(t, res.IsError)
+		stored, _, err := st.Read(t.Context(), f.name, artifactstore.ReadOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, f.data, stored)
+	}
+
+	res := call("read_artifact", map[string]any{"id": "logo.png"})
+	require.False(t, res.IsError)
+	require.Len(t, res.Content, 2)
+	img, ok := res.Content[1].(mcp.ImageContent)
+	require.True(t, ok)
+	assert.Equal(t, "image/png", img.MIMEType)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(png), img.Data)
+
+	res = call("read_artifact", map[string]any{"id": "doc.pdf"})
+	require.False(t, res.IsError)
+	require.Len(t, res.Content, 2)
+	embedded, ok := res.Content[1].(mcp.EmbeddedResource)
+	require.True(t, ok)
+	blob, ok := embedded.Resource.(mcp.BlobResourceContents)
+	require.True(t, ok)
+	assert.Equal(t, "application/pdf", blob.MIMEType)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pdf), blob.Blob)
+
+	// Binary content is not dumped as text unless asked for as base64
+	res = call("read_artifact", map[string]any{"id": "doc.pdf", "encoding": "utf8"})
+	assert.True(t, res.IsError)
+	res = call("read_artifact", map[string]any{"id": "doc.pdf", "encoding": "base64"})
+	require.False(t, res.IsError)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pdf), res.Content[0].(mcp.TextContent).Text)
+
+	res = call("write_artifact", map[string]any{"filename": "bad.bin", "content": "not base64!", "encoding": "base64"})
+	assert.True(t, res.IsError)
+}
+
+func TestStore_MountMCP(t *testing.T) {
+	st, err := artifactstore.Open(t.TempDir()+"/data", artifactstore.WithCredentials([]artifactstore.Credential{
+		{Token: "alice-token", Principal: artifactstore.Principal{Name: "alice", UserID: "alice"}},
+	}))
+	require.NoError(t, err)
+	hooks := &server.Hooks{}
+	st.AddSessionHooks(hooks)
+	s := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+	st.RegisterTools(s)
+
+	mux := http.NewServeMux()
+	st.Mount(mux)
+	st.MountMCP(mux, s, artifactstore.WithAllowedOrigins("https://app.example.com"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	auth := transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer alice-token"})
+
+	// Streamable HTTP, and a second connection resuming the session
+	c, err := mcpclient.NewStreamableHttpClient(srv.URL+artifactstore.MCPPath, auth)
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "write_artifact"
+	req.Params.Arguments = map[string]any{"filename": "plan.md", "content": "# Plan", "virtual_path": "/plan.md"}
+	res, err := c.CallTool(t.Context(), req)
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	_, meta, err := st.Read(t.Context(), "/plan.md", artifactstore.ReadOptions{UserID: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", meta.UserID)
+
+	resumed, err := mcpclient.NewStreamableHttpClient(srv.URL+artifactstore.MCPPath, auth,
+		transport.WithSession(c.GetSessionId()))
+	require.NoError(t, err)
+	defer resumed.Close()
+	require.NoError(t, resumed.Start(t.Context()))
+	tools, err := resumed.ListTools(t.Context(), mcp.ListToolsRequest{})
+	require.NoError(t, err, "no new initialize needed")
+	assert.NotEmpty(t, tools.Tools)
+
+	// Legacy SSE on the same listener
+	sse, err := mcpclient.NewSSEMCPClient(srv.URL+artifactstore.MCPSSEPath, mcpclient.WithHeaders(map[string]string{"Authorization": "Bearer alice-token"}))
+	require.NoError(t, err)
+	defer sse.Close()
+	require.NoError(t, sse.Start(t.Context()))
+	_, err = sse.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	// Credentials and origins are checked
+	post := func(origin, token string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+artifactstore.MCPPath, nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, post("https://app.example.com", ""))
+	assert.Equal(t, http.StatusForbidden, post("http://evil.example", "alice-token"))
+}
@@ -27,20 +27,31 @@
 // Artifacts can be "global" (accessible to everyone) or scoped to a "UserID".
 // If a UserID is provided (via environment or options), the server ensures
 // that operations are restricted to that user's private storage area.
+//
+// # Tracing
+//
+// Every call starts an OpenTelemetry client span using the global tracer
+// provider and propagates the W3C trace context to the server, so artifact
+// operations appear in the caller's traces once OpenTelemetry is configured.
 package client
 
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"iter"
 	"net"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	connect "connectrpc.com/connect"
+	"connectrpc.com/otelconnect"
 	pb "github.com/hmsoft0815/mlcartifact/proto"
 	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
 	"golang.org/x/net/http2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 )
 
 // Version is the current version of the library.
@@ -105,10 +116,17 @@ func NewClientWithAddr(addr string, opts ...ClientOption) (*Client, error) {
 
 	return &Client{
 		httpClient: settings.httpClient,
-		cli:        protoconnect.NewArtifactServiceClient(settings.httpClient, baseURL),
+		cli:        protoconnect.NewArtifactServiceClient(settings.httpClient, baseURL, connect.WithInterceptors(tracingInterceptor())),
 	}, nil
 }
 
+// tracingInterceptor traces calls with the global OpenTelemetry provider.
+func tracingInterceptor() connect.Interceptor {
+	// NewInterceptor only fails on invalid options
+	i, _ := otelconnect.NewInterceptor(otelconnect.WithoutMetrics())
+	return i
+}
+
 // NewClientWithService creates a client wrapping an existing service implementation.
 // This is primarily used for unit testing with mocked services.
 func NewClientWithService(service protoconnect.ArtifactServiceClient) *Client {
@@ -206,6 +224,145 @@ func (c *Client) Delete(ctx context.Context, idOrFilename string, opts ...Delete
 	return res.Msg, nil
 }
 
+// CreateShareLink returns a signed, expiring HTTP URL for an artifact that
+// can be opened by humans without a Connect client.
+//
+// The link lifetime defaults to one hour on the server and never exceeds the
+// artifact's own expiry. Use [WithShareExpiresMinutes] to change it.
+func (c *Client) CreateShareLink(ctx context.Context, idOrFilename string, opts ...ShareOption) (*pb.CreateShareLinkResponse, error) {
+	req := &pb.CreateShareLinkRequest{
+		Id:     idOrFilename,
+		UserId: os.Getenv("ARTIFACT_USER_ID"),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	res, err := c.cli.CreateShareLink(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return res.Msg, nil
+}
+
+// SetExpiry changes the time-to-live of an artifact to the given number of
+// hours from now.
+func (c *Client) SetExpiry(ctx context.Context, idOrFilename string, hours int32, opts ...ExpiryOption) (*pb.SetExpiryResponse, error) {
+	req := &pb.SetExpiryRequest{
+		Id:           idOrFilename,
+		UserId:       os.Getenv("ARTIFACT_USER_ID"),
+		ExpiresHours: hours,
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	res, err := c.cli.SetExpiry(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return res.Msg, nil
+}
+
+// Watch streams change events ("created", "patched", "deleted", "expired")
+// for the artifacts of a user scope, so that pipelines do not need to poll
+// List. Iteration ends when ctx is cancelled or the stream fails; in the
+// latter case the error is yielded as the last element.
+//
+// To resume after a reconnect without missing events, pass the Cursor of the
+// last received event via [WithWatchCursor]. If the server no longer knows
+// the cursor (e.g. after a restart), the stream fails with
+// connect.CodeOutOfRange and the consumer should re-list instead.
+//
+//	for ev, err := range cli.Watch(ctx, client.WithWatchPathPrefix("/projects/alpha")) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(ev.Type, ev.Artifact.VirtualPath)
+//	}
+func (c *Client) Watch(ctx context.Context, opts ...WatchOption) iter.Seq2[*pb.WatchEvent, error] {
+	req := &pb.WatchRequest{
+		UserId: os.Getenv("ARTIFACT_USER_ID"),
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	return func(yield func(*pb.WatchEvent, error) bool) {
+		stream, err := c.cli.Watch(ctx, connect.NewRequest(req))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer stream.Close()
+
+		for stream.Receive() {
+			if !yield(stream.Msg(), nil) {
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// QueryAudit returns the newest audit log entries matching the options,
+// oldest first. The server only allows admins to query the audit log.
+func (c *Client) QueryAudit(ctx context.Context, opts ...AuditOption) (*pb.QueryAuditResponse, error) {
+	req := &pb.QueryAuditRequest{}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	res, err := c.cli.QueryAudit(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return res.Msg, nil
+}
+
+// ErrorReason returns the machine-readable reason the server attached to err,
+// such as "NOT_FOUND", "ALREADY_EXISTS", "INVALID_PATH" or "QUOTA_EXCEEDED".
+// It returns "" if err carries no reason, e.g. for network errors; use
+// connect.CodeOf for the coarser RPC status code.
+func ErrorReason(err error) string {
+	var ce *connect.Error
+	if !errors.As(err, &ce) {
+		return ""
+	}
+	for _, detail := range ce.Details() {
+		msg, derr := detail.Value()
+		if derr != nil {
+			continue
+		}
+		if info, ok := msg.(*errdetails.ErrorInfo); ok && info.Domain == pb.ErrorDomain {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// RetryDelay returns how long the server asked to wait before retrying a
+// call that failed with ResourceExhausted because of a rate limit. It returns
+// false if err carries no retry hint.
+func RetryDelay(err error) (time.Duration, bool) {
+	var ce *connect.Error
+	if !errors.As(err, &ce) {
+		return 0, false
+	}
+	for _, detail := range ce.Details() {
+		msg, derr := detail.Value()
+		if derr != nil {
+			continue
+		}
+		if info, ok := msg.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
 // WriteOption is a functional option for configuring Write requests.
 type WriteOption func(*pb.WriteRequest)
 
@@ -294,3 +451,114 @@ func WithDeleteUserID(id string) DeleteOption {
 		r.UserId = id
 	}
 }
+
+// ShareOption is a functional option for configuring CreateShareLink requests.
+type ShareOption func(*pb.CreateShareLinkRequest)
+
+// WithShareExpiresMinutes sets the lifetime of the link in minutes.
+func WithShareExpiresMinutes(m int32) ShareOption {
+	return func(r *pb.CreateShareLinkRequest) {
+		r.ExpiresMinutes = m
+	}
+}
+
+// WithShareUserID specifies the user ID for the share operation.
+func WithShareUserID(id string) ShareOption {
+	return func(r *pb.CreateShareLinkRequest) {
+		r.UserId = id
+	}
+}
+
+// ExpiryOption is a functional option for configuring SetExpiry requests.
+type ExpiryOption func(*pb.SetExpiryRequest)
+
+// WithExpiryUserID specifies the user ID for the expiry update.
+func WithExpiryUserID(id string) ExpiryOption {
+	return func(r *pb.SetExpiryRequest) {
+		r.UserId = id
+	}
+}
+
+// WatchOption is a functional option for configuring Watch requests.
+type WatchOption func(*pb.WatchRequest)
+
+// WithWatchUserID specifies the user scope to watch.
+func WithWatchUserID(id string) WatchOption {
+	return func(r *pb.WatchRequest) {
+		r.UserId = id
+	}
+}
+
+// WithWatchPathPrefix only delivers events for artifacts at or below the
+// given virtual path.
+func WithWatchPathPrefix(prefix string) WatchOption {
+	return func(r *pb.WatchRequest) {
+		r.PathPrefix = prefix
+	}
+}
+
+// WithWatchSource only delivers events for artifacts from the given source.
+func WithWatchSource(source string) WatchOption {
+	return func(r *pb.WatchRequest) {
+		r.Source = source
+	}
+}
+
+// WithWatchCursor resumes the stream right after the event with this cursor.
+func WithWatchCursor(cursor string) WatchOption {
+	return func(r *pb.WatchRequest) {
+		r.Cursor = cursor
+	}
+}
+
+// AuditOption is a functional option for configuring QueryAudit requests.
+type AuditOption func(*pb.QueryAuditRequest)
+
+// WithAuditArtifact only returns entries for an artifact ID or virtual path.
+func WithAuditArtifact(idOrPath string) AuditOption {
+	return func(r *pb.QueryAuditRequest) {
+		r.ArtifactId = idOrPath
+	}
+}
+
+// WithAuditScope only returns entries for a user scope ("global" for the
+// global scope).
+func WithAuditScope(scope string) AuditOption {
+	return func(r *pb.QueryAuditRequest) {
+		r.Scope = scope
+	}
+}
+
+// WithAuditPrincipal only returns entries of an authenticated caller.
+func WithAuditPrincipal(name string) AuditOption {
+	return func(r *pb.QueryAuditRequest) {
+		r.Principal = name
+	}
+}
+
+// WithAuditOperation only returns entries of an operation, e.g. "Delete".
+func WithAuditOperation(op string) AuditOption {
+	return func(r *pb.QueryAuditRequest) {
+		r.Operation = op
+	}
+}
+
+// WithAuditTimeRange only returns entries between since and until. Zero
+// values leave the range open.
+func WithAuditTimeRange(since, until time.Time) AuditOption {
+	return func(r *pb.QueryAuditRequest) {
+		if !since.IsZero() {
+			r.Since = since.Format(time.RFC3339)
+		}
+		if !until.IsZero() {
+			r.Until = until.Format(time.RFC3339)
+		}
+	}
+}
+
+// WithAuditLimit returns at most the newest n entries (server default 100).
+func WithAuditLimit(n int32) AuditOption {
+	return func(r *pb.QueryAuditRequest) {
+		r.Limit = n
+	}
+}
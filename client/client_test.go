@@ -2,6 +2,9 @@ package client
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	connect "connectrpc.com/connect"
@@ -41,6 +44,25 @@ func (m *mockArtifactClient) Delete(ctx context.Context, req *connect.Request[pb
 	return connect.NewResponse(&pb.DeleteResponse{}), nil
 }
 
+func (m *mockArtifactClient) Watch(ctx context.Context, req *connect.Request[pb.WatchRequest]) (*connect.ServerStreamForClient[pb.WatchEvent], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("not implemented"))
+}
+
+type watchHandler struct {
+	protoconnect.UnimplementedArtifactServiceHandler
+	lastWatch *pb.WatchRequest
+}
+
+func (h *watchHandler) Watch(ctx context.Context, req *connect.Request[pb.WatchRequest], stream *connect.ServerStream[pb.WatchEvent]) error {
+	h.lastWatch = req.Msg
+	for _, typ := range []string{"created", "patched"} {
+		if err := stream.Send(&pb.WatchEvent{Type: typ, Cursor: "c-" + typ}); err != nil {
+			return err
+		}
+	}
+	return connect.NewError(connect.CodeUnavailable, errors.New("watcher fell behind"))
+}
+
 func TestClient(t *testing.T) {
 	mockCli := &mockArtifactClient{}
 	client := NewClientWithService(mockCli)
@@ -58,3 +80,27 @@ func TestClient(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, []byte("read-data"), readRes.Content)
 }
+
+func TestClient_Watch(t *testing.T) {
+	h := &watchHandler{}
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewArtifactServiceHandler(h))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClientWithService(protoconnect.NewArtifactServiceClient(srv.Client(), srv.URL))
+
+	var types []string
+	var lastErr error
+	for ev, err := range client.Watch(context.Background(), WithWatchPathPrefix("/projects"), WithWatchCursor("c-0")) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		types = append(types, ev.Type)
+	}
+	assert.Equal(t, []string{"created", "patched"}, types)
+	assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(lastErr))
+	assert.Equal(t, "/projects", h.lastWatch.PathPrefix)
+	assert.Equal(t, "c-0", h.lastWatch.Cursor)
+}
@@ -51,6 +51,10 @@ func main() {
 		handleCreate(cli, flag.Args()[1:])
 	case "download":
 		handleDownload(cli, flag.Args()[1:])
+	case "share":
+		handleShare(cli, flag.Args()[1:])
+	case "audit":
+		handleAudit(cli, flag.Args()[1:])
 	default:
 		fmt.Printf("Unknown command: %s\n", cmd)
 		usage()
@@ -67,6 +71,8 @@ func usage() {
 	fmt.Println("  delete <id> [--user ID]")
 	fmt.Println("  create <file> [--name NAME] [--description DESC] [--user ID] [--expires HOURS]")
 	fmt.Println("  download <id/filename> <local-path> [--user ID]")
+	fmt.Println("  share <id/filename> [--expires MINUTES] [--user ID]")
+	fmt.Println("  audit [--id ID/PATH] [--scope ID] [--principal NAME] [--op OPERATION] [--since DURATION] [--limit N]")
 }
 
 func handleList(cli *client.Client, args []string) {
@@ -188,3 +194,73 @@ func handleDownload(cli *client.Client, args []string) {
 
 	fmt.Printf("Successfully downloaded %s (%s) to %s\n", res.Filename, res.MimeType, dest)
 }
+
+func handleShare(cli *client.Client, args []string) {
+	fs := flag.NewFlagSet("share", flag.ExitOnError)
+	user := fs.String("user", "", "Scope to user ID")
+	expires := fs.Int("expires", 60, "Link lifetime in minutes")
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Parse error: %v", err)
+	}
+
+	if fs.NArg() < 1 {
+		log.Fatal("Artifact ID required")
+	}
+	id := fs.Arg(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := cli.CreateShareLink(ctx, id,
+		client.WithShareUserID(*user),
+		client.WithShareExpiresMinutes(int32(*expires)),
+	)
+	if err != nil {
+		log.Fatalf("Share failed: %v", err)
+	}
+
+	fmt.Printf("Share link for %s (%s), valid until %s:\n%s\n", res.Filename, res.MimeType, res.ExpiresAt, res.Url)
+}
+
+func handleAudit(cli *client.Client, args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	id := fs.String("id", "", "Filter by artifact ID or virtual path")
+	scope := fs.String("scope", "", "Filter by user scope (\"global\" for the global scope)")
+	principal := fs.String("principal", "", "Filter by principal")
+	op := fs.String("op", "", "Filter by operation, e.g. Read or Delete")
+	since := fs.Duration("since", 0, "Only entries newer than this, e.g. 24h")
+	limit := fs.Int("limit", 100, "Show at most the newest N entries")
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Parse error: %v", err)
+	}
+
+	var from time.Time
+	if *since > 0 {
+		from = time.Now().Add(-*since)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := cli.QueryAudit(ctx,
+		client.WithAuditArtifact(*id),
+		client.WithAuditScope(*scope),
+		client.WithAuditPrincipal(*principal),
+		client.WithAuditOperation(*op),
+		client.WithAuditTimeRange(from, time.Time{}),
+		client.WithAuditLimit(int32(*limit)),
+	)
+	if err != nil {
+		log.Fatalf("Audit query failed: %v", err)
+	}
+
+	fmt.Printf("%-25s %-16s %-12s %-10s %-15s %-30s %-10s %s\n", "Time", "Operation", "Principal", "Scope", "ID", "Path", "Bytes", "Outcome")
+	fmt.Println(strings.Repeat("-", 130))
+	for _, e := range res.Entries {
+		scope := e.UserId
+		if scope == "" {
+			scope = "global"
+		}
+		fmt.Printf("%-25s %-16s %-12s %-10s %-15s %-30s %-10d %s\n", e.Time[:min(len(e.Time), 25)], e.Operation, e.Principal, scope, e.ArtifactId, e.VirtualPath, e.Bytes, e.Outcome)
+	}
+}
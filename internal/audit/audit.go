@@ -0,0 +1,283 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package audit records an append-only log of artifact operations.
+//
+// Every entry is one JSON object per line in audit.jsonl inside the log
+// directory. When the file grows beyond the configured size it is renamed to
+// audit-<timestamp>.jsonl and a new file is started; the oldest rotated files
+// are removed once more than the configured number exist. Query reads the
+// rotated files and the current file in chronological order.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+)
+
+const (
+	currentFile   = "audit.jsonl"
+	rotatedPrefix = "audit-"
+	rotatedSuffix = ".jsonl"
+)
+
+// Outcomes of an operation.
+const (
+	OutcomeOK       = "ok"
+	OutcomeNotFound = "not_found"
+	OutcomeDenied   = "denied"
+	OutcomeInvalid  = "invalid" // Rejected request, e.g. a bad path or an expired cursor
+	OutcomeError    = "error"
+)
+
+// Entry is a single audited operation.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Operation   string    `json:"operation"`             // RPC or store operation, e.g. "Read"
+	Principal   string    `json:"principal"`             // Authenticated caller
+	UserID      string    `json:"user_id,omitempty"`     // User scope ("" = global)
+	ArtifactID  string    `json:"artifact_id,omitempty"` // Resolved artifact ID, or the requested ID/path
+	VirtualPath string    `json:"virtual_path,omitempty"`
+	Source      string    `json:"source,omitempty"` // Source server of the artifact or request
+	Bytes       int64     `json:"bytes,omitempty"`  // Bytes read or written
+	Outcome     string    `json:"outcome"`          // ok, not_found, denied, invalid or error
+	Error       string    `json:"error,omitempty"`
+}
+
+// Filter selects entries in Query. Zero fields match everything.
+type Filter struct {
+	ArtifactID string // Matches the artifact ID or virtual path
+	UserID     string // User scope, "global" for the global scope
+	Principal  string
+	Operation  string
+	Since      time.Time
+	Until      time.Time
+	Limit      int // Return at most the newest Limit entries
+}
+
+func (f Filter) match(e *Entry) bool {
+	if f.ArtifactID != "" && e.ArtifactID != f.ArtifactID && e.VirtualPath != f.ArtifactID {
+		return false
+	}
+	if f.UserID != "" && e.UserID != f.UserID && !(f.UserID == "global" && e.UserID == "") {
+		return false
+	}
+	if f.Principal != "" && e.Principal != f.Principal {
+		return false
+	}
+	if f.Operation != "" && !strings.EqualFold(e.Operation, f.Operation) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Logger appends entries to a rotating JSONL file. It is safe for concurrent use.
+type Logger struct {
+	dir      string
+	maxBytes int64
+	maxFiles int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+
+	observedMu sync.Mutex
+	observed   map[*storage.Store]bool
+}
+
+// Option is a functional option for configuring the Logger.
+type Option func(*Logger)
+
+// WithMaxBytes sets the size at which the log file is rotated (default 10 MiB).
+func WithMaxBytes(n int64) Option {
+	return func(l *Logger) {
+		l.maxBytes = n
+	}
+}
+
+// WithMaxFiles sets how many rotated files are kept (default 10, 0 keeps all).
+func WithMaxFiles(n int) Option {
+	return func(l *Logger) {
+		l.maxFiles = n
+	}
+}
+
+// NewLogger opens or creates the audit log in dir.
+func NewLogger(dir string, opts ...Option) (*Logger, error) {
+	l := &Logger{dir: dir, maxBytes: 10 << 20, maxFiles: 10}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) open() error {
+	f, err := os.OpenFile(filepath.Join(l.dir, currentFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// Log appends an entry. The time is set to now if it is zero. Log is a no-op
+// on a nil Logger, so callers do not need to check whether auditing is enabled.
+func (l *Logger) Log(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+	if l.size > 0 && l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate renames the current file and starts a new one. The caller must hold l.mu.
+func (l *Logger) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	l.f = nil
+
+	name := rotatedPrefix + time.Now().UTC().Format("20060102T150405.000000000") + rotatedSuffix
+	if err := os.Rename(filepath.Join(l.dir, currentFile), filepath.Join(l.dir, name)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	if rotated, err := l.rotatedFiles(); err == nil && l.maxFiles > 0 && len(rotated) > l.maxFiles {
+		for _, old := range rotated[:len(rotated)-l.maxFiles] {
+			_ = os.Remove(old)
+		}
+	}
+	return l.open()
+}
+
+// rotatedFiles returns the rotated log files, oldest first.
+func (l *Logger) rotatedFiles() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), rotatedPrefix) && strings.HasSuffix(e.Name(), rotatedSuffix) {
+			files = append(files, filepath.Join(l.dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Query returns the entries matching f in chronological order. With a limit,
+// only the newest f.Limit matches are returned. Writes wait while a query
+// runs, so that it sees a consistent set of files.
+func (l *Logger) Query(f Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, filepath.Join(l.dir, currentFile))
+
+	var results []Entry
+	for _, path := range files {
+		if err := scanFile(path, func(e *Entry) {
+			if f.match(e) {
+				results = append(results, *e)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Limit > 0 && len(results) > f.Limit {
+		results = results[len(results)-f.Limit:]
+	}
+	return results, nil
+}
+
+func scanFile(path string, fn func(*Entry)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // Skip a torn last line after a crash
+		}
+		fn(&e)
+	}
+	return scanner.Err()
+}
+
+// Close closes the log file.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
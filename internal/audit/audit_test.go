@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_LogQuery(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(dir)
+	require.NoError(t, err)
+
+	t0 := time.Now().Add(-time.Hour)
+	require.NoError(t, l.Log(Entry{Time: t0, Operation: "Write", Principal: "alice", UserID: "alice", ArtifactID: "a1", VirtualPath: "/x.txt", Bytes: 3, Outcome: OutcomeOK}))
+	require.NoError(t, l.Log(Entry{Operation: "Read", Principal: "bob", ArtifactID: "a1", Outcome: OutcomeOK}))
+	require.NoError(t, l.Log(Entry{Operation: "Delete", Principal: "alice", UserID: "alice", ArtifactID: "a2", Outcome: OutcomeNotFound}))
+
+	all, err := l.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "Write", all[0].Operation)
+	assert.False(t, all[1].Time.IsZero())
+
+	byPath, err := l.Query(Filter{ArtifactID: "/x.txt"})
+	require.NoError(t, err)
+	assert.Len(t, byPath, 1)
+
+	global, err := l.Query(Filter{UserID: "global"})
+	require.NoError(t, err)
+	require.Len(t, global, 1)
+	assert.Equal(t, "bob", global[0].Principal)
+
+	recent, err := l.Query(Filter{Principal: "alice", Since: t0.Add(time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, "Delete", recent[0].Operation)
+
+	newest, err := l.Query(Filter{Operation: "read", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, newest, 1)
+
+	// Entries survive reopening the log
+	require.NoError(t, l.Close())
+	l, err = NewLogger(dir)
+	require.NoError(t, err)
+	defer l.Close()
+	all, err = l.Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestLogger_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(dir, WithMaxBytes(200), WithMaxFiles(2))
+	require.NoError(t, err)
+	defer l.Close()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, l.Log(Entry{Operation: "Read", Principal: "p", ArtifactID: "id", Outcome: OutcomeOK}))
+	}
+
+	rotated, err := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+	require.NoError(t, err)
+	assert.Len(t, rotated, 2)
+	info, err := os.Stat(filepath.Join(dir, "audit.jsonl"))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(200))
+
+	// Only the retained files are queried, newest last
+	entries, err := l.Query(Filter{})
+	require.NoError(t, err)
+	assert.Less(t, len(entries), 20)
+	assert.NotEmpty(t, entries)
+
+	var nilLogger *Logger
+	assert.NoError(t, nilLogger.Log(Entry{}))
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+)
+
+// Observe records every operation on the artifacts of store, whichever
+// front end started it: Connect RPCs, MCP tools, the HTTP API, WebDAV and
+// share link downloads, see storage.Store.OnOperation and Report. The principal is taken from the context of the
+// operation, "anonymous" if there is none. Observing a store again has no
+// effect.
+func (l *Logger) Observe(store *storage.Store) {
+	if l == nil || store == nil {
+		return
+	}
+	l.observedMu.Lock()
+	defer l.observedMu.Unlock()
+	if l.observed[store] {
+		return
+	}
+	if l.observed == nil {
+		l.observed = make(map[*storage.Store]bool)
+	}
+	l.observed[store] = true
+	store.OnOperation(l.record)
+}
+
+// record logs a store operation.
+func (l *Logger) record(ctx context.Context, op storage.Operation) {
+	e := Entry{
+		Operation:  op.Name,
+		Principal:  auth.FromContext(ctx).Name,
+		UserID:     op.UserID,
+		ArtifactID: op.Ref,
+		Source:     op.Source,
+		Bytes:      op.Bytes,
+		Outcome:    Outcome(op.Err),
+	}
+	switch {
+	case op.Name == "List" || op.Name == "Find" || op.Name == "Watch":
+		e.ArtifactID, e.VirtualPath = "", op.Ref
+	case op.Artifact != nil:
+		e.ArtifactID, e.VirtualPath = op.Artifact.ID, op.Artifact.VirtualPath
+	}
+	if op.Err != nil {
+		e.Error = op.Err.Error()
+	}
+	if err := l.Log(e); err != nil {
+		slog.ErrorContext(ctx, "Failed to write audit log", "error", err)
+	}
+}
+
+// Outcome returns the outcome of an operation that failed with err, which
+// may be nil.
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		return OutcomeNotFound
+	case errors.Is(err, auth.ErrForbidden), errors.Is(err, auth.ErrUnauthenticated):
+		return OutcomeDenied
+	case errors.Is(err, storage.ErrCursorExpired):
+		return OutcomeInvalid
+	}
+	switch storage.Reason(err) {
+	case storage.ReasonInvalidPath, storage.ReasonInvalidArgument, storage.ReasonAlreadyExists, storage.ReasonConflict, storage.ReasonQuotaExceeded:
+		return OutcomeInvalid
+	}
+	return OutcomeError
+}
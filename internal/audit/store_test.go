@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Observe(t *testing.T) {
+	l, err := NewLogger(t.TempDir())
+	require.NoError(t, err)
+	defer l.Close()
+
+	store := storage.NewStore(t.TempDir())
+	l.Observe(store)
+	l.Observe(store) // no duplicate entries
+
+	alice := auth.NewContext(context.Background(), &auth.Principal{Name: "alice", UserID: "alice"})
+	meta, err := store.Write(alice, "a.md", []byte("# A"), storage.WriteOptions{UserID: "alice", Source: "agent", VirtualPath: "/docs/a.md"})
+	require.NoError(t, err)
+	f, _, err := store.Open(auth.NewContext(context.Background(), &auth.Principal{Name: "share-link"}), meta.ID, storage.OpenOptions{UserID: "alice"})
+	require.NoError(t, err)
+	f.Close()
+	_, err = store.ListVFS(alice, "/docs", storage.ListVFSOptions{UserID: "alice"})
+	require.NoError(t, err)
+	require.NoError(t, store.Move(alice, "/docs/a.md", "/docs/b.md", storage.MoveOptions{UserID: "alice"}))
+	_, err = store.DeleteTree(alice, "/docs", storage.DeleteTreeOptions{UserID: "alice"})
+	require.NoError(t, err)
+	ok, err := store.Delete(alice, meta.ID, storage.DeleteOptions{UserID: "alice"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = store.Write(alice, "../x", []byte("x"), storage.WriteOptions{UserID: "../bob"})
+	require.Error(t, err)
+	store.Report(alice, storage.Operation{Name: "Watch", Ref: "/docs", UserID: "alice"}, storage.ErrCursorExpired)
+
+	entries, err := l.Query(Filter{})
+	require.NoError(t, err)
+	var ops []string
+	for _, e := range entries {
+		ops = append(ops, e.Operation+":"+e.Outcome)
+	}
+	assert.Equal(t, []string{"Write:ok", "Read:ok", "List:ok", "Move:ok", "Delete:ok", "Delete:not_found", "Write:invalid", "Watch:invalid"}, ops)
+
+	write, read, list, move := entries[0], entries[1], entries[2], entries[3]
+	assert.Equal(t, "alice", write.Principal)
+	assert.Equal(t, meta.ID, write.ArtifactID)
+	assert.Equal(t, "/docs/a.md", write.VirtualPath)
+	assert.Equal(t, "agent", write.Source)
+	assert.Equal(t, int64(3), write.Bytes)
+	assert.Equal(t, "share-link", read.Principal)
+	assert.Equal(t, "/docs", list.VirtualPath)
+	assert.Equal(t, "/docs/b.md", move.VirtualPath)
+	assert.Equal(t, meta.ID, entries[4].ArtifactID)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package auth resolves the caller of an HTTP or Connect request to a
+// Principal and enforces user scopes.
+//
+// Credentials are static tokens loaded from a JSON file. Clients present them
+// either as "Authorization: Bearer <token>" or via HTTP Basic authentication
+// with the principal name as username and the token as password.
+//
+// A nil *Authenticator disables authentication: every caller is treated as an
+// anonymous administrator and may choose any user scope, which matches the
+// behaviour of a server without authentication configured.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrUnauthenticated is returned when no valid credentials were presented.
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	// ErrForbidden is returned when a principal requests a scope it may not access.
+	ErrForbidden = errors.New("access to user scope denied")
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Name   string `json:"name"`              // Display name, also the Basic auth username
+	UserID string `json:"user_id,omitempty"` // Scope the principal is bound to ("" = global)
+	Admin  bool   `json:"admin,omitempty"`   // Admins may access every user scope
+}
+
+// Anonymous is the principal used when authentication is disabled.
+var Anonymous = &Principal{Name: "anonymous", Admin: true}
+
+// ResolveScope returns the user scope a request should operate on.
+// Admins get the requested scope. Other principals are bound to their own
+// UserID; an empty request selects it, any other value is rejected.
+func (p *Principal) ResolveScope(requested string) (string, error) {
+	if p.Admin {
+		return requested, nil
+	}
+	if requested == "" || requested == p.UserID {
+		return p.UserID, nil
+	}
+	return "", ErrForbidden
+}
+
+// Credential binds a secret token to a principal.
+type Credential struct {
+	Token string `json:"token"`
+	Principal
+}
+
+// Authenticator validates request credentials.
+type Authenticator struct {
+	creds []Credential
+}
+
+// NewAuthenticator creates an Authenticator for the given credentials.
+func NewAuthenticator(creds []Credential) *Authenticator {
+	return &Authenticator{creds: creds}
+}
+
+// LoadFile reads credentials from a JSON file of the form
+//
+//	{"credentials": [{"token": "...", "name": "alice", "user_id": "alice", "admin": false}]}
+func LoadFile(path string) (*Authenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Credentials []Credential `json:"credentials"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid credentials file: %w", err)
+	}
+	for i, c := range cfg.Credentials {
+		if c.Token == "" || c.Name == "" {
+			return nil, fmt.Errorf("invalid credentials file: entry %d needs a name and a token", i)
+		}
+	}
+	return NewAuthenticator(cfg.Credentials), nil
+}
+
+// Authenticate resolves the principal from the Authorization header.
+func (a *Authenticator) Authenticate(h http.Header) (*Principal, error) {
+	if a == nil {
+		return Anonymous, nil
+	}
+
+	authz := h.Get("Authorization")
+	name, token := "", ""
+	switch {
+	case strings.HasPrefix(authz, "Bearer "):
+		token = strings.TrimPrefix(authz, "Bearer ")
+	case strings.HasPrefix(authz, "Basic "):
+		r := &http.Request{Header: http.Header{"Authorization": {authz}}}
+		var ok bool
+		if name, token, ok = r.BasicAuth(); !ok {
+			return nil, ErrUnauthenticated
+		}
+	default:
+		return nil, ErrUnauthenticated
+	}
+
+	for i := range a.creds {
+		c := &a.creds[i]
+		if subtle.ConstantTimeCompare([]byte(c.Token), []byte(token)) == 1 && (name == "" || name == c.Name) {
+			p := c.Principal
+			return &p, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
+
+// Middleware authenticates HTTP requests and stores the principal in the
+// request context. Unauthenticated requests receive a 401 with a Basic
+// challenge, so browsers prompt for credentials.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r.Header)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mlcartifact", charset="UTF-8"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+	})
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, or Anonymous if there is none.
+func FromContext(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(contextKey{}).(*Principal); ok {
+		return p
+	}
+	return Anonymous
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCreds = []Credential{
+	{Token: "alice-token", Principal: Principal{Name: "alice", UserID: "alice"}},
+	{Token: "root-token", Principal: Principal{Name: "root", Admin: true}},
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	a := NewAuthenticator(testCreds)
+
+	p, err := a.Authenticate(http.Header{"Authorization": {"Bearer alice-token"}})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", p.UserID)
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("root", "root-token")
+	p, err = a.Authenticate(req.Header)
+	require.NoError(t, err)
+	assert.True(t, p.Admin)
+
+	// Basic auth username must match the token's principal
+	req.SetBasicAuth("alice", "root-token")
+	_, err = a.Authenticate(req.Header)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = a.Authenticate(http.Header{"Authorization": {"Bearer nope"}})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	_, err = a.Authenticate(http.Header{})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	// A nil authenticator disables authentication
+	var disabled *Authenticator
+	p, err = disabled.Authenticate(http.Header{})
+	require.NoError(t, err)
+	assert.Same(t, Anonymous, p)
+}
+
+func TestPrincipal_ResolveScope(t *testing.T) {
+	alice := &testCreds[0].Principal
+	scope, err := alice.ResolveScope("")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", scope)
+
+	_, err = alice.ResolveScope("bob")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	root := &testCreds[1].Principal
+	scope, err = root.ResolveScope("bob")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", scope)
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "creds.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"credentials":[{"token":"t","name":"n","user_id":"u"}]}`), 0600))
+
+	a, err := LoadFile(path)
+	require.NoError(t, err)
+	p, err := a.Authenticate(http.Header{"Authorization": {"Bearer t"}})
+	require.NoError(t, err)
+	assert.Equal(t, "u", p.UserID)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"credentials":[{"token":""}]}`), 0600))
+	_, err = LoadFile(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package auth
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Interceptor returns a Connect interceptor that authenticates every call and
+// rewrites the "user_id" field of incoming messages to the scope the caller
+// is allowed to use. Requests for a foreign scope fail with PermissionDenied.
+func (a *Authenticator) Interceptor() connect.Interceptor {
+	return &interceptor{auth: a}
+}
+
+type interceptor struct {
+	auth *Authenticator
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		p, err := i.auth.Authenticate(req.Header())
+		if err != nil {
+			return nil, connect.NewError(connect.CodeUnauthenticated, err)
+		}
+		if msg, ok := req.Any().(proto.Message); ok {
+			if err := ScopeMessage(msg, p); err != nil {
+				return nil, connect.NewError(connect.CodePermissionDenied, err)
+			}
+		}
+		return next(NewContext(ctx, p), req)
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		p, err := i.auth.Authenticate(conn.RequestHeader())
+		if err != nil {
+			return connect.NewError(connect.CodeUnauthenticated, err)
+		}
+		return next(NewContext(ctx, p), &scopedConn{StreamingHandlerConn: conn, principal: p})
+	}
+}
+
+// scopedConn applies ScopeMessage to every message received on a stream.
+type scopedConn struct {
+	connect.StreamingHandlerConn
+	principal *Principal
+}
+
+func (c *scopedConn) Receive(msg any) error {
+	if err := c.StreamingHandlerConn.Receive(msg); err != nil {
+		return err
+	}
+	if m, ok := msg.(proto.Message); ok {
+		if err := ScopeMessage(m, c.principal); err != nil {
+			return connect.NewError(connect.CodePermissionDenied, err)
+		}
+	}
+	return nil
+}
+
+// ScopeMessage resolves the "user_id" string field of msg, if present,
+// through p.ResolveScope and stores the result back into the message.
+func ScopeMessage(msg proto.Message, p *Principal) error {
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName("user_id")
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+		return nil
+	}
+	scope, err := p.ResolveScope(m.Get(fd).String())
+	if err != nil {
+		return err
+	}
+	m.Set(fd, protoreflect.ValueOfString(scope))
+	return nil
+}
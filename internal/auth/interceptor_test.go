@@ -0,0 +1,57 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/grpc"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	pb "github.com/hmsoft0815/mlcartifact/proto"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptor_ScopesRequests(t *testing.T) {
+	store := storage.NewStore(t.TempDir())
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "alice-token", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+		{Token: "root-token", Principal: auth.Principal{Name: "root", Admin: true}},
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewArtifactServiceHandler(grpc.NewConnectServer(store), connect.WithInterceptors(a.Interceptor())))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cli := protoconnect.NewArtifactServiceClient(srv.Client(), srv.URL)
+	ctx := context.Background()
+
+	write := func(token, userID string) error {
+		req := connect.NewRequest(&pb.WriteRequest{Filename: "a.txt", Content: []byte("x"), UserId: userID})
+		if token != "" {
+			req.Header().Set("Authorization", "Bearer "+token)
+		}
+		_, err := cli.Write(ctx, req)
+		return err
+	}
+
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(write("", "")))
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(write("alice-token", "bob")))
+
+	// An empty user_id is bound to the principal's own scope
+	require.NoError(t, write("alice-token", ""))
+	items, err := store.List(t.Context(), storage.ListOptions{UserID: "alice"})
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	// Admins may write into any scope
+	require.NoError(t, write("root-token", "bob"))
+	items, err = store.List(t.Context(), storage.ListOptions{UserID: "bob"})
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
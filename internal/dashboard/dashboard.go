@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package dashboard serves the embedded web dashboard for browsing and
+// managing artifacts.
+//
+// The dashboard is a static single-page application compiled into the server
+// binary. It talks to the ArtifactService through the Connect JSON protocol,
+// so it must be mounted on the same listener as the Connect handler. When
+// authentication is enabled the user signs in with a token, which the page
+// sends as a bearer token on every RPC.
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+)
+
+// PathPrefix is the URL path under which the Handler is mounted.
+const PathPrefix = "/dashboard/"
+
+//go:embed static
+var static embed.FS
+
+// whoami is the JSON body of the /dashboard/api/whoami endpoint.
+type whoami struct {
+	*auth.Principal
+	AuthEnabled bool `json:"auth_enabled"`
+}
+
+// Handler returns the dashboard handler. a may be nil if authentication is
+// disabled, in which case every visitor may switch between user scopes.
+func Handler(a *auth.Authenticator) http.Handler {
+	files, _ := fs.Sub(static, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+PathPrefix, http.StripPrefix(PathPrefix, http.FileServerFS(files)))
+	mux.HandleFunc("GET "+PathPrefix+"api/whoami", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		p, err := a.Authenticate(r.Header)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(whoami{Principal: p, AuthEnabled: a != nil})
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hdr := w.Header()
+		hdr.Set("Content-Security-Policy", "default-src 'self'; img-src 'self' blob: data:; style-src 'self'; object-src 'none'; frame-ancestors 'none'")
+		hdr.Set("X-Content-Type-Options", "nosniff")
+		hdr.Set("Referrer-Policy", "no-referrer")
+		mux.ServeHTTP(w, r)
+	})
+}
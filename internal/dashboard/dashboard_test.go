@@ -0,0 +1,58 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ServesAssets(t *testing.T) {
+	srv := httptest.NewServer(Handler(nil))
+	defer srv.Close()
+
+	for _, p := range []string{"", "app.js", "style.css"} {
+		res, err := http.Get(srv.URL + PathPrefix + p)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode, p)
+		assert.NotEmpty(t, body, p)
+		assert.Contains(t, res.Header.Get("Content-Security-Policy"), "default-src 'self'")
+	}
+}
+
+func TestHandler_Whoami(t *testing.T) {
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "t1", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+	})
+	srv := httptest.NewServer(Handler(a))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + PathPrefix + "api/whoami")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+PathPrefix+"api/whoami", nil)
+	req.Header.Set("Authorization", "Bearer t1")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var who struct {
+		Name        string `json:"name"`
+		UserID      string `json:"user_id"`
+		Admin       bool   `json:"admin"`
+		AuthEnabled bool   `json:"auth_enabled"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&who))
+	assert.Equal(t, "alice", who.UserID)
+	assert.False(t, who.Admin)
+	assert.True(t, who.AuthEnabled)
+}
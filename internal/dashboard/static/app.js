@@ -0,0 +1,455 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+//
+// mlcartifact dashboard. Talks to the ArtifactService via the Connect JSON
+// protocol; no build step and no third-party dependencies.
+"use strict";
+
+const SERVICE = "/artifact.v1.ArtifactService/";
+const TEXT_PREVIEW_LIMIT = 2 * 1024 * 1024;
+
+const state = {
+  token: sessionStorage.getItem("mlcartifact.token") || "",
+  principal: null,
+  scope: "",
+  mode: "vfs",
+  dir: "/",
+  selected: null,
+};
+
+const $ = (id) => document.getElementById(id);
+
+// ---- RPC -------------------------------------------------------------------
+
+function authHeaders() {
+  return state.token ? { Authorization: "Bearer " + state.token } : {};
+}
+
+async function rpc(method, body) {
+  const res = await fetch(SERVICE + method, {
+    method: "POST",
+    headers: Object.assign(
+      { "Content-Type": "application/json", "Connect-Protocol-Version": "1" },
+      authHeaders(),
+    ),
+    body: JSON.stringify(body),
+  });
+  const data = await res.json().catch(() => ({}));
+  if (!res.ok) {
+    throw new Error((data.code ? data.code + ": " : "") + (data.message || res.statusText));
+  }
+  return data;
+}
+
+// ---- base64 helpers --------------------------------------------------------
+
+function bytesToBase64(bytes) {
+  let binary = "";
+  for (let i = 0; i < bytes.length; i += 0x8000) {
+    binary += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+  }
+  return btoa(binary);
+}
+
+function base64ToBytes(b64) {
+  const binary = atob(b64 || "");
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) bytes[i] = binary.charCodeAt(i);
+  return bytes;
+}
+
+// ---- rendering helpers -----------------------------------------------------
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs || {})) {
+    if (k === "class") node.className = v;
+    else if (k.startsWith("on")) node.addEventListener(k.slice(2), v);
+    else node.setAttribute(k, v);
+  }
+  for (const c of children) {
+    if (c !== null && c !== undefined) node.append(c);
+  }
+  return node;
+}
+
+function status(msg, isError) {
+  const s = $("status");
+  s.textContent = msg || "";
+  s.className = isError ? "error" : "";
+}
+
+function formatDate(s) {
+  if (!s) return "";
+  const d = new Date(s);
+  return isNaN(d) ? s : d.toLocaleString();
+}
+
+function joinPath(dir, name) {
+  return (dir.endsWith("/") ? dir : dir + "/") + name;
+}
+
+// escapeHTML is used by the markdown renderer, which builds HTML strings.
+function escapeHTML(s) {
+  return s.replace(/[&<>"']/g, (c) => ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;" })[c]);
+}
+
+// renderMarkdown converts a useful subset of Markdown to HTML. All input is
+// escaped first, so artifact content can never inject markup or scripts.
+function renderMarkdown(src) {
+  const inline = (s) =>
+    escapeHTML(s)
+      .replace(/`([^`]+)`/g, "<code>$1</code>")
+      .replace(/\*\*([^*]+)\*\*/g, "<strong>$1</strong>")
+      .replace(/\*([^*]+)\*/g, "<em>$1</em>")
+      .replace(/\[([^\]]+)\]\((https?:\/\/[^\s)]+)\)/g, '<a href="$2" rel="noopener noreferrer" target="_blank">$1</a>');
+
+  const out = [];
+  let list = null;
+  let para = [];
+  const flushPara = () => {
+    if (para.length) out.push("<p>" + inline(para.join(" ")) + "</p>");
+    para = [];
+  };
+  const flushList = () => {
+    if (list) out.push("</" + list + ">");
+    list = null;
+  };
+
+  const lines = src.split("\n");
+  for (let i = 0; i < lines.length; i++) {
+    const line = lines[i];
+    if (line.startsWith("```")) {
+      flushPara();
+      flushList();
+      const code = [];
+      for (i++; i < lines.length && !lines[i].startsWith("```"); i++) code.push(lines[i]);
+      out.push("<pre><code>" + escapeHTML(code.join("\n")) + "</code></pre>");
+      continue;
+    }
+    let m;
+    if ((m = line.match(/^(#{1,6})\s+(.*)$/))) {
+      flushPara();
+      flushList();
+      out.push("<h" + m[1].length + ">" + inline(m[2]) + "</h" + m[1].length + ">");
+    } else if ((m = line.match(/^\s*([-*]|\d+\.)\s+(.*)$/))) {
+      flushPara();
+      const kind = /\d/.test(m[1]) ? "ol" : "ul";
+      if (list !== kind) {
+        flushList();
+        out.push("<" + kind + ">");
+        list = kind;
+      }
+      out.push("<li>" + inline(m[2]) + "</li>");
+    } else if (line.startsWith(">")) {
+      flushPara();
+      flushList();
+      out.push("<blockquote>" + inline(line.replace(/^>\s?/, "")) + "</blockquote>");
+    } else if (line.trim() === "") {
+      flushPara();
+      flushList();
+    } else {
+      flushList();
+      para.push(line);
+    }
+  }
+  flushPara();
+  flushList();
+  return out.join("\n");
+}
+
+// parseCSV handles quoted fields, escaped quotes and embedded newlines.
+function parseCSV(text) {
+  const rows = [];
+  let row = [];
+  let field = "";
+  let quoted = false;
+  for (let i = 0; i < text.length; i++) {
+    const c = text[i];
+    if (quoted) {
+      if (c === '"' && text[i + 1] === '"') {
+        field += '"';
+        i++;
+      } else if (c === '"') {
+        quoted = false;
+      } else {
+        field += c;
+      }
+    } else if (c === '"') {
+      quoted = true;
+    } else if (c === ",") {
+      row.push(field);
+      field = "";
+    } else if (c === "\n" || c === "\r") {
+      if (c === "\r" && text[i + 1] === "\n") i++;
+      row.push(field);
+      rows.push(row);
+      row = [];
+      field = "";
+    } else {
+      field += c;
+    }
+  }
+  if (field !== "" || row.length) {
+    row.push(field);
+    rows.push(row);
+  }
+  return rows;
+}
+
+function isTextual(mime) {
+  return (
+    mime.startsWith("text/") ||
+    ["application/json", "application/javascript", "application/x-typescript", "application/xml"].includes(mime)
+  );
+}
+
+// ---- views -----------------------------------------------------------------
+
+function renderBreadcrumb() {
+  const bc = $("breadcrumb");
+  bc.replaceChildren();
+  if (state.mode !== "vfs") {
+    bc.append("All artifacts" + (state.scope ? " of " + state.scope : " (global)"));
+    return;
+  }
+  bc.append(el("a", { onclick: () => navigate("/") }, "/"));
+  let acc = "";
+  for (const part of state.dir.split("/").filter(Boolean)) {
+    acc += "/" + part;
+    const target = acc;
+    bc.append(el("a", { onclick: () => navigate(target) }, part), "/");
+  }
+}
+
+async function refresh() {
+  renderBreadcrumb();
+  status("Loading…");
+  try {
+    const req = { userId: state.scope, limit: 1000 };
+    if (state.mode === "vfs") req.dirPath = state.dir;
+    const res = await rpc("List", req);
+    renderListing(res.items || []);
+    status("");
+  } catch (err) {
+    renderListing([]);
+    status(err.message, true);
+  }
+}
+
+function renderListing(items) {
+  const tbody = $("listing").querySelector("tbody");
+  tbody.replaceChildren();
+  $("empty").hidden = items.length > 0;
+
+  for (const item of items) {
+    const isDir = item.isDirectory || item.mimeType === "directory";
+    const name = state.mode === "vfs" ? item.filename : item.virtualPath || item.filename;
+    const row = el(
+      "tr",
+      {},
+      el("td", { class: "name" + (isDir ? " dir" : ""), title: item.description || "", onclick: () => (isDir ? navigate(item.virtualPath) : select(row, item)) }, name),
+      el("td", {}, isDir ? "folder" : item.mimeType),
+      el("td", {}, isDir ? "" : formatDate(item.expiresAt)),
+      el(
+        "td",
+        { class: "actions" },
+        isDir ? null : el("button", { type: "button", title: "Download", onclick: () => download(item) }, "⬇"),
+        isDir ? null : el("button", { type: "button", title: "Change expiry", onclick: () => changeTTL(item) }, "⏱"),
+        isDir ? null : el("button", { type: "button", class: "danger", title: "Delete", onclick: () => remove(item) }, "✕"),
+      ),
+    );
+    tbody.append(row);
+  }
+}
+
+function select(row, item) {
+  for (const tr of $("listing").querySelectorAll("tr.selected")) tr.classList.remove("selected");
+  row.classList.add("selected");
+  preview(item);
+}
+
+function navigate(dir) {
+  state.dir = dir || "/";
+  refresh();
+}
+
+async function readArtifact(item) {
+  const res = await rpc("Read", { id: item.id, userId: state.scope });
+  return { bytes: base64ToBytes(res.content), mime: res.mimeType || "application/octet-stream", filename: res.filename };
+}
+
+async function preview(item) {
+  state.selected = item;
+  const header = $("preview-header");
+  const body = $("preview-body");
+  header.replaceChildren(
+    el("strong", {}, item.virtualPath || item.filename),
+    el("span", {}, item.id + " · " + item.mimeType + (item.source ? " · " + item.source : "")),
+  );
+  body.replaceChildren(el("p", { class: "hint" }, "Loading…"));
+
+  try {
+    const { bytes, mime } = await readArtifact(item);
+    body.replaceChildren();
+    if (item.description) body.append(el("p", { class: "hint" }, item.description));
+
+    if (mime.startsWith("image/")) {
+      // SVG is rendered through <img>, which never executes embedded scripts.
+      const url = URL.createObjectURL(new Blob([bytes], { type: mime }));
+      body.append(el("img", { src: url, alt: item.filename, onload: () => URL.revokeObjectURL(url) }));
+    } else if (isTextual(mime)) {
+      const text = new TextDecoder().decode(bytes.subarray(0, TEXT_PREVIEW_LIMIT));
+      if (mime === "text/markdown") {
+        const div = el("div", { class: "markdown" });
+        div.innerHTML = renderMarkdown(text);
+        body.append(div);
+      } else if (mime === "text/csv") {
+        const rows = parseCSV(text).slice(0, 500);
+        const table = el("table");
+        rows.forEach((r, i) => table.append(el("tr", {}, ...r.map((c) => el(i === 0 ? "th" : "td", {}, c)))));
+        body.append(table);
+      } else {
+        body.append(el("pre", {}, text));
+      }
+      if (bytes.length > TEXT_PREVIEW_LIMIT) body.append(el("p", { class: "hint" }, "Preview truncated."));
+    } else {
+      body.append(el("p", { class: "hint" }, "No preview available for " + mime + ". Use download instead."));
+    }
+  } catch (err) {
+    body.replaceChildren(el("p", { class: "error" }, err.message));
+  }
+}
+
+async function download(item) {
+  try {
+    status("Downloading " + item.filename + "…");
+    const { bytes, mime, filename } = await readArtifact(item);
+    const url = URL.createObjectURL(new Blob([bytes], { type: mime }));
+    const a = el("a", { href: url, download: filename || item.filename });
+    document.body.append(a);
+    a.click();
+    a.remove();
+    setTimeout(() => URL.revokeObjectURL(url), 10000);
+    status("");
+  } catch (err) {
+    status(err.message, true);
+  }
+}
+
+async function remove(item) {
+  if (!confirm("Delete " + (item.virtualPath || item.filename) + " permanently?")) return;
+  try {
+    await rpc("Delete", { id: item.id, userId: state.scope });
+    if (state.selected && state.selected.id === item.id) {
+      state.selected = null;
+      $("preview-header").replaceChildren();
+      $("preview-body").replaceChildren(el("p", { class: "hint" }, "Select an artifact to preview it."));
+    }
+    refresh();
+  } catch (err) {
+    status(err.message, true);
+  }
+}
+
+async function changeTTL(item) {
+  const input = prompt("Keep " + item.filename + " for how many hours from now?", "24");
+  if (input === null) return;
+  const hours = parseInt(input, 10);
+  if (!(hours > 0)) {
+    status("Please enter a positive number of hours.", true);
+    return;
+  }
+  try {
+    const res = await rpc("SetExpiry", { id: item.id, userId: state.scope, expiresHours: hours });
+    status("Expires " + formatDate(res.expiresAt));
+    refresh();
+  } catch (err) {
+    status(err.message, true);
+  }
+}
+
+async function upload(files) {
+  for (const file of files) {
+    try {
+      status("Uploading " + file.name + "…");
+      const bytes = new Uint8Array(await file.arrayBuffer());
+      const req = {
+        filename: file.name,
+        content: bytesToBase64(bytes),
+        mimeType: file.type || "",
+        userId: state.scope,
+        source: "dashboard",
+      };
+      if (state.mode === "vfs") req.virtualPath = joinPath(state.dir, file.name);
+      await rpc("Write", req);
+    } catch (err) {
+      status(err.message, true);
+      return;
+    }
+  }
+  status("");
+  refresh();
+}
+
+// ---- session ---------------------------------------------------------------
+
+async function whoami() {
+  const res = await fetch("api/whoami", { headers: authHeaders() });
+  if (res.status === 401) return null;
+  if (!res.ok) throw new Error("whoami failed: " + res.statusText);
+  return res.json();
+}
+
+function showApp(principal) {
+  state.principal = principal;
+  state.scope = principal.user_id || "";
+  $("login").hidden = true;
+  $("toolbar").hidden = false;
+  $("app").hidden = false;
+  $("scope-label").hidden = !principal.admin;
+  $("scope").value = state.scope;
+  $("whoami").textContent = principal.auth_enabled
+    ? principal.name + (principal.admin ? " (admin)" : "")
+    : "authentication disabled";
+  refresh();
+}
+
+function showLogin(message) {
+  $("app").hidden = true;
+  $("toolbar").hidden = true;
+  $("login").hidden = false;
+  $("login-error").textContent = message || "";
+}
+
+async function start() {
+  try {
+    const p = await whoami();
+    if (p) showApp(p);
+    else showLogin(state.token ? "Invalid token." : "");
+  } catch (err) {
+    showLogin(err.message);
+  }
+}
+
+$("login-form").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  state.token = $("token").value.trim();
+  sessionStorage.setItem("mlcartifact.token", state.token);
+  start();
+});
+$("mode").addEventListener("change", (ev) => {
+  state.mode = ev.target.value;
+  refresh();
+});
+$("scope").addEventListener("change", (ev) => {
+  state.scope = ev.target.value.trim();
+  state.dir = "/";
+  refresh();
+});
+$("upload").addEventListener("change", (ev) => {
+  upload(Array.from(ev.target.files));
+  ev.target.value = "";
+});
+$("refresh").addEventListener("click", refresh);
+
+start();
@@ -0,0 +1,359 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package dav exposes the virtual file system of the artifact store over
+// WebDAV, so that artifacts can be browsed and edited with file managers and
+// editors.
+//
+// Every artifact with a virtual path appears as a file at that path; virtual
+// directories are derived from the paths below them. PROPFIND lists
+// directories through Store.ListVFS, GET and PUT read and write artifacts by
+// virtual path, and DELETE, MKCOL and MOVE map to the corresponding store
+// operations. Saving an existing file replaces its content in place, so the
+// artifact keeps its ID and metadata.
+//
+// Each request operates on a single user scope. With authentication enabled
+// it is the scope of the authenticated principal. Without authentication the
+// Basic auth username, if any, selects the scope and the global scope is used
+// otherwise.
+package dav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"golang.org/x/net/webdav"
+)
+
+// PathPrefix is the URL path under which the Handler is mounted.
+const PathPrefix = "/webdav/"
+
+// DefaultSource is recorded as the source of artifacts created over WebDAV.
+const DefaultSource = "webdav"
+
+// ErrTooLarge is returned while uploading a file that exceeds the maximum
+// artifact size of the store.
+var ErrTooLarge = errors.New("file exceeds the maximum artifact size")
+
+// Handler returns the WebDAV handler. a may be nil if authentication is
+// disabled.
+func Handler(store *storage.Store, a *auth.Authenticator) http.Handler {
+	var (
+		mu       sync.Mutex
+		handlers = make(map[string]*webdav.Handler)
+	)
+
+	// forScope returns the WebDAV handler of a user scope. Every scope has its
+	// own lock system, since equal paths in different scopes are different files.
+	forScope := func(userID string) *webdav.Handler {
+		mu.Lock()
+		defer mu.Unlock()
+		h, ok := handlers[userID]
+		if !ok {
+			h = &webdav.Handler{
+				Prefix:     strings.TrimSuffix(PathPrefix, "/"),
+				FileSystem: &fileSystem{store: store, userID: userID},
+				LockSystem: webdav.NewMemLS(),
+			}
+			handlers[userID] = h
+		}
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r.Header)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mlcartifact", charset="UTF-8"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		// The webdav package answers failed uploads with 405, so refuse
+		// uploads that announce their excess size up front.
+		if limit := store.MaxArtifactSize(); r.Method == http.MethodPut && limit > 0 && r.ContentLength > limit {
+			http.Error(w, ErrTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// Files are served with the MIME type chosen by their writer, so a
+		// browser must not run the script of an HTML or SVG file
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("Content-Security-Policy", "sandbox")
+		}
+
+		userID := p.UserID
+		if a == nil {
+			userID, _, _ = r.BasicAuth()
+		}
+		forScope(userID).ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	})
+}
+
+// fileSystem implements webdav.FileSystem on top of one user scope of the store.
+type fileSystem struct {
+	store  *storage.Store
+	userID string
+}
+
+func (fs *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	p := storage.NormalizePath(name)
+	if _, err := fs.Stat(ctx, p); err == nil {
+		return os.ErrExist
+	}
+	return fs.store.Mkdir(fs.userID, p)
+}
+
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	p := storage.NormalizePath(name)
+
+	if flag&(os.O_CREATE|os.O_TRUNC) != 0 {
+		if p == "/" || fs.store.IsDir(fs.userID, p) {
+			return nil, fmt.Errorf("%s is a directory", p)
+		}
+		tmp, err := os.CreateTemp("", "mlcartifact-webdav-*")
+		if err != nil {
+			return nil, err
+		}
+		return &writeFile{File: tmp, ctx: ctx, fs: fs, path: p, max: fs.store.MaxArtifactSize()}, nil
+	}
+
+	info, err := fs.Stat(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &dirFile{ctx: ctx, fs: fs, info: info, path: p}, nil
+	}
+
+	f, _, err := fs.store.Open(ctx, p, storage.OpenOptions{UserID: fs.userID})
+	if err != nil {
+		return nil, davError(err)
+	}
+	return &readFile{File: f, info: info}, nil
+}
+
+func (fs *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	_, err := fs.store.DeleteTree(ctx, name, storage.DeleteTreeOptions{UserID: fs.userID})
+	return davError(err)
+}
+
+func (fs *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	return davError(fs.store.Move(ctx, oldName, newName, storage.MoveOptions{UserID: fs.userID}))
+}
+
+func (fs *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	p := storage.NormalizePath(name)
+	if p != "/" {
+		fi, meta, err := fs.store.Stat(ctx, p, storage.StatOptions{UserID: fs.userID})
+		if err == nil {
+			return newFileInfo(fi, meta), nil
+		}
+	}
+	if fs.store.IsDir(fs.userID, p) {
+		return &fileInfo{name: path.Base(p), dir: true, modTime: time.Now()}, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// davError translates the store's not-found and already-exists errors into
+// os.ErrNotExist and os.ErrExist, which the webdav package maps to status codes.
+func davError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return os.ErrNotExist
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return os.ErrExist
+	}
+	return err
+}
+
+// fileInfo describes an artifact or a virtual directory.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	meta    *storage.ArtifactMetadata
+}
+
+func newFileInfo(fi os.FileInfo, meta *storage.ArtifactMetadata) *fileInfo {
+	return &fileInfo{name: path.Base(meta.VirtualPath), size: fi.Size(), modTime: fi.ModTime(), meta: meta}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() any           { return fi.meta }
+
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// ContentType implements webdav.ContentTyper with the artifact's MIME type.
+func (fi *fileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.meta == nil || fi.meta.MimeType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.meta.MimeType, nil
+}
+
+// ETag implements webdav.ETager using the same format as the HTTP API.
+func (fi *fileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.meta == nil {
+		return "", webdav.ErrNotImplemented
+	}
+	return fmt.Sprintf(`"%s-%x-%x"`, fi.meta.ID, fi.modTime.UnixNano(), fi.size), nil
+}
+
+// readFile is an artifact opened for reading.
+type readFile struct {
+	*os.File
+	info os.FileInfo
+}
+
+func (f *readFile) Stat() (os.FileInfo, error) { return f.info, nil }
+
+func (f *readFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errors.New("not a directory")
+}
+
+func (f *readFile) Write(p []byte) (int, error) {
+	return 0, errors.New("file is opened read-only")
+}
+
+// writeFile buffers uploaded content in a temporary file and stores it when
+// closed: existing artifacts are replaced in place, new paths create artifacts.
+// The webdav package closes files within the request, so the request context
+// is kept for the store calls of Close. Writes beyond max bytes fail with
+// ErrTooLarge, and the file is then discarded instead of stored.
+type writeFile struct {
+	*os.File
+	ctx  context.Context
+	fs   *fileSystem
+	path string
+	max  int64 // 0 = unlimited
+	size int64
+	err  error
+}
+
+func (f *writeFile) Write(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if f.max > 0 && f.size+int64(len(p)) > f.max {
+		f.err = ErrTooLarge
+		return 0, f.err
+	}
+	n, err := f.File.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// ReadFrom hides (*os.File).ReadFrom, which io.Copy would use to bypass the
+// size limit of Write.
+func (f *writeFile) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{f}, r)
+}
+
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(f.path), size: fi.Size(), modTime: fi.ModTime()}, nil
+}
+
+func (f *writeFile) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, errors.New("not a directory")
+}
+
+func (f *writeFile) Close() error {
+	defer os.Remove(f.File.Name())
+	defer f.File.Close()
+
+	if f.err != nil {
+		return f.err
+	}
+	if _, err := f.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	store, userID := f.fs.store, f.fs.userID
+	if _, _, err := store.Stat(f.ctx, f.path, storage.StatOptions{UserID: userID}); err == nil {
+		_, err = store.Replace(f.ctx, f.path, f.File, storage.ReplaceOptions{UserID: userID})
+		return err
+	}
+	_, err := store.WriteStream(f.ctx, path.Base(f.path), f.File, storage.WriteOptions{
+		Source:      DefaultSource,
+		UserID:      userID,
+		VirtualPath: f.path,
+	})
+	return err
+}
+
+// dirFile is an open virtual directory. Like writeFile, it keeps the request
+// context for listing the directory.
+type dirFile struct {
+	ctx     context.Context
+	fs      *fileSystem
+	info    os.FileInfo
+	path    string
+	entries []os.FileInfo
+	listed  bool
+}
+
+func (d *dirFile) Close() error                                 { return nil }
+func (d *dirFile) Stat() (os.FileInfo, error)                   { return d.info, nil }
+func (d *dirFile) Read(p []byte) (int, error)                   { return 0, errors.New("is a directory") }
+func (d *dirFile) Write(p []byte) (int, error)                  { return 0, errors.New("is a directory") }
+func (d *dirFile) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+
+func (d *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.listed {
+		items, err := d.fs.store.ListVFS(d.ctx, d.path, storage.ListVFSOptions{UserID: d.fs.userID})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if item.MimeType == "directory" && item.ID == "" {
+				d.entries = append(d.entries, &fileInfo{name: item.Filename, dir: true, modTime: time.Now()})
+				continue
+			}
+			fi, meta, err := d.fs.store.Stat(d.ctx, item.ID, storage.StatOptions{UserID: d.fs.userID})
+			if err != nil {
+				continue
+			}
+			d.entries = append(d.entries, newFileInfo(fi, meta))
+		}
+		d.listed = true
+	}
+
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,147 @@
+package dav
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, a *auth.Authenticator) (*httptest.Server, *storage.Store) {
+	tempDir, err := os.MkdirTemp("", "artifact-dav-test-*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	store := storage.NewStore(tempDir)
+	mux := http.NewServeMux()
+	mux.Handle(PathPrefix, Handler(store, a))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, store
+}
+
+func do(t *testing.T, method, url, body string, hdr map[string]string) (*http.Response, string) {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	require.NoError(t, err)
+	for k, v := range hdr {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	return res, string(data)
+}
+
+func TestHandler_Lifecycle(t *testing.T) {
+	srv, store := newTestServer(t, nil)
+	base := srv.URL + PathPrefix
+
+	// Artifacts written by agents show up in the tree
+	_, err := store.Write(t.Context(), "plan.md", []byte("# Plan"), storage.WriteOptions{ExpiresHours: 1, Source: "agent", VirtualPath: "/projects/alpha/plan.md"})
+	require.NoError(t, err)
+
+	res, body := do(t, "PROPFIND", base+"projects/alpha/", "", map[string]string{"Depth": "1"})
+	require.Equal(t, http.StatusMultiStatus, res.StatusCode)
+	assert.Contains(t, body, "/webdav/projects/alpha/plan.md")
+	assert.Contains(t, body, "text/markdown")
+
+	// GET reads by virtual path
+	res, body = do(t, http.MethodGet, base+"projects/alpha/plan.md", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "# Plan", body)
+	assert.Equal(t, "nosniff", res.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "sandbox", res.Header.Get("Content-Security-Policy"))
+
+	// PUT on an existing path edits the artifact in place
+	res, _ = do(t, http.MethodPut, base+"projects/alpha/plan.md", "# Plan v2", nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	data, meta, err := store.Read(t.Context(), "/projects/alpha/plan.md", storage.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan v2", string(data))
+	assert.Equal(t, "agent", meta.Source)
+
+	// PUT on a new path creates an artifact
+	res, _ = do(t, http.MethodPut, base+"projects/alpha/notes.txt", "hello", nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	_, meta, err = store.Read(t.Context(), "/projects/alpha/notes.txt", storage.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSource, meta.Source)
+
+	// MKCOL creates an empty directory, MOVE renames into it
+	res, _ = do(t, "MKCOL", base+"projects/beta", "", nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	res, _ = do(t, "MOVE", base+"projects/alpha/notes.txt", "", map[string]string{"Destination": base + "projects/beta/notes.txt"})
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	_, _, err = store.Read(t.Context(), "/projects/beta/notes.txt", storage.ReadOptions{})
+	require.NoError(t, err)
+
+	// DELETE of a directory removes everything below it
+	res, _ = do(t, http.MethodDelete, base+"projects/alpha", "", nil)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+	res, _ = do(t, http.MethodGet, base+"projects/alpha/plan.md", "", nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestHandler_Scopes(t *testing.T) {
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "alice-token", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+	})
+	srv, store := newTestServer(t, a)
+	base := srv.URL + PathPrefix
+
+	_, err := store.Write(t.Context(), "a.txt", []byte("alice"), storage.WriteOptions{ExpiresHours: 1, Source: "test", UserID: "alice", VirtualPath: "/a.txt"})
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "g.txt", []byte("global"), storage.WriteOptions{ExpiresHours: 1, Source: "test", VirtualPath: "/g.txt"})
+	require.NoError(t, err)
+
+	res, _ := do(t, "PROPFIND", base, "", map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Contains(t, res.Header.Get("WWW-Authenticate"), "Basic")
+
+	req, err := http.NewRequest("PROPFIND", base, nil)
+	require.NoError(t, err)
+	req.Header.Set("Depth", "1")
+	req.SetBasicAuth("alice", "alice-token")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	require.Equal(t, http.StatusMultiStatus, res.StatusCode)
+	assert.Contains(t, string(body), "/webdav/a.txt")
+	assert.NotContains(t, string(body), "g.txt")
+}
+
+func TestHandler_MaxArtifactSize(t *testing.T) {
+	srv, store := newTestServer(t, nil)
+	store.SetMaxArtifactSize(4)
+	base := srv.URL + PathPrefix
+
+	res, _ := do(t, http.MethodPut, base+"small.txt", "1234", nil)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// Announced sizes are refused before the upload
+	res, _ = do(t, http.MethodPut, base+"big.txt", "12345", nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+
+	// Uploads of unknown size fail while writing and store nothing
+	req, err := http.NewRequest(http.MethodPut, base+"chunked.txt", io.MultiReader(strings.NewReader("123"), strings.NewReader("45")))
+	require.NoError(t, err)
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.GreaterOrEqual(t, res.StatusCode, 400)
+	_, _, err = store.Stat(t.Context(), "/chunked.txt", storage.StatOptions{})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -16,9 +16,9 @@ type ConnectServer struct {
 }
 
 // NewConnectServer creates a new ConnectServer.
-func NewConnectServer(store *storage.Store) protoconnect.ArtifactServiceHandler {
+func NewConnectServer(store *storage.Store, opts ...ServerOption) protoconnect.ArtifactServiceHandler {
 	return &ConnectServer{
-		server: NewServer(store),
+		server: NewServer(store, opts...),
 	}
 }
 
@@ -69,3 +69,31 @@ func (c *ConnectServer) Find(ctx context.Context, req *connect.Request[pb.FindRe
 	}
 	return connect.NewResponse(res), nil
 }
+
+func (c *ConnectServer) CreateShareLink(ctx context.Context, req *connect.Request[pb.CreateShareLinkRequest]) (*connect.Response[pb.CreateShareLinkResponse], error) {
+	res, err := c.server.CreateShareLink(ctx, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (c *ConnectServer) SetExpiry(ctx context.Context, req *connect.Request[pb.SetExpiryRequest]) (*connect.Response[pb.SetExpiryResponse], error) {
+	res, err := c.server.SetExpiry(ctx, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (c *ConnectServer) Watch(ctx context.Context, req *connect.Request[pb.WatchRequest], stream *connect.ServerStream[pb.WatchEvent]) error {
+	return c.server.watch(ctx, req.Msg, stream.Send)
+}
+
+func (c *ConnectServer) QueryAudit(ctx context.Context, req *connect.Request[pb.QueryAuditRequest]) (*connect.Response[pb.QueryAuditResponse], error) {
+	res, err := c.server.QueryAudit(ctx, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(res), nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	pb "github.com/hmsoft0815/mlcartifact/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details attached to errors.
+const ErrorDomain = pb.ErrorDomain
+
+// codes maps storage error reasons to Connect codes.
+var codes = map[string]connect.Code{
+	storage.ReasonNotFound:        connect.CodeNotFound,
+	storage.ReasonAlreadyExists:   connect.CodeAlreadyExists,
+	storage.ReasonInvalidPath:     connect.CodeInvalidArgument,
+	storage.ReasonInvalidArgument: connect.CodeInvalidArgument,
+	storage.ReasonQuotaExceeded:   connect.CodeResourceExhausted,
+	storage.ReasonConflict:        connect.CodeFailedPrecondition,
+	storage.ReasonInternal:        connect.CodeInternal,
+}
+
+// storageError converts an error of the store into a Connect error with the
+// matching code. An ErrorInfo detail carries the reason (see storage.Reason)
+// and, for *storage.Error, the operation, path and user scope.
+func storageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var ce *connect.Error
+	if errors.As(err, &ce) {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return connect.NewError(connect.CodeCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return connect.NewError(connect.CodeDeadlineExceeded, err)
+	}
+
+	reason := storage.Reason(err)
+	cerr := connect.NewError(codes[reason], err)
+
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain}
+	var se *storage.Error
+	if errors.As(err, &se) {
+		info.Metadata = map[string]string{"op": se.Op}
+		if se.Path != "" {
+			info.Metadata["path"] = se.Path
+		}
+		if se.UserID != "" {
+			info.Metadata["user_id"] = se.UserID
+		}
+	}
+	if detail, derr := connect.NewErrorDetail(info); derr == nil {
+		cerr.AddDetail(detail)
+	}
+	return cerr
+}
@@ -13,6 +13,9 @@ import (
 	"time"
 
 	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/internal/audit"
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/share"
 	"github.com/hmsoft0815/mlcartifact/internal/storage"
 	pb "github.com/hmsoft0815/mlcartifact/proto"
 )
@@ -21,17 +24,44 @@ import (
 type Server struct {
 	pb.UnimplementedArtifactServiceServer
 	Store *storage.Store // The underlying file storage backend
+	Share *share.Signer  // Optional signer for download links; nil disables CreateShareLink
+	Audit *audit.Logger  // Optional audit log; nil disables auditing and QueryAudit
+}
+
+// ServerOption is a functional option for configuring the Server.
+type ServerOption func(*Server)
+
+// WithShareSigner enables CreateShareLink using the given signer.
+func WithShareSigner(signer *share.Signer) ServerOption {
+	return func(s *Server) {
+		s.Share = signer
+	}
+}
+
+// WithAuditLog records every operation on the artifacts of the server's
+// store in the given audit log, see audit.Logger.Observe, and enables
+// QueryAudit. Operations of other front ends on the same store, such as
+// the MCP tools, are recorded too.
+func WithAuditLog(l *audit.Logger) ServerOption {
+	return func(s *Server) {
+		s.Audit = l
+	}
 }
 
 // NewServer creates a new gRPC server instance with the provided store.
-func NewServer(store *storage.Store) *Server {
-	return &Server{Store: store}
+func NewServer(store *storage.Store, opts ...ServerOption) *Server {
+	s := &Server{Store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Audit.Observe(store)
+	return s
 }
 
 // Write handles the creation or update of an artifact.
 // It maps the proto metadata and content to the storage.Write method.
 func (s *Server) Write(ctx context.Context, req *pb.WriteRequest) (*pb.WriteResponse, error) {
-	slog.Info("gRPC Write request", "filename", req.Filename, "vpath", req.VirtualPath, "user_id", req.UserId)
+	slog.InfoContext(ctx, "gRPC Write request", "filename", req.Filename, "vpath", req.VirtualPath, "user_id", req.UserId)
 
 	// Map proto metadata to map[string]interface{}
 	metadata := make(map[string]interface{})
@@ -39,26 +69,24 @@ func (s *Server) Write(ctx context.Context, req *pb.WriteRequest) (*pb.WriteResp
 		metadata[k] = v
 	}
 
-	meta, err := s.Store.Write(
-		req.Filename,
-		req.Content,
-		req.MimeType,
-		int(req.ExpiresHours),
-		req.Source,
-		req.UserId,
-		req.Description,
-		metadata,
-		req.VirtualPath,
-	)
+	meta, err := s.Store.Write(ctx, req.Filename, req.Content, storage.WriteOptions{
+		MimeType:     req.MimeType,
+		ExpiresHours: int(req.ExpiresHours),
+		Source:       req.Source,
+		UserID:       req.UserId,
+		Description:  req.Description,
+		Metadata:     metadata,
+		VirtualPath:  req.VirtualPath,
+	})
 
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to write artifact: %w", err))
+		return nil, storageError(err)
 	}
 
 	return &pb.WriteResponse{
 		Id:          meta.ID,
 		Filename:    meta.Filename,
-		Uri:         fmt.Sprintf("artifact://%s", meta.Filename),
+		Uri:         meta.URI(),
 		ExpiresAt:   meta.ExpiresAt.Format(time.RFC3339),
 		VirtualPath: meta.VirtualPath,
 	}, nil
@@ -66,14 +94,11 @@ func (s *Server) Write(ctx context.Context, req *pb.WriteRequest) (*pb.WriteResp
 
 // Read retrieves an artifact's content and metadata by ID, filename or virtual path.
 func (s *Server) Read(ctx context.Context, req *pb.ReadRequest) (*pb.ReadResponse, error) {
-	slog.Info("gRPC Read request", "id", req.Id, "user_id", req.UserId)
+	slog.InfoContext(ctx, "gRPC Read request", "id", req.Id, "user_id", req.UserId)
 
-	content, meta, err := s.Store.Read(req.Id, req.UserId)
+	content, meta, err := s.Store.Read(ctx, req.Id, storage.ReadOptions{UserID: req.UserId})
 	if err != nil {
-		if err.Error() == "artifact not found" {
-			return nil, connect.NewError(connect.CodeNotFound, errors.New("artifact not found"))
-		}
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to read artifact: %w", err))
+		return nil, storageError(err)
 	}
 
 	return &pb.ReadResponse{
@@ -86,23 +111,23 @@ func (s *Server) Read(ctx context.Context, req *pb.ReadRequest) (*pb.ReadRespons
 
 // Delete removes an artifact permanently.
 func (s *Server) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
-	slog.Info("gRPC Delete request", "id", req.Id, "user_id", req.UserId)
-	deleted, err := s.Store.Delete(req.Id, req.UserId)
+	slog.InfoContext(ctx, "gRPC Delete request", "id", req.Id, "user_id", req.UserId)
+	deleted, err := s.Store.Delete(ctx, req.Id, storage.DeleteOptions{UserID: req.UserId})
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to delete artifact: %w", err))
+		return nil, storageError(err)
 	}
 	if !deleted {
-		return nil, connect.NewError(connect.CodeNotFound, errors.New("artifact not found"))
+		return nil, storageError(&storage.Error{Op: "delete", Path: req.Id, UserID: req.UserId, Err: storage.ErrNotFound})
 	}
 	return &pb.DeleteResponse{Deleted: deleted}, nil
 }
 
 // List returns a paginated list of artifacts or a virtual directory listing.
 func (s *Server) List(ctx context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
-	slog.Info("gRPC List request", "user_id", req.UserId, "vdir", req.DirPath)
-	items, err := s.Store.List(req.UserId, int(req.Limit), int(req.Offset), req.DirPath)
+	slog.InfoContext(ctx, "gRPC List request", "user_id", req.UserId, "vdir", req.DirPath)
+	items, err := s.Store.List(ctx, storage.ListOptions{UserID: req.UserId, DirPath: req.DirPath, Limit: int(req.Limit), Offset: int(req.Offset)})
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to list artifacts: %w", err))
+		return nil, storageError(err)
 	}
 
 	var pbItems []*pb.ArtifactInfo
@@ -115,6 +140,7 @@ func (s *Server) List(ctx context.Context, req *pb.ListRequest) (*pb.ListRespons
 			UserId:      item.UserID,
 			CreatedAt:   item.CreatedAt.Format(time.RFC3339),
 			ExpiresAt:   item.ExpiresAt.Format(time.RFC3339),
+			Description: item.Description,
 			VirtualPath: item.VirtualPath,
 			IsDirectory: item.MimeType == "directory",
 		})
@@ -125,13 +151,10 @@ func (s *Server) List(ctx context.Context, req *pb.ListRequest) (*pb.ListRespons
 
 // Patch updates part of an artifact's content.
 func (s *Server) Patch(ctx context.Context, req *pb.PatchRequest) (*pb.PatchResponse, error) {
-	slog.Info("gRPC Patch request", "id", req.Id, "user_id", req.UserId)
-	newSize, err := s.Store.Patch(req.Id, req.UserId, req.Content, int(req.LineStart), int(req.LineEnd), req.Append)
+	slog.InfoContext(ctx, "gRPC Patch request", "id", req.Id, "user_id", req.UserId)
+	newSize, err := s.Store.Patch(ctx, req.Id, req.Content, storage.PatchOptions{UserID: req.UserId, LineStart: int(req.LineStart), LineEnd: int(req.LineEnd), Append: req.Append})
 	if err != nil {
-		if err.Error() == "artifact not found" {
-			return nil, connect.NewError(connect.CodeNotFound, errors.New("artifact not found"))
-		}
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to patch artifact: %w", err))
+		return nil, storageError(err)
 	}
 
 	return &pb.PatchResponse{
@@ -143,10 +166,10 @@ func (s *Server) Patch(ctx context.Context, req *pb.PatchRequest) (*pb.PatchResp
 
 // Find searches for artifacts by virtual path pattern.
 func (s *Server) Find(ctx context.Context, req *pb.FindRequest) (*pb.ListResponse, error) {
-	slog.Info("gRPC Find request", "pattern", req.Pattern, "user_id", req.UserId)
-	items, err := s.Store.Find(req.UserId, req.Pattern)
+	slog.InfoContext(ctx, "gRPC Find request", "pattern", req.Pattern, "user_id", req.UserId)
+	items, err := s.Store.Find(ctx, req.Pattern, storage.FindOptions{UserID: req.UserId})
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to find artifacts: %w", err))
+		return nil, storageError(err)
 	}
 
 	var pbItems []*pb.ArtifactInfo
@@ -165,3 +188,151 @@ func (s *Server) Find(ctx context.Context, req *pb.FindRequest) (*pb.ListRespons
 
 	return &pb.ListResponse{Items: pbItems}, nil
 }
+
+// CreateShareLink returns a signed, expiring HTTP download URL for an artifact.
+func (s *Server) CreateShareLink(ctx context.Context, req *pb.CreateShareLinkRequest) (_ *pb.CreateShareLinkResponse, err error) {
+	slog.InfoContext(ctx, "gRPC CreateShareLink request", "id", req.Id, "user_id", req.UserId)
+	op := storage.Operation{Name: "CreateShareLink", Ref: req.Id, UserID: req.UserId}
+	defer func() { s.Store.Report(ctx, op, err) }()
+	if s.Share == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("share links are not configured on this server"))
+	}
+
+	// Stat, not Read: signing a link does not read the content
+	_, meta, err := s.Store.Stat(ctx, req.Id, storage.StatOptions{UserID: req.UserId})
+	if err != nil {
+		return nil, storageError(err)
+	}
+	op.Artifact = meta
+
+	link := s.Share.Sign(meta.ID, req.UserId, time.Duration(req.ExpiresMinutes)*time.Minute, meta.ExpiresAt)
+	return &pb.CreateShareLinkResponse{
+		Url:       link.URL,
+		ExpiresAt: link.ExpiresAt.Format(time.RFC3339),
+		Filename:  meta.Filename,
+		MimeType:  meta.MimeType,
+	}, nil
+}
+
+// SetExpiry changes the time-to-live of an existing artifact.
+func (s *Server) SetExpiry(ctx context.Context, req *pb.SetExpiryRequest) (*pb.SetExpiryResponse, error) {
+	slog.InfoContext(ctx, "gRPC SetExpiry request", "id", req.Id, "user_id", req.UserId, "expires_hours", req.ExpiresHours)
+	meta, err := s.Store.SetExpiry(ctx, req.Id, int(req.ExpiresHours), storage.SetExpiryOptions{UserID: req.UserId})
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	return &pb.SetExpiryResponse{ExpiresAt: meta.ExpiresAt.Format(time.RFC3339)}, nil
+}
+
+// Watch streams artifact change events over gRPC.
+func (s *Server) Watch(req *pb.WatchRequest, stream pb.ArtifactService_WatchServer) error {
+	return s.watch(stream.Context(), req, stream.Send)
+}
+
+// watch subscribes to the store and forwards matching events to send until
+// the client disconnects. It is shared by the gRPC and Connect handlers.
+func (s *Server) watch(ctx context.Context, req *pb.WatchRequest, send func(*pb.WatchEvent) error) error {
+	slog.InfoContext(ctx, "gRPC Watch request", "user_id", req.UserId, "prefix", req.PathPrefix, "source", req.Source, "cursor", req.Cursor)
+	sub, err := s.Store.Watch(ctx, storage.WatchFilter{
+		UserID:     req.UserId,
+		PathPrefix: req.PathPrefix,
+		Source:     req.Source,
+	}, req.Cursor)
+	if err != nil {
+		if errors.Is(err, storage.ErrCursorExpired) {
+			err = connect.NewError(connect.CodeOutOfRange, err)
+		} else {
+			err = connect.NewError(connect.CodeInternal, fmt.Errorf("failed to watch artifacts: %w", err))
+		}
+	}
+	s.Store.Report(ctx, storage.Operation{Name: "Watch", Ref: req.PathPrefix, UserID: req.UserId, Source: req.Source}, err)
+	if err != nil {
+		return err
+	}
+
+	for ev := range sub.C {
+		item := ev.Artifact
+		err := send(&pb.WatchEvent{
+			Type:   string(ev.Type),
+			Cursor: ev.Cursor,
+			Time:   ev.Time.Format(time.RFC3339Nano),
+			Artifact: &pb.ArtifactInfo{
+				Id:          item.ID,
+				Filename:    item.Filename,
+				MimeType:    item.MimeType,
+				Source:      item.Source,
+				CreatedAt:   item.CreatedAt.Format(time.RFC3339),
+				ExpiresAt:   item.ExpiresAt.Format(time.RFC3339),
+				SizeBytes:   ev.SizeBytes,
+				UserId:      item.UserID,
+				Description: item.Description,
+				VirtualPath: item.VirtualPath,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := sub.Err(); err != nil {
+		return connect.NewError(connect.CodeUnavailable, fmt.Errorf("%w, resume from the last cursor", err))
+	}
+	return nil
+}
+
+// QueryAudit returns audit log entries. Only admins may query the log.
+func (s *Server) QueryAudit(ctx context.Context, req *pb.QueryAuditRequest) (*pb.QueryAuditResponse, error) {
+	slog.InfoContext(ctx, "gRPC QueryAudit request", "artifact_id", req.ArtifactId, "scope", req.Scope, "principal", req.Principal, "operation", req.Operation)
+	if s.Audit == nil {
+		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("audit log is not configured on this server"))
+	}
+	if !auth.FromContext(ctx).Admin {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("querying the audit log requires an admin principal"))
+	}
+
+	filter := audit.Filter{
+		ArtifactID: req.ArtifactId,
+		UserID:     req.Scope,
+		Principal:  req.Principal,
+		Operation:  req.Operation,
+		Limit:      int(req.Limit),
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	for _, bound := range []struct {
+		value string
+		dst   *time.Time
+	}{{req.Since, &filter.Since}, {req.Until, &filter.Until}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid time %q: %w", bound.value, err))
+		}
+		*bound.dst = t
+	}
+
+	entries, err := s.Audit.Query(filter)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to query audit log: %w", err))
+	}
+
+	res := &pb.QueryAuditResponse{}
+	for _, e := range entries {
+		res.Entries = append(res.Entries, &pb.AuditEntry{
+			Time:        e.Time.Format(time.RFC3339Nano),
+			Operation:   e.Operation,
+			Principal:   e.Principal,
+			UserId:      e.UserID,
+			ArtifactId:  e.ArtifactID,
+			VirtualPath: e.VirtualPath,
+			Source:      e.Source,
+			Bytes:       e.Bytes,
+			Outcome:     e.Outcome,
+			Error:       e.Error,
+		})
+	}
+	return res, nil
+}
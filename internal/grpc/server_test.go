@@ -2,11 +2,22 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/client"
+	"github.com/hmsoft0815/mlcartifact/internal/audit"
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/share"
 	"github.com/hmsoft0815/mlcartifact/internal/storage"
 	pb "github.com/hmsoft0815/mlcartifact/proto"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,7 +45,7 @@ func TestServer_WriteRead(t *testing.T) {
 	writeRes, err := s.Write(ctx, writeReq)
 	require.NoError(t, err)
 	assert.NotEmpty(t, writeRes.Id)
-	assert.Contains(t, writeRes.Uri, writeReq.Filename)
+	assert.Equal(t, "artifact://test-user/"+writeRes.Id, writeRes.Uri)
 
 	// Test Read
 	readReq := &pb.ReadRequest{
@@ -77,3 +88,155 @@ func TestServer_ListDelete(t *testing.T) {
 	listRes2, _ := s.List(ctx, &pb.ListRequest{UserId: userId})
 	assert.Len(t, listRes2.Items, 1)
 }
+
+func TestServer_CreateShareLink(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-grpc-share-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := storage.NewStore(tempDir)
+	ctx := context.Background()
+
+	// Without a signer the RPC is disabled
+	_, err = NewServer(store).CreateShareLink(ctx, &pb.CreateShareLinkRequest{Id: "x"})
+	assert.Equal(t, connect.CodeUnimplemented, connect.CodeOf(err))
+
+	s := NewServer(store, WithShareSigner(share.NewSigner([]byte("secret"), "http://artifacts.local")))
+	writeRes, err := s.Write(ctx, &pb.WriteRequest{Filename: "chart.png", Content: []byte("png"), UserId: "u1"})
+	require.NoError(t, err)
+
+	res, err := s.CreateShareLink(ctx, &pb.CreateShareLinkRequest{Id: writeRes.Id, UserId: "u1", ExpiresMinutes: 5})
+	require.NoError(t, err)
+	assert.Contains(t, res.Url, "http://artifacts.local/share/"+writeRes.Id+"?")
+	assert.Equal(t, "image/png", res.MimeType)
+
+	_, err = s.CreateShareLink(ctx, &pb.CreateShareLinkRequest{Id: "missing", UserId: "u1"})
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
+
+func TestServer_Watch(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-grpc-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := storage.NewStore(tempDir)
+	s := NewServer(store)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *pb.WatchEvent, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.watch(ctx, &pb.WatchRequest{UserId: "u1", PathPrefix: "/projects"}, func(ev *pb.WatchEvent) error {
+			events <- ev
+			return nil
+		})
+	}()
+	require.Eventually(t, func() bool {
+		_, err := s.Write(ctx, &pb.WriteRequest{Filename: "skip.txt", Content: []byte("x"), UserId: "u1", VirtualPath: "/other/skip.txt"})
+		require.NoError(t, err)
+		_, err = s.Write(ctx, &pb.WriteRequest{Filename: "a.txt", Content: []byte("abc"), UserId: "u1", VirtualPath: "/projects/a.txt"})
+		require.NoError(t, err)
+		return len(events) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	ev := <-events
+	assert.Equal(t, "created", ev.Type)
+	assert.Equal(t, "/projects/a.txt", ev.Artifact.VirtualPath)
+	assert.Equal(t, int64(3), ev.Artifact.SizeBytes)
+	cancel()
+	require.NoError(t, <-done)
+
+	// Resuming from a cursor replays later events
+	_, err = s.Delete(context.Background(), &pb.DeleteRequest{Id: ev.Artifact.Id, UserId: "u1"})
+	require.NoError(t, err)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	go func() {
+		done <- s.watch(ctx2, &pb.WatchRequest{UserId: "u1", Cursor: ev.Cursor}, func(ev *pb.WatchEvent) error {
+			events <- ev
+			return nil
+		})
+	}()
+	var types []string
+	for len(types) == 0 || types[len(types)-1] != "deleted" {
+		types = append(types, (<-events).Type)
+	}
+	cancel2()
+	<-done
+
+	// Unknown cursors are rejected
+	err = s.watch(context.Background(), &pb.WatchRequest{UserId: "u1", Cursor: "stale.1"}, nil)
+	assert.Equal(t, connect.CodeOutOfRange, connect.CodeOf(err))
+}
+
+func TestServer_Audit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-grpc-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	logger, err := audit.NewLogger(filepath.Join(tempDir, "audit"))
+	require.NoError(t, err)
+	defer logger.Close()
+
+	s := NewServer(storage.NewStore(filepath.Join(tempDir, "store")), WithAuditLog(logger))
+	alice := auth.NewContext(context.Background(), &auth.Principal{Name: "alice", UserID: "alice"})
+
+	w, err := s.Write(alice, &pb.WriteRequest{Filename: "r.csv", Content: []byte("a,b"), UserId: "alice", Source: "etl", VirtualPath: "/r.csv"})
+	require.NoError(t, err)
+	_, err = s.Read(alice, &pb.ReadRequest{Id: "/r.csv", UserId: "alice"})
+	require.NoError(t, err)
+	_, err = s.Delete(alice, &pb.DeleteRequest{Id: w.Id, UserId: "alice"})
+	require.NoError(t, err)
+	_, err = s.Read(alice, &pb.ReadRequest{Id: w.Id, UserId: "alice"})
+	require.Error(t, err)
+
+	// Non-admins may not query the log
+	_, err = s.QueryAudit(alice, &pb.QueryAuditRequest{})
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+
+	res, err := s.QueryAudit(context.Background(), &pb.QueryAuditRequest{ArtifactId: w.Id})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 4)
+	ops := []string{}
+	for _, e := range res.Entries {
+		ops = append(ops, e.Operation+":"+e.Outcome)
+		assert.Equal(t, "alice", e.Principal)
+		assert.Equal(t, "alice", e.UserId)
+	}
+	assert.Equal(t, []string{"Write:ok", "Read:ok", "Delete:ok", "Read:not_found"}, ops)
+	assert.Equal(t, int64(3), res.Entries[1].Bytes)
+	assert.Equal(t, "etl", res.Entries[2].Source)
+	assert.Equal(t, "/r.csv", res.Entries[2].VirtualPath)
+
+	_, err = s.QueryAudit(context.Background(), &pb.QueryAuditRequest{Since: "yesterday"})
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-grpc-errors-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewArtifactServiceHandler(NewConnectServer(storage.NewStore(tempDir))))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := client.NewClientWithAddr(srv.URL, client.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.Read(ctx, "/missing.md", client.WithReadUserID("u1"))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	assert.Equal(t, storage.ReasonNotFound, client.ErrorReason(err))
+
+	_, err = c.Delete(ctx, "missing")
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	assert.Equal(t, storage.ReasonNotFound, client.ErrorReason(err))
+
+	_, err = c.Write(ctx, "a.txt", []byte("x"), client.WithUserID("../other"))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.Equal(t, storage.ReasonInvalidPath, client.ErrorReason(err))
+
+	assert.Equal(t, "", client.ErrorReason(errors.New("plain")))
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package health reports whether the artifact service can take traffic.
+//
+// It serves the gRPC health protocol (grpc.health.v1.Health, wire compatible
+// with connectrpc.com/grpchealth and grpc_health_probe), gRPC server
+// reflection for grpcurl and similar tools, and plain HTTP probes for load
+// balancers and Kubernetes:
+//
+//   - LivenessPath answers 200 as long as the process serves HTTP.
+//   - ReadinessPath answers 200 once the VFS index has been rebuilt and the
+//     data directory is writable, and 503 with the reason otherwise.
+//
+// The gRPC health status of the ArtifactService and of the server as a whole
+// ("") follows readiness.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// LivenessPath is the URL path of the liveness probe.
+	LivenessPath = "/healthz"
+	// ReadinessPath is the URL path of the readiness probe.
+	ReadinessPath = "/readyz"
+
+	// ServiceName is the fully-qualified name of the gRPC health service.
+	ServiceName = "grpc.health.v1.Health"
+)
+
+const (
+	checkProcedure = "/" + ServiceName + "/Check"
+	watchProcedure = "/" + ServiceName + "/Watch"
+)
+
+// DefaultWatchInterval is how often a Watch stream re-checks readiness.
+const DefaultWatchInterval = 5 * time.Second
+
+// ErrIndexing is reported while the VFS index is being rebuilt.
+var ErrIndexing = errors.New("index rebuild in progress")
+
+// Checker checks the readiness of a store.
+type Checker struct {
+	store    *storage.Store
+	interval time.Duration
+}
+
+// New creates a Checker for store.
+func New(store *storage.Store) *Checker {
+	return &Checker{store: store, interval: DefaultWatchInterval}
+}
+
+// Ready returns nil if the store can serve requests, or the reason it
+// cannot.
+func (c *Checker) Ready(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !c.store.Indexed() {
+		return ErrIndexing
+	}
+	return c.store.CheckWritable()
+}
+
+// status returns the gRPC serving status of service.
+func (c *Checker) status(ctx context.Context, service string) healthpb.HealthCheckResponse_ServingStatus {
+	switch service {
+	case "", protoconnect.ArtifactServiceName:
+	default:
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if c.Ready(ctx) != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// LivenessHandler returns the handler of the liveness probe.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+}
+
+// ReadinessHandler returns the handler of the readiness probe.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := c.Ready(r.Context()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprintf(w, "not ready: %v\n", err)
+			return
+		}
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+}
+
+// Handler returns the path and handler of the gRPC health service.
+func (c *Checker) Handler(opts ...connect.HandlerOption) (string, http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle(checkProcedure, connect.NewUnaryHandler(checkProcedure, c.check, opts...))
+	mux.Handle(watchProcedure, connect.NewServerStreamHandler(watchProcedure, c.watch, opts...))
+	return "/" + ServiceName + "/", mux
+}
+
+func (c *Checker) check(ctx context.Context, req *connect.Request[healthpb.HealthCheckRequest]) (*connect.Response[healthpb.HealthCheckResponse], error) {
+	st := c.status(ctx, req.Msg.GetService())
+	if st == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("unknown service %q", req.Msg.GetService()))
+	}
+	return connect.NewResponse(&healthpb.HealthCheckResponse{Status: st}), nil
+}
+
+// watch sends the current status and then every change of it until the
+// client goes away. Unknown services are reported as SERVICE_UNKNOWN, as the
+// protocol requires, since they might be registered later.
+func (c *Checker) watch(ctx context.Context, req *connect.Request[healthpb.HealthCheckRequest], stream *connect.ServerStream[healthpb.HealthCheckResponse]) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if st := c.status(ctx, req.Msg.GetService()); st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReflectionHandlers returns the paths and handlers of gRPC server
+// reflection, v1 and v1alpha, for the ArtifactService and the health
+// service.
+func ReflectionHandlers(opts ...connect.HandlerOption) map[string]http.Handler {
+	reflector := grpcreflect.NewStaticReflector(protoconnect.ArtifactServiceName, ServiceName)
+	handlers := make(map[string]http.Handler, 2)
+	path, h := grpcreflect.NewHandlerV1(reflector, opts...)
+	handlers[path] = h
+	path, h = grpcreflect.NewHandlerV1Alpha(reflector, opts...)
+	handlers[path] = h
+	return handlers
+}
+
+// Mount adds the gRPC health service, reflection and the HTTP probes to mux.
+func (c *Checker) Mount(mux *http.ServeMux, opts ...connect.HandlerOption) {
+	mux.Handle(c.Handler(opts...))
+	for path, h := range ReflectionHandlers(opts...) {
+		mux.Handle(path, h)
+	}
+	mux.Handle(LivenessPath, LivenessHandler())
+	mux.Handle(ReadinessPath, c.ReadinessHandler())
+}
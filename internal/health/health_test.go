@@ -0,0 +1,106 @@
+package health
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// newServer serves the health endpoints of a fresh store over HTTP/2.
+func newServer(t *testing.T) (*httptest.Server, string) {
+	dir := t.TempDir() + "/data"
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	c := New(storage.NewStore(dir))
+	c.interval = 10 * time.Millisecond
+
+	mux := http.NewServeMux()
+	c.Mount(mux)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv, dir
+}
+
+func get(t *testing.T, srv *httptest.Server, path string) (int, string) {
+	res, err := srv.Client().Get(srv.URL + path)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(body)
+}
+
+func TestProbes(t *testing.T) {
+	srv, dir := newServer(t)
+
+	code, body := get(t, srv, ReadinessPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+
+	// Without a writable data directory the server is alive but not ready
+	require.NoError(t, os.RemoveAll(dir))
+	code, body = get(t, srv, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "data directory is not writable")
+
+	code, _ = get(t, srv, LivenessPath)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealthService(t *testing.T) {
+	srv, dir := newServer(t)
+	check := connect.NewClient[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse](
+		srv.Client(), srv.URL+checkProcedure, connect.WithGRPC())
+
+	for _, service := range []string{"", protoconnect.ArtifactServiceName} {
+		res, err := check.CallUnary(t.Context(), connect.NewRequest(&healthpb.HealthCheckRequest{Service: service}))
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Msg.Status)
+	}
+
+	_, err := check.CallUnary(t.Context(), connect.NewRequest(&healthpb.HealthCheckRequest{Service: "other.Service"}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	// Watch reports the change to NOT_SERVING
+	watch := connect.NewClient[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse](
+		srv.Client(), srv.URL+watchProcedure, connect.WithGRPC())
+	stream, err := watch.CallServerStream(t.Context(), connect.NewRequest(&healthpb.HealthCheckRequest{}))
+	require.NoError(t, err)
+	defer stream.Close()
+
+	require.True(t, stream.Receive())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, stream.Msg().Status)
+	require.NoError(t, os.RemoveAll(dir))
+	require.True(t, stream.Receive())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, stream.Msg().Status)
+}
+
+func TestReflection(t *testing.T) {
+	srv, _ := newServer(t)
+
+	stream := grpcreflect.NewClient(srv.Client(), srv.URL, connect.WithGRPC()).NewStream(t.Context())
+	defer stream.Close()
+	names, err := stream.ListServices()
+	require.NoError(t, err)
+	var services []string
+	for _, n := range names {
+		services = append(services, string(n))
+	}
+	assert.ElementsMatch(t, []string{protoconnect.ArtifactServiceName, ServiceName}, services)
+
+	desc, err := stream.FileContainingSymbol(protoconnect.ArtifactServiceName)
+	require.NoError(t, err)
+	assert.NotEmpty(t, desc)
+}
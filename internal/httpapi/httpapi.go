@@ -0,0 +1,423 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package httpapi provides a resource-oriented HTTP API for artifacts.
+//
+// It is intended for shell scripts and tools without protobuf support and is
+// mounted next to the Connect handler. Artifacts are addressed either by ID
+// (or filename) under /v1/artifacts/ or by virtual path under /v1/vfs/:
+//
+//	curl -T report.csv http://host/v1/artifacts/report.csv
+//	curl http://host/v1/vfs/projects/alpha/readme.md
+//	curl -X DELETE http://host/v1/artifacts/1a2b-3c4d5e6f
+//
+// GET supports Range and conditional requests via ETag, HEAD returns the
+// metadata as X-Artifact-* headers, and bodies are streamed to and from disk.
+// Requests are authenticated like the Connect API. The user scope is taken
+// from the "user_id" query parameter or the X-Artifact-User-ID header and
+// resolved against the caller's principal: it defaults to the principal's
+// scope, and other scopes are forbidden to non-admins.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+)
+
+// PathPrefix is the URL path under which the Handler is mounted.
+const PathPrefix = "/v1/"
+
+// DefaultSource is recorded as the artifact source when the client sends none.
+const DefaultSource = "http-api"
+
+// Handler serves the REST-style artifact API.
+type Handler struct {
+	store   *storage.Store
+	mux     *http.ServeMux
+	handler http.Handler
+}
+
+// NewHandler creates a Handler backed by the given store. a may be nil if
+// authentication is disabled, in which case every caller may choose any
+// user scope.
+func NewHandler(store *storage.Store, a *auth.Authenticator) *Handler {
+	h := &Handler{store: store, mux: http.NewServeMux()}
+	h.handler = a.Middleware(h.mux)
+
+	h.mux.HandleFunc("GET /v1/artifacts", h.list)
+	h.mux.HandleFunc("POST /v1/artifacts", h.create)
+	h.mux.HandleFunc("GET /v1/artifacts/{id}", h.get)
+	h.mux.HandleFunc("PUT /v1/artifacts/{id}", h.putByID)
+	h.mux.HandleFunc("DELETE /v1/artifacts/{id}", h.delete)
+
+	h.mux.HandleFunc("GET /v1/vfs/{path...}", h.getVFS)
+	h.mux.HandleFunc("PUT /v1/vfs/{path...}", h.putVFS)
+	h.mux.HandleFunc("DELETE /v1/vfs/{path...}", h.deleteVFS)
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+// artifactJSON is the JSON representation of an artifact returned by the API.
+type artifactJSON struct {
+	*storage.ArtifactMetadata
+	SizeBytes int64  `json:"size_bytes"`
+	ETag      string `json:"etag,omitempty"`
+}
+
+// listJSON is the JSON representation of a (directory) listing.
+type listJSON struct {
+	Items []*storage.ArtifactMetadata `json:"items"`
+}
+
+// list returns a flat listing, or a VFS directory listing if "dir" is set.
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	uID, ok := userID(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := h.store.List(r.Context(), storage.ListOptions{UserID: uID, DirPath: q.Get("dir"), Limit: limit, Offset: offset})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, listJSON{Items: items})
+}
+
+// create stores the request body as a new artifact named by the "filename" parameter.
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	filename := r.URL.Query().Get("filename")
+	if filename == "" {
+		http.Error(w, "filename query parameter is required", http.StatusBadRequest)
+		return
+	}
+	h.writeNew(w, r, filename, r.URL.Query().Get("virtual_path"))
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, r.PathValue("id"))
+}
+
+// putByID replaces the content of an existing artifact, or creates a new one
+// using the last path segment as filename.
+func (h *Handler) putByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if h.replaceExisting(w, r, id) {
+		return
+	}
+	h.writeNew(w, r, id, r.URL.Query().Get("virtual_path"))
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	h.remove(w, r, r.PathValue("id"))
+}
+
+// getVFS serves the artifact at a virtual path, or lists it if it is a directory.
+func (h *Handler) getVFS(w http.ResponseWriter, r *http.Request) {
+	p := r.PathValue("path")
+	if p == "" || strings.HasSuffix(p, "/") {
+		h.listVFS(w, r, p)
+		return
+	}
+	uID, ok := userID(w, r)
+	if !ok {
+		return
+	}
+
+	f, meta, err := h.store.Open(r.Context(), storage.NormalizePath(p), storage.OpenOptions{UserID: uID})
+	if err != nil {
+		if isNotFound(err) {
+			h.listVFS(w, r, p)
+			return
+		}
+		writeError(w, err)
+		return
+	}
+	defer f.Close()
+	serveFile(w, r, f, meta)
+}
+
+func (h *Handler) listVFS(w http.ResponseWriter, r *http.Request, dir string) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	uID, ok := userID(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := h.store.ListVFS(r.Context(), storage.NormalizePath(dir), storage.ListVFSOptions{UserID: uID, Limit: limit, Offset: offset})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(items) == 0 && dir != "" {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, listJSON{Items: items})
+}
+
+// putVFS replaces the artifact at a virtual path or creates it.
+func (h *Handler) putVFS(w http.ResponseWriter, r *http.Request) {
+	vPath := storage.NormalizePath(r.PathValue("path"))
+	if vPath == "/" {
+		http.Error(w, "a file path is required", http.StatusBadRequest)
+		return
+	}
+	if h.replaceExisting(w, r, vPath) {
+		return
+	}
+	h.writeNew(w, r, path.Base(vPath), vPath)
+}
+
+func (h *Handler) deleteVFS(w http.ResponseWriter, r *http.Request) {
+	h.remove(w, r, storage.NormalizePath(r.PathValue("path")))
+}
+
+// serve streams an artifact with Range, ETag and conditional request support.
+// HEAD requests receive the same headers without a body.
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, idOrPath string) {
+	uID, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	f, meta, err := h.store.Open(r.Context(), idOrPath, storage.OpenOptions{UserID: uID})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer f.Close()
+	serveFile(w, r, f, meta)
+}
+
+// serveFile writes an opened artifact to the response.
+func serveFile(w http.ResponseWriter, r *http.Request, f *os.File, meta *storage.ArtifactMetadata) {
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// The MIME type is chosen by the writer, so only passive content is
+	// displayed and nothing can run script on the API origin
+	disposition := "attachment"
+	if storage.IsPassiveMimeType(meta.MimeType) {
+		disposition = "inline"
+	}
+	setMetadataHeaders(w, meta, info)
+	w.Header().Set("Content-Type", meta.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": meta.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	http.ServeContent(w, r, meta.Filename, info.ModTime(), f)
+}
+
+// replaceExisting overwrites idOrPath if it exists and reports whether the
+// request has been handled. It honours If-Match and If-None-Match.
+func (h *Handler) replaceExisting(w http.ResponseWriter, r *http.Request, idOrPath string) bool {
+	uID, ok := userID(w, r)
+	if !ok {
+		return true
+	}
+	info, meta, err := h.store.Stat(r.Context(), idOrPath, storage.StatOptions{UserID: uID})
+	if err != nil {
+		if isNotFound(err) {
+			if match := r.Header.Get("If-Match"); match != "" {
+				http.Error(w, "artifact does not exist", http.StatusPreconditionFailed)
+				return true
+			}
+			return false
+		}
+		writeError(w, err)
+		return true
+	}
+	if r.Header.Get("If-None-Match") == "*" {
+		http.Error(w, "artifact already exists", http.StatusPreconditionFailed)
+		return true
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != etag(meta, info) {
+		http.Error(w, "artifact has been modified", http.StatusPreconditionFailed)
+		return true
+	}
+
+	meta, err = h.store.Replace(r.Context(), idOrPath, r.Body, storage.ReplaceOptions{UserID: uID})
+	if err != nil {
+		writeError(w, err)
+		return true
+	}
+
+	slog.InfoContext(r.Context(), "HTTP artifact replaced", "id", meta.ID, "vpath", meta.VirtualPath, "user_id", uID)
+	h.respondStored(w, r, meta, http.StatusOK)
+	return true
+}
+
+// writeNew stores the request body as a new artifact.
+func (h *Handler) writeNew(w http.ResponseWriter, r *http.Request, filename, virtualPath string) {
+	uID, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	expiresHours, _ := strconv.Atoi(q.Get("expires_hours"))
+	source := q.Get("source")
+	if source == "" {
+		source = DefaultSource
+	}
+
+	meta, err := h.store.WriteStream(r.Context(), filename, r.Body, storage.WriteOptions{
+		MimeType:     mimeType(r),
+		ExpiresHours: expiresHours,
+		Source:       source,
+		UserID:       uID,
+		Description:  q.Get("description"),
+		VirtualPath:  virtualPath,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "HTTP artifact created", "id", meta.ID, "filename", meta.Filename, "vpath", meta.VirtualPath, "user_id", meta.UserID)
+	w.Header().Set("Location", PathPrefix+"artifacts/"+meta.ID)
+	h.respondStored(w, r, meta, http.StatusCreated)
+}
+
+// respondStored writes the JSON description of a freshly stored artifact.
+func (h *Handler) respondStored(w http.ResponseWriter, r *http.Request, meta *storage.ArtifactMetadata, status int) {
+	res := artifactJSON{ArtifactMetadata: meta}
+	if info, _, err := h.store.Stat(r.Context(), meta.ID, storage.StatOptions{UserID: meta.UserID}); err == nil {
+		res.SizeBytes = info.Size()
+		res.ETag = etag(meta, info)
+		w.Header().Set("ETag", res.ETag)
+	}
+	writeJSON(w, status, res)
+}
+
+func (h *Handler) remove(w http.ResponseWriter, r *http.Request, idOrPath string) {
+	uID, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	deleted, err := h.store.Delete(r.Context(), idOrPath, storage.DeleteOptions{UserID: uID})
+	if err != nil && !isNotFound(err) {
+		writeError(w, err)
+		return
+	}
+	if !deleted {
+		http.NotFound(w, r)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "HTTP artifact deleted", "id", idOrPath, "user_id", uID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setMetadataHeaders exposes artifact metadata as response headers.
+func setMetadataHeaders(w http.ResponseWriter, meta *storage.ArtifactMetadata, info os.FileInfo) {
+	hdr := w.Header()
+	hdr.Set("ETag", etag(meta, info))
+	hdr.Set("X-Artifact-ID", meta.ID)
+	hdr.Set("X-Artifact-Filename", meta.Filename)
+	hdr.Set("X-Artifact-Created-At", meta.CreatedAt.Format(time.RFC3339))
+	hdr.Set("X-Artifact-Expires-At", meta.ExpiresAt.Format(time.RFC3339))
+	if meta.VirtualPath != "" {
+		hdr.Set("X-Artifact-Virtual-Path", meta.VirtualPath)
+	}
+	if meta.Source != "" {
+		hdr.Set("X-Artifact-Source", meta.Source)
+	}
+	if meta.UserID != "" {
+		hdr.Set("X-Artifact-User-ID", meta.UserID)
+	}
+	if meta.Description != "" {
+		hdr.Set("X-Artifact-Description", mime.QEncoding.Encode("utf-8", meta.Description))
+	}
+}
+
+// etag derives a strong entity tag from the artifact ID and file state.
+func etag(meta *storage.ArtifactMetadata, info os.FileInfo) string {
+	return fmt.Sprintf(`"%s-%x-%x"`, meta.ID, info.ModTime().UnixNano(), info.Size())
+}
+
+// userID returns the user scope of a request: the one requested by the
+// client, resolved against the authenticated principal. If the principal
+// may not access it, userID responds with 403 Forbidden and reports false.
+func userID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	requested := r.URL.Query().Get("user_id")
+	if requested == "" {
+		requested = r.Header.Get("X-Artifact-User-ID")
+	}
+	uID, err := auth.FromContext(r.Context()).ResolveScope(requested)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return "", false
+	}
+	return uID, true
+}
+
+// mimeType returns the MIME type requested by the client, or "" to let the
+// store detect it from the filename. Generic upload types are ignored.
+func mimeType(r *http.Request) string {
+	if mt := r.URL.Query().Get("mime_type"); mt != "" {
+		return mt
+	}
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	switch mt {
+	case "application/octet-stream", "application/x-www-form-urlencoded", "multipart/form-data":
+		return ""
+	}
+	return mt
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, storage.ErrNotFound) || errors.Is(err, os.ErrNotExist)
+}
+
+// writeError responds with the HTTP status matching a storage error.
+func writeError(w http.ResponseWriter, err error) {
+	if isNotFound(err) {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+	status := http.StatusInternalServerError
+	switch storage.Reason(err) {
+	case storage.ReasonInvalidPath, storage.ReasonInvalidArgument:
+		status = http.StatusBadRequest
+	case storage.ReasonAlreadyExists, storage.ReasonConflict:
+		status = http.StatusConflict
+	case storage.ReasonQuotaExceeded:
+		status = http.StatusInsufficientStorage
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
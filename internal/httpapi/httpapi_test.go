@@ -0,0 +1,205 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *storage.Store) {
+	tempDir, err := os.MkdirTemp("", "artifact-httpapi-test-*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	store := storage.NewStore(tempDir)
+	srv := httptest.NewServer(NewHandler(store, nil))
+	t.Cleanup(srv.Close)
+	return srv, store
+}
+
+func do(t *testing.T, method, url string, body string, hdr map[string]string) *http.Response {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	require.NoError(t, err)
+	for k, v := range hdr {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func TestHandler_ArtifactLifecycle(t *testing.T) {
+	srv, _ := newTestServer(t)
+	base := srv.URL + "/v1/artifacts/"
+
+	// Create via PUT (curl -T)
+	res := do(t, http.MethodPut, base+"report.csv?user_id=alice", "a,b\n1,2\n", nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var created artifactJSON
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+	assert.Equal(t, "report.csv", created.Filename)
+	assert.Equal(t, "text/csv", created.MimeType)
+	assert.Equal(t, int64(8), created.SizeBytes)
+	assert.Equal(t, "/v1/artifacts/"+created.ID, res.Header.Get("Location"))
+
+	// GET by ID
+	res = do(t, http.MethodGet, base+created.ID+"?user_id=alice", "", nil)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "a,b\n1,2\n", string(body))
+	etag := res.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// Wrong scope is not found
+	res = do(t, http.MethodGet, base+created.ID+"?user_id=bob", "", nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// HEAD returns metadata headers without a body
+	res = do(t, http.MethodHead, base+created.ID, "", map[string]string{"X-Artifact-User-ID": "alice"})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, created.ID, res.Header.Get("X-Artifact-ID"))
+	assert.Equal(t, "8", res.Header.Get("Content-Length"))
+
+	// Conditional GET
+	res = do(t, http.MethodGet, base+created.ID+"?user_id=alice", "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+
+	// Range request
+	res = do(t, http.MethodGet, base+created.ID+"?user_id=alice", "", map[string]string{"Range": "bytes=4-6"})
+	body, _ = io.ReadAll(res.Body)
+	assert.Equal(t, http.StatusPartialContent, res.StatusCode)
+	assert.Equal(t, "1,2", string(body))
+
+	// PUT with a stale ETag is rejected, with the current one it replaces in place
+	res = do(t, http.MethodPut, base+created.ID+"?user_id=alice", "x", map[string]string{"If-Match": `"stale"`})
+	assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+	res = do(t, http.MethodPut, base+created.ID+"?user_id=alice", "a,b\n3,4\n", map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var replaced artifactJSON
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&replaced))
+	assert.Equal(t, created.ID, replaced.ID)
+
+	// List
+	res = do(t, http.MethodGet, srv.URL+"/v1/artifacts?user_id=alice", "", nil)
+	var list listJSON
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&list))
+	assert.Len(t, list.Items, 1)
+
+	// DELETE
+	res = do(t, http.MethodDelete, base+created.ID+"?user_id=alice", "", nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	res = do(t, http.MethodDelete, base+created.ID+"?user_id=alice", "", nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestHandler_VFS(t *testing.T) {
+	srv, store := newTestServer(t)
+	base := srv.URL + "/v1/vfs"
+
+	res := do(t, http.MethodPut, base+"/projects/alpha/readme.md", "# Alpha", nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// The artifact is reachable through the store by virtual path
+	content, meta, err := store.Read(t.Context(), "/projects/alpha/readme.md", storage.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "# Alpha", string(content))
+	assert.Equal(t, "text/markdown", meta.MimeType)
+
+	// Overwriting a path keeps the artifact ID
+	res = do(t, http.MethodPut, base+"/projects/alpha/readme.md", "# Alpha v2", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	_, meta2, err := store.Read(t.Context(), "/projects/alpha/readme.md", storage.ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, meta.ID, meta2.ID)
+
+	// GET on a file
+	res = do(t, http.MethodGet, base+"/projects/alpha/readme.md", "", nil)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "# Alpha v2", string(body))
+
+	// GET on a directory lists it
+	res = do(t, http.MethodGet, base+"/projects", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var list listJSON
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&list))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "directory", list.Items[0].MimeType)
+
+	// Unknown path
+	res = do(t, http.MethodGet, base+"/nope", "", nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// DELETE by path
+	res = do(t, http.MethodDelete, base+"/projects/alpha/readme.md", "", nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestHandler_ActiveContent(t *testing.T) {
+	srv, _ := newTestServer(t)
+	base := srv.URL + "/v1/vfs"
+
+	res := do(t, http.MethodPut, base+"/pages/x.html", "<script>alert(1)</script>", map[string]string{"Content-Type": "text/html"})
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	res = do(t, http.MethodGet, base+"/pages/x.html", "", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/html", res.Header.Get("Content-Type"))
+	assert.Equal(t, "attachment; filename=x.html", res.Header.Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", res.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "sandbox", res.Header.Get("Content-Security-Policy"))
+
+	res = do(t, http.MethodPut, base+"/pages/x.txt", "hello", nil)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	res = do(t, http.MethodGet, base+"/pages/x.txt", "", nil)
+	assert.Equal(t, "inline; filename=x.txt", res.Header.Get("Content-Disposition"))
+	assert.Equal(t, "sandbox", res.Header.Get("Content-Security-Policy"))
+}
+
+func TestHandler_Auth(t *testing.T) {
+	store := storage.NewStore(t.TempDir())
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "alice-token", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+		{Token: "admin-token", Principal: auth.Principal{Name: "admin", Admin: true}},
+	})
+	srv := httptest.NewServer(NewHandler(store, a))
+	t.Cleanup(srv.Close)
+	base := srv.URL + "/v1/artifacts/"
+	alice := map[string]string{"Authorization": "Bearer alice-token"}
+
+	res := do(t, http.MethodGet, base, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Without a user_id the principal's own scope is used
+	res = do(t, http.MethodPut, base+"notes.txt", "mine", alice)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	var created artifactJSON
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&created))
+	assert.Equal(t, "alice", created.UserID)
+
+	// Other scopes are forbidden, whichever way they are requested
+	res = do(t, http.MethodGet, base+created.ID+"?user_id=bob", "", alice)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = do(t, http.MethodPut, base+"evil.txt", "x", map[string]string{"Authorization": "Bearer alice-token", "X-Artifact-User-ID": "bob"})
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = do(t, http.MethodGet, srv.URL+"/v1/vfs/?user_id=bob", "", alice)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// Admins may choose any scope
+	res = do(t, http.MethodGet, base+created.ID+"?user_id=alice", "", map[string]string{"Authorization": "Bearer admin-token"})
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "mine", string(body))
+}
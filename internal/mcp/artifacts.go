@@ -7,25 +7,46 @@ package mcp
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/hmsoft0815/mlcartifact/internal/share"
 	"github.com/hmsoft0815/mlcartifact/internal/storage"
 )
 
-// WriteArtifactArgs defines the input for saving an artifact via MCP.
+// WriteArtifactArgs defines the input for saving an artifact via MCP. The
+// tool's input schema is generated from the struct: fields without
+// omitempty are required, and the jsonschema tags describe the fields.
 type WriteArtifactArgs struct {
-	Filename       string                 `json:"filename"`         // Desired filename (e.g. "report.md")
-	Content        string                 `json:"content"`          // Text content to store
-	Description    string                 `json:"description,omitempty"` // Optional human-readable description
-	MimeType       string                 `json:"mime_type,omitempty"`   // Optional MIME type (autodetected if empty)
-	ExpiresInHours int                    `json:"expires_in_hours,omitempty"` // Hours until auto-deletion (default 24)
-	Metadata       map[string]interface{} `json:"metadata,omitempty"`     // Arbitrary key-value pairs
-	UserID         string                 `json:"user_id,omitempty"`      // Scopes the artifact to a specific user
-	VirtualPath    string                 `json:"virtual_path,omitempty"` // Hierarchical path (VFS)
+	Filename       string                 `json:"filename" jsonschema_description:"Desired filename, e.g. \"report.md\""`
+	Content        string                 `json:"content" jsonschema_description:"Content to store; base64-encoded if encoding is \"base64\""`
+	Encoding       string                 `json:"encoding,omitempty" jsonschema:"enum=utf8,enum=base64" jsonschema_description:"Encoding of content: \"utf8\" (default) for text, \"base64\" for binary files such as images and PDFs"`
+	Description    string                 `json:"description,omitempty" jsonschema_description:"Human-readable description"`
+	MimeType       string                 `json:"mime_type,omitempty" jsonschema_description:"MIME type; detected from the filename if empty"`
+	ExpiresInHours int                    `json:"expires_in_hours,omitempty" jsonschema_description:"Hours until the artifact is deleted (default 24)"`
+	TTL            string                 `json:"ttl,omitempty" jsonschema:"enum=session" jsonschema_description:"\"session\" for scratch files: the artifact is also deleted when this MCP session ends"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty" jsonschema_description:"Arbitrary key-value pairs"`
+	UserID         string                 `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+	VirtualPath    string                 `json:"virtual_path,omitempty" jsonschema_description:"Absolute path in the virtual file system, e.g. \"/docs/report.md\""`
+	Confirmation   string                 `json:"confirmation_token,omitempty" jsonschema_description:"Token of a CONFIRMATION_REQUIRED error, to repeat the call once the user agreed"`
+}
+
+// WriteArtifactResult is the structured content of a write_artifact result.
+type WriteArtifactResult struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	MimeType    string    `json:"mime_type"`
+	Size        int64     `json:"size" jsonschema_description:"Content size in bytes"`
+	VirtualPath string    `json:"virtual_path,omitempty"`
+	URI         string    `json:"uri" jsonschema_description:"artifact:// URI to read the artifact as an MCP resource"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Reference   string    `json:"reference" jsonschema_description:"Tag to include in the answer to the user"`
 }
 
 var store = storage.NewStore(".artifacts")
@@ -35,7 +56,73 @@ func SetStore(s *storage.Store) {
 	store = s
 }
 
-const errInvalidArgs = "invalid arguments: "
+var shareSigner *share.Signer
+
+// SetShareSigner enables the create_share_link tool using the given signer.
+func SetShareSigner(s *share.Signer) {
+	shareSigner = s
+}
+
+// ReasonUnavailable reports a tool that is not configured on this server. The
+// other reasons of error results are those of storage.Reason.
+const ReasonUnavailable = "UNAVAILABLE"
+
+// ToolError is the structured content of an error result:
+//
+//	{"error": {"reason": "NOT_FOUND", "message": "read \"/a.md\": artifact not found"}}
+type ToolError struct {
+	Reason            string `json:"reason"`
+	Message           string `json:"message"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"` // Set for QUOTA_EXCEEDED if retrying later helps
+	ConfirmationToken string `json:"confirmation_token,omitempty"`  // Set for CONFIRMATION_REQUIRED
+}
+
+// ErrorResult returns an isError result whose text is the message and whose
+// structured content carries the reason, so clients can react to it.
+func ErrorResult(reason, message string) *mcp.CallToolResult {
+	return toolErrorResult(ToolError{Reason: reason, Message: message})
+}
+
+// RetryResult is like ErrorResult but also tells the client how many seconds
+// to wait before retrying.
+func RetryResult(reason, message string, retryAfterSeconds int) *mcp.CallToolResult {
+	return toolErrorResult(ToolError{Reason: reason, Message: message, RetryAfterSeconds: retryAfterSeconds})
+}
+
+func toolErrorResult(te ToolError) *mcp.CallToolResult {
+	res := mcp.NewToolResultStructured(map[string]ToolError{"error": te}, te.Message)
+	res.IsError = true
+	return res
+}
+
+// toolError returns an error result for a storage error.
+func toolError(err error) *mcp.CallToolResult {
+	if errors.Is(err, context.Canceled) {
+		return ErrorResult(ReasonCancelled, err.Error())
+	}
+	return ErrorResult(storage.Reason(err), err.Error())
+}
+
+// invalidArgs returns an error result for invalid tool arguments.
+func invalidArgs(message string) *mcp.CallToolResult {
+	return ErrorResult(storage.ReasonInvalidArgument, "invalid arguments: "+message)
+}
+
+// bindArgs parses the arguments of req into args, a pointer to an *Args
+// struct. It returns an error result if they do not fit.
+func bindArgs(req mcp.CallToolRequest, args any) *mcp.CallToolResult {
+	if err := req.BindArguments(args); err != nil {
+		return invalidArgs(err.Error())
+	}
+	return nil
+}
+
+// structuredResult returns a result with v as structured content and, for
+// clients that only read text, as indented JSON text.
+func structuredResult(v any) *mcp.CallToolResult {
+	text, _ := json.MarshalIndent(v, "", "  ")
+	return mcp.NewToolResultStructured(v, string(text))
+}
 
 // MCPListLimit defines the default maximum number of artifacts returned via MCP.
 var MCPListLimit = 100
@@ -46,217 +133,361 @@ func SetMCPListLimit(limit int) {
 }
 
 // WriteArtifact is an MCP tool handler that saves a file to the artifacts store.
-// It returns a JSON response containing the artifact ID and a reference tag.
+// It returns a WriteArtifactResult with the artifact ID and a reference tag.
 func WriteArtifact(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// 1. Run Cleanup first to keep house clean
-	store.Cleanup()
+	_ = store.Cleanup(ctx)
 
 	// 2. Parse arguments
 	var args WriteArtifactArgs
-	argBytes, _ := json.Marshal(req.Params.Arguments)
-	if err := json.Unmarshal(argBytes, &args); err != nil {
-		return mcp.NewToolResultText(errInvalidArgs + err.Error()), nil
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
 	}
 
 	if args.Filename == "" || args.Content == "" {
-		return mcp.NewToolResultText("filename and content are required"), nil
+		return invalidArgs("filename and content are required"), nil
+	}
+
+	var content []byte
+	switch args.Encoding {
+	case "", EncodingUTF8:
+		content = []byte(args.Content)
+	case EncodingBase64:
+		var err error
+		if content, err = base64.StdEncoding.DecodeString(args.Content); err != nil {
+			return invalidArgs("content is not valid base64: " + err.Error()), nil
+		}
+	default:
+		return invalidArgs(fmt.Sprintf("unknown encoding %q, use %q or %q", args.Encoding, EncodingUTF8, EncodingBase64)), nil
+	}
+	if args.TTL != "" && args.TTL != TTLSession {
+		return invalidArgs(fmt.Sprintf("unknown ttl %q, use %q or leave it empty", args.TTL, TTLSession)), nil
 	}
 
 	// 3. Write via shared store
-	meta, err := store.Write(
-		args.Filename,
-		[]byte(args.Content),
-		args.MimeType,
-		int(args.ExpiresInHours),
-		"mcp-tool", // source
-		args.UserID,
-		args.Description,
-		args.Metadata,
-		args.VirtualPath,
-	)
+	meta, err := store.Write(ctx, args.Filename, content, storage.WriteOptions{
+		MimeType:     args.MimeType,
+		ExpiresHours: int(args.ExpiresInHours),
+		Source:       "mcp-tool",
+		UserID:       args.UserID,
+		Description:  args.Description,
+		Metadata:     args.Metadata,
+		VirtualPath:  args.VirtualPath,
+	})
 
 	if err != nil {
-		return nil, fmt.Errorf("storage error: %w", err)
+		return toolError(err), nil
 	}
-
-	// 4. Build response
-	fileTag := fmt.Sprintf("<file id=\"%s\" type=\"%s\">%s</file>", meta.ID, meta.MimeType, meta.Filename)
-	res := map[string]interface{}{
-		"id":         meta.ID,
-		"filename":   meta.Filename,
-		"mime_type":  meta.MimeType,
-		"expires_at": meta.ExpiresAt.Format(time.RFC3339),
-		"reference":  fileTag,
+	if args.TTL == TTLSession && !trackSessionArtifact(ctx, meta) {
+		// Nobody would delete it when the session ends
+		_, _ = store.Delete(ctx, meta.ID, storage.DeleteOptions{UserID: meta.UserID})
+		return ErrorResult(ReasonUnavailable, "session artifacts are not available: this MCP server does not track sessions"), nil
 	}
-	resBytes, _ := json.MarshalIndent(res, "", "  ")
-
-	slog.Info("artifact saved via MCP", "id", meta.ID, "filename", meta.Filename, "vpath", meta.VirtualPath)
 
-	return mcp.NewToolResultText(string(resBytes)), nil
+	// 4. Build response
+	slog.InfoContext(ctx, "artifact saved via MCP", "id", meta.ID, "filename", meta.Filename, "vpath", meta.VirtualPath)
+
+	return structuredResult(WriteArtifactResult{
+		ID:          meta.ID,
+		Filename:    meta.Filename,
+		MimeType:    meta.MimeType,
+		Size:        int64(len(content)),
+		VirtualPath: meta.VirtualPath,
+		URI:         meta.URI(),
+		ExpiresAt:   meta.ExpiresAt,
+		Reference:   fmt.Sprintf("<file id=\"%s\" type=\"%s\">%s</file>", meta.ID, meta.MimeType, meta.Filename),
+	}), nil
 }
 
 // ReadArtifactArgs defines the input for reading an artifact via MCP.
 type ReadArtifactArgs struct {
-	ID     string `json:"id"`               // The ID or filename of the artifact
-	UserID string `json:"user_id,omitempty"` // The user scope
+	ID       string `json:"id" jsonschema_description:"ID, filename or virtual path"`
+	Encoding string `json:"encoding,omitempty" jsonschema:"enum=utf8,enum=base64" jsonschema_description:"Force a text result: \"utf8\" fails for binary content, \"base64\" returns the content base64-encoded"`
+	UserID   string `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+}
+
+// ReadArtifactResult is the structured content of a read_artifact result.
+// The content itself is in the result's content blocks.
+type ReadArtifactResult struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	MimeType    string `json:"mime_type"`
+	Size        int64  `json:"size" jsonschema_description:"Content size in bytes"`
+	VirtualPath string `json:"virtual_path,omitempty"`
+	URI         string `json:"uri"`
 }
 
 // ReadArtifact is an MCP tool handler that retrieves an artifact's content.
+// Text is returned as text, PNG, JPEG, GIF and WebP images as image content
+// and other binary content as an embedded blob resource. The "encoding"
+// argument forces a text result instead: "utf8" refuses binary content,
+// "base64" encodes it. The structured content is a ReadArtifactResult.
 func ReadArtifact(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args ReadArtifactArgs
-	argBytes, _ := json.Marshal(req.Params.Arguments)
-	if err := json.Unmarshal(argBytes, &args); err != nil {
-		return mcp.NewToolResultText(errInvalidArgs + err.Error()), nil
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
 	}
 
 	if args.ID == "" {
-		return mcp.NewToolResultText("id is required"), nil
+		return invalidArgs("id is required"), nil
 	}
 
-	content, meta, err := store.Read(args.ID, args.UserID)
+	content, meta, err := store.Read(ctx, args.ID, storage.ReadOptions{UserID: args.UserID})
 	if err != nil {
-		return mcp.NewToolResultText("error reading artifact: " + err.Error()), nil
+		return toolError(err), nil
 	}
 
-	slog.Info("artifact read via MCP", "id", meta.ID, "filename", meta.Filename)
+	slog.InfoContext(ctx, "artifact read via MCP", "id", meta.ID, "filename", meta.Filename)
+
+	res := &mcp.CallToolResult{StructuredContent: ReadArtifactResult{
+		ID:          meta.ID,
+		Filename:    meta.Filename,
+		MimeType:    meta.MimeType,
+		Size:        int64(len(content)),
+		VirtualPath: meta.VirtualPath,
+		URI:         meta.URI(),
+	}}
+	switch args.Encoding {
+	case EncodingUTF8:
+		if !utf8.Valid(content) {
+			return ErrorResult(storage.ReasonInvalidArgument, fmt.Sprintf("%s (%s) is not UTF-8 text, read it with encoding %q", meta.Filename, meta.MimeType, EncodingBase64)), nil
+		}
+		res.Content = []mcp.Content{mcp.NewTextContent(string(content))}
+		return res, nil
+	case EncodingBase64:
+		res.Content = []mcp.Content{mcp.NewTextContent(base64.StdEncoding.EncodeToString(content))}
+		return res, nil
+	case "":
+	default:
+		return invalidArgs(fmt.Sprintf("unknown encoding %q, use %q or %q", args.Encoding, EncodingUTF8, EncodingBase64)), nil
+	}
 
-	// We return the content directly as text if possible, or as a message
-	return mcp.NewToolResultText(string(content)), nil
+	if isText(meta.MimeType, content) {
+		res.Content = []mcp.Content{mcp.NewTextContent(string(content))}
+		return res, nil
+	}
+
+	// Binary content never goes into a text block: images are shown to the
+	// model, everything else is attached as a blob
+	summary := mcp.NewTextContent(fmt.Sprintf("%s (%s, %d bytes)", meta.Filename, meta.MimeType, len(content)))
+	data := base64.StdEncoding.EncodeToString(content)
+	if isImage(meta.MimeType) {
+		res.Content = []mcp.Content{summary, mcp.NewImageContent(data, meta.MimeType)}
+		return res, nil
+	}
+	blob := mcp.BlobResourceContents{URI: meta.URI(), MIMEType: meta.MimeType, Blob: data}
+	res.Content = []mcp.Content{summary, mcp.NewEmbeddedResource(blob)}
+	return res, nil
 }
 
+// Encodings of the content of write_artifact and the result of
+// read_artifact.
+const (
+	EncodingUTF8   = "utf8"
+	EncodingBase64 = "base64"
+)
+
 // ListArtifactsArgs defines the input for listing artifacts via MCP.
 type ListArtifactsArgs struct {
-	UserID string `json:"user_id,omitempty"` // Optional user scope to filter results
+	UserID string `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+	ListingArgs
 }
 
-	// ListArtifacts is an MCP tool handler that returns a list of available artifacts.
+// ListArtifacts is an MCP tool handler that returns a list of available artifacts.
 func ListArtifacts(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args ListArtifactsArgs
-	argBytes, _ := json.Marshal(req.Params.Arguments)
-	if err := json.Unmarshal(argBytes, &args); err != nil {
-		return mcp.NewToolResultText(errInvalidArgs + err.Error()), nil
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
 	}
 
 	// We limit MCP results as LLMs don't need huge lists.
-	items, err := store.List(args.UserID, int(MCPListLimit), 0, "")
+	l, res := newListing(args.ListingArgs, "list_artifacts\x00"+args.UserID)
+	if res != nil {
+		return res, nil
+	}
+	items, err := store.List(ctx, storage.ListOptions{UserID: args.UserID, Limit: l.fetchLimit(), Offset: l.offset})
 	if err != nil {
-		return mcp.NewToolResultText("error listing artifacts: " + err.Error()), nil
+		return toolError(err), nil
 	}
 
-	resBytes, _ := json.MarshalIndent(items, "", "  ")
-	return mcp.NewToolResultText(string(resBytes)), nil
+	return l.result(items), nil
 }
 
 // DeleteArtifactArgs defines the input for deleting an artifact via MCP.
 type DeleteArtifactArgs struct {
-	ID     string `json:"id"`               // The ID or filename of the artifact to delete
-	UserID string `json:"user_id,omitempty"` // The user scope
+	ID           string `json:"id" jsonschema_description:"ID, filename or virtual path"`
+	UserID       string `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+	Confirmation string `json:"confirmation_token,omitempty" jsonschema_description:"Token of a CONFIRMATION_REQUIRED error, to repeat the call once the user agreed"`
+}
+
+// DeleteArtifactResult is the structured content of a delete_artifact
+// result.
+type DeleteArtifactResult struct {
+	ID      string `json:"id" jsonschema_description:"The id argument"`
+	Deleted bool   `json:"deleted"`
 }
 
 // DeleteArtifact is an MCP tool handler that removes an artifact from the store.
 func DeleteArtifact(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args DeleteArtifactArgs
-	argBytes, _ := json.Marshal(req.Params.Arguments)
-	if err := json.Unmarshal(argBytes, &args); err != nil {
-		return mcp.NewToolResultText(errInvalidArgs + err.Error()), nil
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
 	}
 
 	if args.ID == "" {
-		return mcp.NewToolResultText("id is required"), nil
+		return invalidArgs("id is required"), nil
 	}
 
-	deleted, err := store.Delete(args.ID, args.UserID)
+	deleted, err := store.Delete(ctx, args.ID, storage.DeleteOptions{UserID: args.UserID})
 	if err != nil {
-		return mcp.NewToolResultText("error deleting artifact: " + err.Error()), nil
+		return toolError(err), nil
 	}
 
 	if !deleted {
-		return mcp.NewToolResultText("artifact not found"), nil
+		return toolError(&storage.Error{Op: "delete", Path: args.ID, UserID: args.UserID, Err: storage.ErrNotFound}), nil
 	}
 
-	slog.Info("artifact deleted via MCP", "id", args.ID)
-	return mcp.NewToolResultText("artifact deleted successfully"), nil
+	slog.InfoContext(ctx, "artifact deleted via MCP", "id", args.ID)
+	return mcp.NewToolResultStructured(DeleteArtifactResult{ID: args.ID, Deleted: true}, "artifact deleted successfully"), nil
 }
 
 // VFSPatchArgs defines the input for patching an artifact via MCP.
 type VFSPatchArgs struct {
-	ID        string `json:"id"`                  // ID or virtual path
-	Content   string `json:"content"`             // Text to insert/append
-	LineStart int    `json:"line_start,omitempty"` // Optional start line
-	LineEnd   int    `json:"line_end,omitempty"`   // Optional end line
-	Append    bool   `json:"append,omitempty"`     // If true, appends to end
-	UserID    string `json:"user_id,omitempty"`    // User scope
+	ID           string `json:"id" jsonschema_description:"ID or virtual path"`
+	Content      string `json:"content" jsonschema_description:"Text to insert or append"`
+	LineStart    int    `json:"line_start,omitempty" jsonschema_description:"First line to replace (0-based)"`
+	LineEnd      int    `json:"line_end,omitempty" jsonschema_description:"Line after the last one to replace"`
+	Append       bool   `json:"append,omitempty" jsonschema_description:"Append to the end instead of replacing lines"`
+	UserID       string `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+	Confirmation string `json:"confirmation_token,omitempty" jsonschema_description:"Token of a CONFIRMATION_REQUIRED error, to repeat the call once the user agreed"`
+}
+
+// VFSPatchResult is the structured content of a vfs_patch result.
+type VFSPatchResult struct {
+	Success bool  `json:"success"`
+	NewSize int64 `json:"new_size" jsonschema_description:"Content size in bytes after the patch"`
 }
 
 // VFSPatch is an MCP tool handler that modifies an artifact's content.
 func VFSPatch(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args VFSPatchArgs
-	argBytes, _ := json.Marshal(req.Params.Arguments)
-	if err := json.Unmarshal(argBytes, &args); err != nil {
-		return mcp.NewToolResultText(errInvalidArgs + err.Error()), nil
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
 	}
 
-	newSize, err := store.Patch(args.ID, args.UserID, []byte(args.Content), args.LineStart, args.LineEnd, args.Append)
-	if err != nil {
-		return mcp.NewToolResultText("error patching artifact: " + err.Error()), nil
+	if args.ID == "" {
+		return invalidArgs("id is required"), nil
 	}
 
-	res := map[string]interface{}{
-		"success":  true,
-		"new_size": newSize,
+	newSize, err := store.Patch(ctx, args.ID, []byte(args.Content), storage.PatchOptions{UserID: args.UserID, LineStart: args.LineStart, LineEnd: args.LineEnd, Append: args.Append})
+	if err != nil {
+		return toolError(err), nil
 	}
-	resBytes, _ := json.MarshalIndent(res, "", "  ")
-	return mcp.NewToolResultText(string(resBytes)), nil
+
+	return structuredResult(VFSPatchResult{Success: true, NewSize: newSize}), nil
 }
 
 // VFSListArgs defines the input for listing a virtual directory.
 type VFSListArgs struct {
-	Path   string `json:"path"`               // The virtual directory path (e.g. "/docs")
-	UserID string `json:"user_id,omitempty"` // User scope
+	Path   string `json:"path" jsonschema_description:"Virtual directory, e.g. \"/docs\""`
+	UserID string `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+	ListingArgs
 }
 
 // VFSList is an MCP tool handler that lists a virtual directory.
 func VFSList(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args VFSListArgs
-	argBytes, _ := json.Marshal(req.Params.Arguments)
-	if err := json.Unmarshal(argBytes, &args); err != nil {
-		return mcp.NewToolResultText(errInvalidArgs + err.Error()), nil
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
 	}
 
-	items, err := store.List(args.UserID, int(MCPListLimit), 0, args.Path)
+	l, res := newListing(args.ListingArgs, "vfs_ls\x00"+args.UserID+"\x00"+args.Path)
+	if res != nil {
+		return res, nil
+	}
+	items, err := store.List(ctx, storage.ListOptions{UserID: args.UserID, DirPath: args.Path, Limit: l.fetchLimit(), Offset: l.offset})
 	if err != nil {
-		return mcp.NewToolResultText("error listing vfs: " + err.Error()), nil
+		return toolError(err), nil
 	}
 
-	resBytes, _ := json.MarshalIndent(items, "", "  ")
-	return mcp.NewToolResultText(string(resBytes)), nil
+	return l.result(items), nil
 }
 
 // VFSFindArgs defines the input for searching artifacts.
 type VFSFindArgs struct {
-	Pattern string `json:"pattern"`           // Glob pattern (e.g. "*.txt")
-	UserID  string `json:"user_id,omitempty"` // User scope
+	Pattern string `json:"pattern" jsonschema_description:"Glob pattern, e.g. \"/**/*.go\""`
+	UserID  string `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+	ListingArgs
 }
 
 // VFSFind is an MCP tool handler that searches for artifacts.
 func VFSFind(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var args VFSFindArgs
-	argBytes, _ := json.Marshal(req.Params.Arguments)
-	if err := json.Unmarshal(argBytes, &args); err != nil {
-		return mcp.NewToolResultText(errInvalidArgs + err.Error()), nil
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
 	}
 
-	items, err := store.Find(args.UserID, args.Pattern)
+	l, res := newListing(args.ListingArgs, "vfs_find\x00"+args.UserID+"\x00"+args.Pattern)
+	if res != nil {
+		return res, nil
+	}
+	items, err := store.Find(ctx, args.Pattern, storage.FindOptions{UserID: args.UserID, Limit: l.fetchLimit(), Offset: l.offset})
 	if err != nil {
-		return mcp.NewToolResultText("error finding artifacts: " + err.Error()), nil
+		return toolError(err), nil
 	}
 
-	resBytes, _ := json.MarshalIndent(items, "", "  ")
-	return mcp.NewToolResultText(string(resBytes)), nil
+	return l.result(items), nil
 }
 
+// CreateShareLinkArgs defines the input for creating a download link via MCP.
+type CreateShareLinkArgs struct {
+	ID             string `json:"id" jsonschema_description:"ID, filename or virtual path"`
+	ExpiresMinutes int    `json:"expires_minutes,omitempty" jsonschema_description:"Link lifetime in minutes (default 60)"`
+	UserID         string `json:"user_id,omitempty" jsonschema_description:"User scope; if empty, that of the authenticated user or else global"`
+}
+
+// CreateShareLinkResult is the structured content of a create_share_link
+// result.
+type CreateShareLinkResult struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Filename  string    `json:"filename"`
+	MimeType  string    `json:"mime_type"`
+}
+
+// CreateShareLink is an MCP tool handler that returns a signed, expiring
+// HTTP URL a human can open to download the artifact.
+func CreateShareLink(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args CreateShareLinkArgs
+	if res := bindArgs(req, &args); res != nil {
+		return res, nil
+	}
+
+	if args.ID == "" {
+		return invalidArgs("id is required"), nil
+	}
+	if shareSigner == nil {
+		return ErrorResult(ReasonUnavailable, "share links are not configured on this server"), nil
+	}
+
+	_, meta, err := store.Stat(ctx, args.ID, storage.StatOptions{UserID: args.UserID})
+	store.Report(ctx, storage.Operation{Name: "CreateShareLink", Ref: args.ID, UserID: args.UserID, Artifact: meta}, err)
+	if err != nil {
+		return toolError(err), nil
+	}
+
+	link := shareSigner.Sign(meta.ID, args.UserID, time.Duration(args.ExpiresMinutes)*time.Minute, meta.ExpiresAt)
+	slog.InfoContext(ctx, "share link created via MCP", "id", meta.ID, "expires_at", link.ExpiresAt)
+	return structuredResult(CreateShareLinkResult{
+		URL:       link.URL,
+		ExpiresAt: link.ExpiresAt,
+		Filename:  meta.Filename,
+		MimeType:  meta.MimeType,
+	}), nil
+}
 
 // HandleVFSUsagePrompt provides guidelines to the LLM on using the virtual file system.
+// The optional "directory" argument names the directory to work in.
 func HandleVFSUsagePrompt(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
 	instructions := `# mlcartifact VFS Usage Guidelines
 
@@ -277,12 +508,16 @@ For large artifacts (code files, data logs, long reports), prefer ` + "`vfs_patc
 - Use ` + "`vfs_ls`" + ` to list contents of a virtual directory.
 - Use ` + "`vfs_find`" + ` with glob patterns (e.g., ` + "`/**/*.go`" + `) to search across the entire user scope.
 - When reading an artifact by path, ensure you include the leading ` + "`/`" + `.
+- Listings are paged: pass ` + "`next_cursor`" + ` as ` + "`cursor`" + ` to continue. Use ` + "`format: \"table\"`" + ` and ` + "`fields`" + ` for concise output and ` + "`max_tokens`" + ` to bound it.
 
 ## 4. Cross-Tool References
 When you save an artifact, you receive a reference tag like ` + "`<file id=\"...\" type=\"...\">filename</file>`" + `. 
 - **Always** include this tag in your final response to the user so they can access the file.
 - Other tools (like D2 renderer or Barcode generator) can also output these tags.
 `
+	if dir := req.Params.Arguments["directory"]; dir != "" {
+		instructions += "\n## 5. Working Directory\nKeep the artifacts of this task below `" + storage.NormalizePath(dir) + "`.\n"
+	}
 
 	return &mcp.GetPromptResult{
 		Description: "Guidelines for using the mlcartifact VFS capabilities.",
@@ -0,0 +1,175 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// MaxCompletions is the most values a completion returns, the limit of the
+// MCP specification. Completion.HasMore tells that there are more.
+const MaxCompletions = 100
+
+// Completer answers completion/complete from the VFS index, so that hosts
+// can offer existing paths instead of letting the model guess them. It
+// completes the arguments of ResourceTemplate and the prompt arguments
+// named after tool arguments:
+//
+//   - "path", "virtual_path" and "id" with virtual paths, the children of
+//     the directory typed so far; "id" also with recent artifact IDs
+//   - "directory" and "dir" with virtual directories
+//   - "user_id" and the template's "user" with the user scopes the caller
+//     may access
+//
+// Completions are scoped like tool calls: to the "user_id" (or "user")
+// argument already given, which defaults to the scope of the session's
+// principal.
+type Completer struct{}
+
+// CompletionOptions returns the options that make an MCP server answer
+// completion/complete with a Completer.
+func CompletionOptions() []server.ServerOption {
+	return []server.ServerOption{
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(Completer{}),
+		server.WithResourceCompletionProvider(Completer{}),
+	}
+}
+
+// CompletePromptArgument completes an argument of any prompt by its name.
+func (Completer) CompletePromptArgument(ctx context.Context, promptName string, arg mcp.CompleteArgument, cc mcp.CompleteContext) (*mcp.Completion, error) {
+	switch arg.Name {
+	case "user_id":
+		return completeScopes(ctx, arg.Value)
+	case "path", "virtual_path", "id", "directory", "dir":
+	default:
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+
+	userID, err := completionScope(ctx, cc.Arguments["user_id"], false)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, path := range store.CompletePath(userID, arg.Value) {
+		if strings.HasSuffix(path, "/") || (arg.Name != "directory" && arg.Name != "dir") {
+			values = append(values, path)
+		}
+	}
+	if arg.Name == "id" && !strings.HasPrefix(arg.Value, "/") {
+		ids, err := recentIDs(ctx, userID, arg.Value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(ids, values...)
+	}
+	return completion(values), nil
+}
+
+// CompleteResourceArgument completes the {user} and {+path} arguments of
+// ResourceTemplate. Paths are completed without their leading slash, as
+// they follow the user in the URI, and include the recent artifact IDs.
+func (Completer) CompleteResourceArgument(ctx context.Context, uri string, arg mcp.CompleteArgument, cc mcp.CompleteContext) (*mcp.Completion, error) {
+	if uri != ResourceTemplate {
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+	switch arg.Name {
+	case "user":
+		return completeScopes(ctx, arg.Value)
+	case "path":
+	default:
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+
+	userID, err := completionScope(ctx, cc.Arguments["user"], true)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	if !strings.Contains(arg.Value, "/") {
+		if values, err = recentIDs(ctx, userID, arg.Value); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range store.CompletePath(userID, "/"+arg.Value) {
+		values = append(values, path[1:])
+	}
+	return completion(values), nil
+}
+
+// completionScope resolves the user scope of a completion against the
+// session's principal. Like in URIs, an empty scope is the global one if
+// explicit is set.
+func completionScope(ctx context.Context, requested string, explicit bool) (string, error) {
+	p, err := sessionPrincipal(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ReasonPermissionDenied, err)
+	}
+	userID, err := p.ResolveScope(requested)
+	if err == nil && explicit && userID != requested {
+		err = fmt.Errorf("%w: %q", auth.ErrForbidden, requested)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ReasonPermissionDenied, err)
+	}
+	return userID, nil
+}
+
+// completeScopes completes a user scope: admins get every scope with
+// artifacts, other principals their own.
+func completeScopes(ctx context.Context, prefix string) (*mcp.Completion, error) {
+	p, err := sessionPrincipal(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ReasonPermissionDenied, err)
+	}
+	scopes := []string{p.UserID}
+	if p.Admin {
+		scopes = store.Scopes()
+	}
+	var values []string
+	for _, scope := range scopes {
+		if scope != "" && strings.HasPrefix(scope, prefix) {
+			values = append(values, scope)
+		}
+	}
+	return completion(values), nil
+}
+
+// recentIDs returns the IDs of the artifacts of a user scope that start
+// with prefix, newest first.
+func recentIDs(ctx context.Context, userID, prefix string) ([]string, error) {
+	items, err := store.List(ctx, storage.ListOptions{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(items, func(a, b *storage.ArtifactMetadata) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	var ids []string
+	for _, meta := range items {
+		if strings.HasPrefix(meta.ID, prefix) {
+			ids = append(ids, meta.ID)
+		}
+	}
+	return ids, nil
+}
+
+// completion returns at most MaxCompletions of values.
+func completion(values []string) *mcp.Completion {
+	c := &mcp.Completion{Values: values, Total: len(values)}
+	if len(values) > MaxCompletions {
+		c.Values, c.HasMore = values[:MaxCompletions], true
+	}
+	if c.Values == nil {
+		c.Values = []string{}
+	}
+	return c
+}
@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func complete(t *testing.T, c *client.Client, ref any, name, value string, args map[string]string) ([]string, error) {
+	req := mcp.CompleteRequest{}
+	req.Params.Ref = ref
+	req.Params.Argument = mcp.CompleteArgument{Name: name, Value: value}
+	req.Params.Context.Arguments = args
+	res, err := c.Complete(t.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Completion.Values, nil
+}
+
+func TestCompleter(t *testing.T) {
+	st := storage.NewStore(t.TempDir())
+	SetStore(st)
+	var ids []string
+	for _, w := range []struct{ user, path string }{
+		{"alice", "/projects/alpha/plan.md"},
+		{"alice", "/projects/beta.md"},
+		{"alice", ""},
+		{"bob", "/projects/bob.md"},
+	} {
+		meta, err := st.Write(t.Context(), "f.md", []byte("x"), storage.WriteOptions{UserID: w.user, VirtualPath: w.path})
+		require.NoError(t, err)
+		ids = append(ids, meta.ID)
+	}
+
+	hooks := &server.Hooks{}
+	AddSessionHooks(hooks)
+	s := server.NewMCPServer("test", "1.0.0", append(CompletionOptions(), server.WithHooks(hooks))...)
+	Register(s, ToolConfig{})
+	require.NoError(t, RegisterResources(t.Context(), s))
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "alice-token", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+		{Token: "admin-token", Principal: auth.Principal{Name: "admin", Admin: true}},
+	})
+	srv := httptest.NewServer(a.Middleware(server.NewStreamableHTTPServer(s)))
+	defer srv.Close()
+
+	alice := connect(t, srv.URL, "alice-token")
+	admin := connect(t, srv.URL, "admin-token")
+	prompt := mcp.PromptReference{Type: "ref/prompt", Name: VFSUsagePrompt}
+	template := mcp.ResourceReference{Type: "ref/resource", URI: ResourceTemplate}
+
+	// Prompt arguments complete in the session's own scope
+	values, err := complete(t, alice, prompt, "directory", "/pro", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/projects/"}, values)
+	values, err = complete(t, alice, prompt, "path", "/projects/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/projects/alpha/", "/projects/beta.md"}, values)
+	values, err = complete(t, alice, prompt, "id", "", nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{ids[0], ids[1], ids[2], "/projects/"}, values)
+	_, err = complete(t, alice, prompt, "path", "/", map[string]string{"user_id": "bob"})
+	assert.ErrorContains(t, err, ReasonPermissionDenied)
+
+	// Template arguments: the user is explicit, paths have no leading slash
+	values, err = complete(t, alice, template, "user", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, values)
+	values, err = complete(t, alice, template, "path", "projects/a", map[string]string{"user": "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"projects/alpha/"}, values)
+	_, err = complete(t, alice, template, "path", "", map[string]string{"user": "bob"})
+	assert.ErrorContains(t, err, ReasonPermissionDenied)
+
+	values, err = complete(t, admin, template, "user", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, values)
+	values, err = complete(t, admin, template, "path", "projects/", map[string]string{"user": "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"projects/bob.md"}, values)
+
+	// The prompt uses the completed directory
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = VFSUsagePrompt
+	req.Params.Arguments = map[string]string{"directory": "/projects/alpha"}
+	res, err := alice.GetPrompt(t.Context(), req)
+	require.NoError(t, err)
+	assert.Contains(t, res.Messages[0].Content.(mcp.TextContent).Text, "`/projects/alpha`")
+}
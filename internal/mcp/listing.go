@@ -0,0 +1,255 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Output formats of ListingArgs.
+const (
+	FormatJSON  = "json"
+	FormatTable = "table"
+)
+
+// Fields of an ArtifactEntry, in the order of the table format.
+var listingFields = []string{"id", "virtual_path", "filename", "mime_type", "description", "source", "user_id", "created_at", "expires_at", "metadata"}
+
+// tableFields are the default fields of the table format.
+var tableFields = []string{"id", "virtual_path", "filename", "mime_type"}
+
+// ListingArgs are the arguments of list_artifacts, vfs_ls and vfs_find that
+// page through the listing and keep it small. A listing that is cut short
+// by limit or by the budget returns next_cursor; repeating the call with it
+// returns the next page. The cursor holds the offset of that page, so
+// artifacts created or deleted between two calls shift the listing: the
+// next page then repeats or skips entries.
+type ListingArgs struct {
+	Cursor    string   `json:"cursor,omitempty" jsonschema_description:"next_cursor of the previous page, to continue the listing"`
+	Limit     int      `json:"limit,omitempty" jsonschema_description:"Maximum number of artifacts per page; the server's maximum if 0"`
+	Format    string   `json:"format,omitempty" jsonschema:"enum=json,enum=table" jsonschema_description:"\"json\" (default) for objects, \"table\" for one tab-separated line per artifact"`
+	Fields    []string `json:"fields,omitempty" jsonschema_description:"Fields to return, of id, virtual_path, filename, mime_type, description, source, user_id, created_at, expires_at and metadata; all for json and the first four for table by default"`
+	MaxBytes  int      `json:"max_bytes,omitempty" jsonschema_description:"End the page before its output exceeds this many bytes"`
+	MaxTokens int      `json:"max_tokens,omitempty" jsonschema_description:"End the page before its output exceeds about this many tokens"`
+}
+
+// ArtifactList is the structured content of the results of list_artifacts,
+// vfs_ls and vfs_find. In the table format the artifacts are in Table, not
+// in Artifacts.
+type ArtifactList struct {
+	Artifacts  []ArtifactEntry `json:"artifacts"`
+	Table      string          `json:"table,omitempty" jsonschema_description:"Header line and one line per artifact, tab-separated"`
+	NextCursor string          `json:"next_cursor,omitempty" jsonschema_description:"Set if there are more artifacts: pass it as cursor to get them"`
+}
+
+// ArtifactEntry is an artifact of an ArtifactList with the fields selected
+// by ListingArgs.Fields. Directories of vfs_ls have the MIME type
+// "directory".
+type ArtifactEntry struct {
+	ID          string         `json:"id,omitempty"`
+	VirtualPath string         `json:"virtual_path,omitempty"`
+	Filename    string         `json:"filename,omitempty"`
+	MimeType    string         `json:"mime_type,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Source      string         `json:"source,omitempty"`
+	UserID      string         `json:"user_id,omitempty"`
+	CreatedAt   *time.Time     `json:"created_at,omitempty"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// listing is a page of a listing being built from ListingArgs.
+type listing struct {
+	ListingArgs
+	query  string // Identifies the listing, so cursors only continue it
+	offset int
+}
+
+// listingCursor is the content of a next_cursor.
+type listingCursor struct {
+	Query  string `json:"q"`
+	Offset int    `json:"o"`
+}
+
+// newListing checks args and decodes the cursor of a listing identified by
+// query. It returns an error result for invalid arguments.
+func newListing(args ListingArgs, query string) (*listing, *mcp.CallToolResult) {
+	l := &listing{ListingArgs: args, query: query}
+	switch l.Format {
+	case "":
+		l.Format = FormatJSON
+	case FormatJSON, FormatTable:
+	default:
+		return nil, invalidArgs(fmt.Sprintf("unknown format %q, use %q or %q", l.Format, FormatJSON, FormatTable))
+	}
+	for _, f := range l.Fields {
+		if !slices.Contains(listingFields, f) {
+			return nil, invalidArgs(fmt.Sprintf("unknown field %q, use %s", f, strings.Join(listingFields, ", ")))
+		}
+	}
+	if len(l.Fields) == 0 {
+		l.Fields = listingFields
+		if l.Format == FormatTable {
+			l.Fields = tableFields
+		}
+	}
+	if l.Limit <= 0 || (MCPListLimit > 0 && l.Limit > MCPListLimit) {
+		l.Limit = MCPListLimit
+	}
+
+	if l.Cursor != "" {
+		var c listingCursor
+		data, err := base64.RawURLEncoding.DecodeString(l.Cursor)
+		if err == nil {
+			err = json.Unmarshal(data, &c)
+		}
+		if err != nil || c.Query != query || c.Offset < 0 {
+			return nil, invalidArgs("cursor does not continue this listing")
+		}
+		l.offset = c.Offset
+	}
+	return l, nil
+}
+
+// fetchLimit is the number of items to fetch from the offset: one more
+// than the page holds, to tell whether there are more. Zero is unlimited.
+func (l *listing) fetchLimit() int {
+	if l.Limit <= 0 {
+		return 0
+	}
+	return l.Limit + 1
+}
+
+// result returns the page of items, which start at the offset. The page
+// ends after Limit items or before the output exceeds the budget, but has
+// at least one item so that the listing always advances.
+func (l *listing) result(items []*storage.ArtifactMetadata) *mcp.CallToolResult {
+	budget := l.MaxBytes
+	if tokens := l.MaxTokens * 4; tokens > 0 && (budget <= 0 || tokens < budget) {
+		budget = tokens // about 4 bytes per token
+	}
+
+	list := ArtifactList{Artifacts: []ArtifactEntry{}}
+	var table strings.Builder
+	if l.Format == FormatTable {
+		table.WriteString(strings.Join(l.Fields, "\t") + "\n")
+	}
+	size, n := table.Len(), 0
+	for _, meta := range items {
+		if l.Limit > 0 && n == l.Limit {
+			break
+		}
+		entry := l.entry(meta)
+		var line []byte
+		if l.Format == FormatTable {
+			line = []byte(l.row(entry) + "\n")
+		} else {
+			line, _ = json.MarshalIndent(entry, "    ", "  ")
+		}
+		if budget > 0 && n > 0 && size+len(line) > budget {
+			break
+		}
+		size += len(line)
+		n++
+		if l.Format == FormatTable {
+			table.Write(line)
+		} else {
+			list.Artifacts = append(list.Artifacts, entry)
+		}
+	}
+	if n < len(items) {
+		data, _ := json.Marshal(listingCursor{Query: l.query, Offset: l.offset + n})
+		list.NextCursor = base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	if l.Format == FormatJSON {
+		return structuredResult(list)
+	}
+	list.Table = table.String()
+	text := list.Table
+	if list.NextCursor != "" {
+		text += "next_cursor: " + list.NextCursor + "\n"
+	}
+	return mcp.NewToolResultStructured(list, text)
+}
+
+// entry returns the selected fields of meta.
+func (l *listing) entry(meta *storage.ArtifactMetadata) ArtifactEntry {
+	var e ArtifactEntry
+	for _, f := range l.Fields {
+		switch f {
+		case "id":
+			e.ID = meta.ID
+		case "virtual_path":
+			e.VirtualPath = meta.VirtualPath
+		case "filename":
+			e.Filename = meta.Filename
+		case "mime_type":
+			e.MimeType = meta.MimeType
+		case "description":
+			e.Description = meta.Description
+		case "source":
+			e.Source = meta.Source
+		case "user_id":
+			e.UserID = meta.UserID
+		case "created_at":
+			if !meta.CreatedAt.IsZero() {
+				e.CreatedAt = &meta.CreatedAt
+			}
+		case "expires_at":
+			if !meta.ExpiresAt.IsZero() {
+				e.ExpiresAt = &meta.ExpiresAt
+			}
+		case "metadata":
+			e.Metadata = meta.Metadata
+		}
+	}
+	return e
+}
+
+// row returns the selected fields of e as a table row.
+func (l *listing) row(e ArtifactEntry) string {
+	cells := make([]string, len(l.Fields))
+	for i, f := range l.Fields {
+		var v string
+		switch f {
+		case "id":
+			v = e.ID
+		case "virtual_path":
+			v = e.VirtualPath
+		case "filename":
+			v = e.Filename
+		case "mime_type":
+			v = e.MimeType
+		case "description":
+			v = e.Description
+		case "source":
+			v = e.Source
+		case "user_id":
+			v = e.UserID
+		case "created_at":
+			if e.CreatedAt != nil {
+				v = e.CreatedAt.UTC().Format(time.RFC3339)
+			}
+		case "expires_at":
+			if e.ExpiresAt != nil {
+				v = e.ExpiresAt.UTC().Format(time.RFC3339)
+			}
+		case "metadata":
+			if e.Metadata != nil {
+				data, _ := json.Marshal(e.Metadata)
+				v = string(data)
+			}
+		}
+		cells[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(v)
+	}
+	return strings.Join(cells, "\t")
+}
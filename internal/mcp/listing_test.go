@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListing(t *testing.T) {
+	c := startTools(t, ToolConfig{})
+	for i := range 5 {
+		res := call(t, c, "write_artifact", map[string]any{
+			"filename": fmt.Sprintf("f%d.md", i), "content": "x", "virtual_path": fmt.Sprintf("/docs/f%d.md", i),
+			"metadata": map[string]any{"note": strings.Repeat("long ", 20)},
+		})
+		require.False(t, res.IsError)
+	}
+
+	list := func(name string, args map[string]any) ArtifactList {
+		res := call(t, c, name, args)
+		require.False(t, res.IsError, res.Content)
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		var out ArtifactList
+		require.NoError(t, json.Unmarshal(data, &out))
+		return out
+	}
+
+	// Pages follow each other without gaps
+	var paths []string
+	args := map[string]any{"path": "/docs", "limit": 2, "fields": []string{"virtual_path"}}
+	for page := 0; ; page++ {
+		require.Less(t, page, 3)
+		out := list("vfs_ls", args)
+		for _, e := range out.Artifacts {
+			assert.Empty(t, e.ID)
+			assert.Nil(t, e.Metadata)
+			paths = append(paths, e.VirtualPath)
+		}
+		if out.NextCursor == "" {
+			break
+		}
+		args["cursor"] = out.NextCursor
+	}
+	assert.Equal(t, []string{"/docs/f0.md", "/docs/f1.md", "/docs/f2.md", "/docs/f3.md", "/docs/f4.md"}, paths)
+
+	// A cursor only continues its own listing
+	res := call(t, c, "vfs_find", map[string]any{"pattern": "/docs/*", "cursor": args["cursor"]})
+	assert.Equal(t, storage.ReasonInvalidArgument, reason(t, res))
+	res = call(t, c, "list_artifacts", map[string]any{"fields": []string{"size"}})
+	assert.Equal(t, storage.ReasonInvalidArgument, reason(t, res))
+
+	// The budget ends the page early, but never before the first artifact
+	out := list("vfs_find", map[string]any{"pattern": "/docs/*", "max_bytes": 400})
+	require.NotEmpty(t, out.NextCursor)
+	assert.Len(t, out.Artifacts, 1)
+	assert.NotEmpty(t, out.Artifacts[0].Metadata)
+	out = list("vfs_find", map[string]any{"pattern": "/docs/*", "max_tokens": 200, "cursor": out.NextCursor})
+	assert.Equal(t, "/docs/f1.md", out.Artifacts[0].VirtualPath)
+
+	// The table format has a header and one line per artifact
+	res = call(t, c, "list_artifacts", map[string]any{"format": FormatTable, "fields": []string{"virtual_path", "mime_type"}, "limit": 3})
+	require.False(t, res.IsError)
+	text := res.Content[0].(mcp.TextContent).Text
+	lines := strings.Split(text, "\n")
+	assert.Equal(t, "virtual_path\tmime_type", lines[0])
+	assert.Regexp(t, `^/docs/f\d\.md\ttext/markdown`, lines[1])
+	assert.Contains(t, lines[4], "next_cursor: ")
+	out = list("list_artifacts", map[string]any{"format": FormatTable})
+	assert.Empty(t, out.Artifacts)
+	assert.Equal(t, "id\tvirtual_path\tfilename\tmime_type", strings.Split(out.Table, "\n")[0])
+	assert.Len(t, strings.Split(strings.TrimSpace(out.Table), "\n"), 6)
+	assert.Empty(t, out.NextCursor)
+
+	// The paging arguments are part of the input schemas
+	for _, tool := range Tools() {
+		switch tool.Tool.Name {
+		case "list_artifacts", "vfs_ls", "vfs_find":
+			var schema struct{ Properties map[string]json.RawMessage }
+			require.NoError(t, json.Unmarshal(tool.Tool.RawInputSchema, &schema))
+			for _, p := range []string{"cursor", "limit", "format", "fields", "max_bytes", "max_tokens"} {
+				assert.Contains(t, schema.Properties, p, tool.Tool.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ReasonCancelled reports a tool call that the client cancelled.
+const ReasonCancelled = "CANCELLED"
+
+// ProgressInterval is the least time between two progress notifications of
+// a tool call. The final one is always sent.
+var ProgressInterval = 100 * time.Millisecond
+
+// Method names of the MCP notifications handled here.
+const (
+	methodProgress  = "notifications/progress"
+	methodCancelled = "notifications/cancelled"
+)
+
+// requestIDField is where the hook of AddSessionHooks leaves the JSON-RPC ID
+// of a tools/call request for trackCall, which only sees the request.
+const requestIDField = "mlcartifact/requestId"
+
+// callKey identifies a running tool call by session and JSON-RPC ID.
+type callKey struct {
+	session, id string
+}
+
+var (
+	callsMu sync.Mutex
+	calls   = make(map[callKey]context.CancelFunc)
+)
+
+// markRequestID is a before-call-tool hook that records the JSON-RPC ID in
+// the request. mcp-go passes the request on to the handler afterwards.
+func markRequestID(_ context.Context, id any, req *mcp.CallToolRequest) {
+	if req.Params.Meta == nil {
+		req.Params.Meta = &mcp.Meta{}
+	}
+	if req.Params.Meta.AdditionalFields == nil {
+		req.Params.Meta.AdditionalFields = make(map[string]any)
+	}
+	req.Params.Meta.AdditionalFields[requestIDField] = id
+}
+
+// newCallKey returns the key of a call with the JSON-RPC ID id in the
+// session of ctx. IDs are compared in their JSON form, so that the number 1
+// of a request matches the 1 of a notification.
+func newCallKey(ctx context.Context, id any) callKey {
+	var k callKey
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		k.session = cs.SessionID()
+	}
+	data, _ := json.Marshal(id)
+	k.id = string(data)
+	return k
+}
+
+// trackCall runs a tool call with a context that notifications/cancelled
+// cancels, and makes the store operations of the call report progress to
+// the client if the request carries a progress token.
+func trackCall(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		meta := req.Params.Meta
+		if meta != nil && meta.AdditionalFields[requestIDField] != nil {
+			k := newCallKey(ctx, meta.AdditionalFields[requestIDField])
+			callsMu.Lock()
+			calls[k] = cancel
+			callsMu.Unlock()
+			defer func() {
+				callsMu.Lock()
+				delete(calls, k)
+				callsMu.Unlock()
+			}()
+		}
+		if meta != nil && meta.ProgressToken != nil {
+			ctx = storage.WithProgress(ctx, notifyProgress(ctx, meta.ProgressToken))
+		}
+		return next(ctx, req)
+	}
+}
+
+// cancelCall handles notifications/cancelled by cancelling the context of
+// the call it names. Calls that already finished are ignored.
+func cancelCall(ctx context.Context, n mcp.JSONRPCNotification) {
+	id, ok := n.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	callsMu.Lock()
+	cancel := calls[newCallKey(ctx, id)]
+	callsMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// notifyProgress returns a storage.ProgressFunc that sends progress
+// notifications for token. Progress only ever increases, as MCP requires,
+// so updates of a later operation of the call that restart at zero are
+// dropped until they overtake the earlier ones.
+func notifyProgress(ctx context.Context, token mcp.ProgressToken) storage.ProgressFunc {
+	srv := server.ServerFromContext(ctx)
+	var last int64 = -1
+	var sent time.Time
+	return func(done, total int64) {
+		if srv == nil || done <= last || (done < total && time.Since(sent) < ProgressInterval) {
+			return
+		}
+		last, sent = done, time.Now()
+		_ = srv.SendNotificationToClient(ctx, methodProgress, map[string]any{
+			"progressToken": token,
+			"progress":      done,
+			"total":         total,
+		})
+	}
+}
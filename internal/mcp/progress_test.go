@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	interval := ProgressInterval
+	ProgressInterval = 0
+	defer func() { ProgressInterval = interval }()
+
+	st := storage.NewStore(t.TempDir())
+	SetStore(st)
+	_, err := st.Write(t.Context(), "big.txt", []byte(strings.Repeat("a", 3<<20+5)), storage.WriteOptions{VirtualPath: "/big.txt"})
+	require.NoError(t, err)
+
+	hooks := &server.Hooks{}
+	AddSessionHooks(hooks)
+	s := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+	Register(s, ToolConfig{})
+	started := make(chan struct{})
+	s.AddTool(mcp.NewTool("wait"), trackCall(func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return toolError(ctx.Err()), nil
+	}))
+	srv := httptest.NewServer(server.NewStreamableHTTPServer(s))
+	defer srv.Close()
+
+	c, err := client.NewStreamableHttpClient(srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var progress []float64
+	c.OnNotification(func(n mcp.JSONRPCNotification) {
+		if n.Method == methodProgress && n.Params.AdditionalFields["progressToken"] == "read-1" {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, n.Params.AdditionalFields["progress"].(float64))
+			assert.Equal(t, float64(3<<20+5), n.Params.AdditionalFields["total"])
+		}
+	})
+
+	// Reads report their progress in chunks, if asked to
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "read_artifact"
+	req.Params.Arguments = map[string]any{"id": "/big.txt"}
+	req.Params.Meta = &mcp.Meta{ProgressToken: "read-1"}
+	res, err := c.CallTool(t.Context(), req)
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	mu.Lock()
+	assert.Equal(t, []float64{1 << 20, 2 << 20, 3 << 20, 3<<20 + 5}, progress)
+	mu.Unlock()
+
+	// notifications/cancelled cancels the call it names
+	post := func(body string) *http.Response {
+		r, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(server.HeaderKeySessionID, c.GetSessionId())
+		resp, err := http.DefaultClient.Do(r)
+		require.NoError(t, err)
+		return resp
+	}
+	done := make(chan *http.Response)
+	go func() {
+		done <- post(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"wait","arguments":{}}}`)
+	}()
+	<-started
+	post(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user"}}`).Body.Close()
+
+	select {
+	case resp := <-done:
+		defer resp.Body.Close()
+		var rpc struct{ Result mcp.CallToolResult }
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		// The response may come as a server-sent event
+		if _, data, ok := strings.Cut(string(body), "data: "); ok {
+			body = []byte(data)
+		}
+		require.NoError(t, json.Unmarshal(body, &rpc))
+		assert.Equal(t, ReasonCancelled, reason(t, &rpc.Result))
+	case <-time.After(5 * time.Second):
+		t.Fatal("call was not cancelled")
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ResourceTemplate is the URI template of artifact resources, see
+// storage.URIScheme. The user is empty for the global scope and the path is
+// a virtual path without the leading slash, or an artifact ID.
+const ResourceTemplate = storage.URIScheme + "://{user}/{+path}"
+
+// ReadResource is an MCP resource handler that returns the content of the
+// artifact named by an artifact:// URI. Text is returned as text, everything
+// else as a base64 blob, both with the artifact's MIME type.
+func ReadResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := req.Params.URI
+	userID, path, err := storage.ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	p, err := sessionPrincipal(ctx)
+	if err == nil {
+		err = allowURI(p, uri)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ReasonPermissionDenied, err)
+	}
+
+	ref := path
+	if _, _, err := store.Stat(ctx, path, storage.StatOptions{UserID: userID}); errors.Is(err, storage.ErrNotFound) && strings.Count(path, "/") == 1 {
+		// artifact://{user}/{id} of an artifact without a virtual path
+		ref = path[1:]
+	}
+	content, meta, err := store.Read(ctx, ref, storage.ReadOptions{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", storage.Reason(err), err)
+	}
+
+	slog.InfoContext(ctx, "artifact read via MCP resource", "id", meta.ID, "uri", uri)
+	return []mcp.ResourceContents{resourceContents(uri, meta.MimeType, content)}, nil
+}
+
+// resourceContents returns content as text if it is text, otherwise as a
+// blob.
+func resourceContents(uri, mimeType string, content []byte) mcp.ResourceContents {
+	if isText(mimeType, content) {
+		return mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(content)}
+	}
+	return mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(content)}
+}
+
+// isText reports whether content of the given MIME type can be handed to a
+// model as text. Untyped content counts as text if it is valid UTF-8 without
+// NUL bytes, since source files are stored as application/octet-stream.
+func isText(mimeType string, content []byte) bool {
+	mt, _, _ := mime.ParseMediaType(mimeType)
+	switch {
+	case strings.HasPrefix(mt, "text/"),
+		strings.HasSuffix(mt, "+json"), strings.HasSuffix(mt, "+xml"),
+		mt == "application/json", mt == "application/xml", mt == "application/javascript",
+		mt == "application/x-typescript", mt == "application/yaml", mt == "application/x-yaml":
+		return utf8.Valid(content)
+	case mt == "" || mt == "application/octet-stream":
+		return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+	}
+	return false
+}
+
+// isImage reports whether content of the given MIME type can be returned as
+// MCP image content.
+func isImage(mimeType string) bool {
+	mt, _, _ := mime.ParseMediaType(mimeType)
+	switch mt {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// RegisterResources adds the artifact resource template to s, and lists every
+// artifact with a virtual path as a resource to the sessions whose principal
+// may access its user scope. The lists follow the changes to the store until
+// ctx is cancelled.
+//
+// Resources are only listed to sessions known to the hooks of
+// AddSessionHooks. Sessions of the streamable HTTP and SSE transports get a
+// list of their own. Transports without session resources, such as stdio,
+// share the server's list, which holds the artifacts of their principals.
+func RegisterResources(ctx context.Context, s *server.MCPServer) error {
+	s.AddResourceTemplate(mcp.NewResourceTemplate(ResourceTemplate, "artifact",
+		mcp.WithTemplateDescription("An artifact by user scope (empty for global) and virtual path or ID"),
+	), ReadResource)
+
+	r := &resources{
+		srv:       s,
+		artifacts: make(map[string]*storage.ArtifactMetadata),
+		shared:    make(map[string]string),
+	}
+
+	// Watch before listing, so no change falls between the two
+	filter := storage.WatchFilter{AnyUser: true}
+	sub, err := store.Watch(ctx, filter, "")
+	if err != nil {
+		return err
+	}
+	if err := r.sync(ctx); err != nil {
+		return err
+	}
+	listedResources.Store(r)
+	go r.follow(ctx, filter, sub)
+	return nil
+}
+
+// listedResources is the resource list of RegisterResources, which the
+// session hooks fill for every new session.
+var listedResources atomic.Pointer[resources]
+
+// resources mirrors the artifacts with virtual paths into the resource lists
+// of the MCP sessions that may access them.
+type resources struct {
+	srv *server.MCPServer
+
+	mu        sync.Mutex
+	artifacts map[string]*storage.ArtifactMetadata // URI -> artifact
+	shared    map[string]string                    // URI -> artifact ID in the server's list
+}
+
+// sync lists the virtual file system of every user scope and replaces the
+// resource lists of all sessions.
+func (r *resources) sync(ctx context.Context) error {
+	found := make(map[string]*storage.ArtifactMetadata)
+	var walk func(userID, dir string) error
+	walk = func(userID, dir string) error {
+		items, err := store.ListVFS(ctx, dir, storage.ListVFSOptions{UserID: userID})
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.MimeType == "directory" {
+				if err := walk(userID, item.VirtualPath); err != nil {
+					return err
+				}
+				continue
+			}
+			found[item.URI()] = item
+		}
+		return nil
+	}
+	for _, userID := range store.Scopes() {
+		if err := walk(userID, "/"); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.artifacts = found
+	for _, sess := range openSessions() {
+		if own, ok := sess.client.(server.SessionWithResources); ok {
+			own.SetSessionResources(r.visible(sess.principal))
+			if own.Initialized() {
+				_ = r.srv.SendNotificationToSpecificClient(own.SessionID(), mcp.MethodNotificationResourcesListChanged, nil)
+			}
+		}
+	}
+	r.syncShared()
+	return nil
+}
+
+// follow applies store events to the resource lists. If it falls behind, it
+// resumes after the last event, or lists everything again if those events
+// are gone.
+func (r *resources) follow(ctx context.Context, filter storage.WatchFilter, sub *storage.Subscription) {
+	cursor := ""
+	for {
+		for ev := range sub.C {
+			r.apply(ev)
+			cursor = ev.Cursor
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		var err error
+		if sub, err = store.Watch(ctx, filter, cursor); err != nil {
+			if sub, err = store.Watch(ctx, filter, ""); err != nil {
+				return
+			}
+			if err := r.sync(ctx); err != nil {
+				slog.Warn("Resyncing MCP resources failed", "error", err)
+			}
+		}
+	}
+}
+
+func (r *resources) apply(ev storage.Event) {
+	meta := ev.Artifact
+	if meta.VirtualPath == "" {
+		return
+	}
+	uri := meta.URI()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch ev.Type {
+	case storage.EventCreated:
+		r.artifacts[uri] = meta
+		shared := false
+		for _, sess := range openSessions() {
+			if allowURI(sess.principal, uri) != nil {
+				continue
+			}
+			if _, ok := sess.client.(server.SessionWithResources); ok {
+				_ = r.srv.AddSessionResource(sess.client.SessionID(), newResource(meta), ReadResource)
+			} else {
+				shared = true
+			}
+		}
+		if shared {
+			r.shared[uri] = meta.ID
+			r.srv.AddResource(newResource(meta), ReadResource)
+		}
+	case storage.EventDeleted, storage.EventExpired:
+		// A newer artifact may have taken over the path
+		if old := r.artifacts[uri]; old == nil || old.ID != meta.ID {
+			return
+		}
+		delete(r.artifacts, uri)
+		for _, sess := range openSessions() {
+			if _, ok := sess.client.(server.SessionWithResources); ok {
+				_ = r.srv.DeleteSessionResources(sess.client.SessionID(), uri)
+			}
+		}
+		if _, ok := r.shared[uri]; ok {
+			delete(r.shared, uri)
+			r.srv.DeleteResources(uri)
+		}
+	}
+}
+
+// openSession fills the resource list of a new session. The caller must not
+// hold sessionsMu.
+func (r *resources) openSession(sess *session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if own, ok := sess.client.(server.SessionWithResources); ok {
+		own.SetSessionResources(r.visible(sess.principal))
+		return
+	}
+	r.syncShared()
+}
+
+// closeSession removes the artifacts of a closed session from the server's
+// list if no other session shares them.
+func (r *resources) closeSession() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.syncShared()
+}
+
+// visible returns the resources of the artifacts p may access. The caller
+// must hold r.mu.
+func (r *resources) visible(p *auth.Principal) map[string]server.ServerResource {
+	list := make(map[string]server.ServerResource)
+	for uri, meta := range r.artifacts {
+		if allowURI(p, uri) == nil {
+			list[uri] = server.ServerResource{Resource: newResource(meta), Handler: ReadResource}
+		}
+	}
+	return list
+}
+
+// syncShared updates the server's list to the artifacts that the sessions
+// without a list of their own may access. The caller must hold r.mu.
+func (r *resources) syncShared() {
+	var principals []*auth.Principal
+	for _, sess := range openSessions() {
+		if _, ok := sess.client.(server.SessionWithResources); !ok {
+			principals = append(principals, sess.principal)
+		}
+	}
+
+	want := make(map[string]string)
+	for uri, meta := range r.artifacts {
+		for _, p := range principals {
+			if allowURI(p, uri) == nil {
+				want[uri] = meta.ID
+				break
+			}
+		}
+	}
+	var stale []string
+	for uri, id := range r.shared {
+		if want[uri] != id {
+			stale = append(stale, uri)
+		}
+	}
+	if len(stale) > 0 {
+		r.srv.DeleteResources(stale...)
+	}
+	var added []server.ServerResource
+	for uri, id := range want {
+		if r.shared[uri] != id {
+			added = append(added, server.ServerResource{Resource: newResource(r.artifacts[uri]), Handler: ReadResource})
+		}
+	}
+	if len(added) > 0 {
+		r.srv.AddResources(added...)
+	}
+	r.shared = want
+}
+
+// newResource describes an artifact as an MCP resource.
+func newResource(meta *storage.ArtifactMetadata) mcp.Resource {
+	return mcp.NewResource(meta.URI(), meta.VirtualPath,
+		mcp.WithResourceDescription(meta.Description),
+		mcp.WithMIMEType(meta.MimeType),
+	)
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ReasonPermissionDenied reports a request for a user scope the caller may
+// not access, or a request of a session that belongs to another principal.
+const ReasonPermissionDenied = "PERMISSION_DENIED"
+
+// TTLSession is the write_artifact TTL class of artifacts that are deleted
+// when the MCP session that wrote them ends.
+const TTLSession = "session"
+
+// ErrSessionPrincipal is returned for a request that was authenticated as a
+// different principal than the one that opened its MCP session.
+var ErrSessionPrincipal = errors.New("MCP session belongs to another principal")
+
+// session is what the server knows about an open MCP session.
+type session struct {
+	client    server.ClientSession
+	principal *auth.Principal
+	artifacts []sessionArtifact
+}
+
+// sessionArtifact is an artifact of the TTLSession class.
+type sessionArtifact struct {
+	id, userID string
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[string]*session) // session ID -> session
+)
+
+// AddSessionHooks adds to h the hooks that bind every MCP session to the
+// principal that opened it, taken from the auth context of the request, and
+// that delete the session's TTLSession artifacts when it ends. The
+// resources of RegisterResources are listed to the sessions by the scopes of
+// their principal, and tool calls can be cancelled with
+// notifications/cancelled.
+//
+// Hooks can only be set when the server is created, so pass h to
+// server.NewMCPServer with server.WithHooks. Without them, tools resolve
+// user_id against the principal of each request and write_artifact rejects
+// the TTLSession class.
+func AddSessionHooks(h *server.Hooks) {
+	h.AddOnRegisterSession(func(ctx context.Context, cs server.ClientSession) {
+		sess := &session{client: cs, principal: auth.FromContext(ctx)}
+		sessionsMu.Lock()
+		sessions[cs.SessionID()] = sess
+		sessionsMu.Unlock()
+		if r := listedResources.Load(); r != nil {
+			r.openSession(sess)
+		}
+	})
+	h.AddBeforeCallTool(markRequestID)
+	h.AddOnUnregisterSession(func(ctx context.Context, cs server.ClientSession) {
+		// The request that ended the session may be gone already
+		endSession(context.WithoutCancel(ctx), cs.SessionID())
+	})
+}
+
+// endSession forgets a session and deletes its TTLSession artifacts.
+func endSession(ctx context.Context, sessionID string) {
+	sessionsMu.Lock()
+	sess := sessions[sessionID]
+	delete(sessions, sessionID)
+	sessionsMu.Unlock()
+	if sess == nil {
+		return
+	}
+	if r := listedResources.Load(); r != nil {
+		r.closeSession()
+	}
+
+	for _, a := range sess.artifacts {
+		if _, err := store.Delete(ctx, a.id, storage.DeleteOptions{UserID: a.userID}); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			slog.WarnContext(ctx, "deleting session artifact failed", "id", a.id, "session", sessionID, "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "session artifact deleted", "id", a.id, "session", sessionID)
+	}
+}
+
+// openSessions returns the sessions known to the hooks.
+func openSessions() []*session {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	return slices.Collect(maps.Values(sessions))
+}
+
+// trackSessionArtifact records an artifact to delete when the session of
+// ctx ends. It reports false if ctx has no session known to the hooks.
+func trackSessionArtifact(ctx context.Context, meta *storage.ArtifactMetadata) bool {
+	cs := server.ClientSessionFromContext(ctx)
+	if cs == nil {
+		return false
+	}
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	sess := sessions[cs.SessionID()]
+	if sess == nil {
+		return false
+	}
+	sess.artifacts = append(sess.artifacts, sessionArtifact{id: meta.ID, userID: meta.UserID})
+	return true
+}
+
+// sessionPrincipal returns the principal of the MCP session of ctx, or that
+// of the request if the session is unknown. A request authenticated as
+// another principal than its session's fails with ErrSessionPrincipal.
+func sessionPrincipal(ctx context.Context) (*auth.Principal, error) {
+	p := auth.FromContext(ctx)
+	cs := server.ClientSessionFromContext(ctx)
+	if cs == nil {
+		return p, nil
+	}
+	return checkSessionPrincipal(cs.SessionID(), p)
+}
+
+// checkSessionPrincipal returns the principal bound to a session, p if the
+// session is unknown, and ErrSessionPrincipal if p is another principal.
+func checkSessionPrincipal(sessionID string, p *auth.Principal) (*auth.Principal, error) {
+	sessionsMu.Lock()
+	sess := sessions[sessionID]
+	sessionsMu.Unlock()
+	if sess == nil {
+		return p, nil
+	}
+	if *sess.principal != *p {
+		return nil, ErrSessionPrincipal
+	}
+	return sess.principal, nil
+}
+
+// allowURI checks that p may access the user scope of an artifact URI.
+// Unlike for tools, the scope of a URI is explicit: the empty host is the
+// global scope, not the principal's own.
+func allowURI(p *auth.Principal, uri string) error {
+	userID, _, err := storage.ParseURI(uri)
+	if err != nil {
+		return err
+	}
+	if scope, err := p.ResolveScope(userID); err != nil || scope != userID {
+		return fmt.Errorf("%w: %q", auth.ErrForbidden, userID)
+	}
+	return nil
+}
+
+// ScopeTools returns a tool middleware that binds tool calls to the user
+// scope of the caller: the "user_id" argument is resolved with
+// auth.Principal.ResolveScope against the principal of the MCP session, so
+// it selects the caller's own scope when empty, and calls for a scope the
+// principal may not access fail with ReasonPermissionDenied. The principal
+// is put into the context of the handler.
+func ScopeTools() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			p, err := sessionPrincipal(ctx)
+			if err != nil {
+				return ErrorResult(ReasonPermissionDenied, err.Error()), nil
+			}
+
+			args := req.GetArguments()
+			requested, _ := args["user_id"].(string)
+			scope, err := p.ResolveScope(requested)
+			if err != nil {
+				return ErrorResult(ReasonPermissionDenied, fmt.Sprintf("user_id %q: %v", requested, err)), nil
+			}
+			if scope != requested {
+				args = maps.Clone(args)
+				if args == nil {
+					args = make(map[string]any)
+				}
+				args["user_id"] = scope
+				req.Params.Arguments = args
+			}
+			return next(auth.NewContext(ctx, p), req)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bearer adds a bearer token to every request, including the DELETE that
+// ends a streamable HTTP session.
+type bearer string
+
+func (b bearer) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+string(b))
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func connect(t *testing.T, url, token string) *client.Client {
+	c, err := client.NewStreamableHttpClient(url, transport.WithHTTPBasicClient(&http.Client{Transport: bearer(token)}))
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	return c
+}
+
+func call(t *testing.T, c *client.Client, name string, args map[string]any) *mcp.CallToolResult {
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	res, err := c.CallTool(t.Context(), req)
+	require.NoError(t, err)
+	return res
+}
+
+func reason(t *testing.T, res *mcp.CallToolResult) string {
+	require.True(t, res.IsError)
+	data, err := json.Marshal(res.StructuredContent)
+	require.NoError(t, err)
+	var out struct{ Error ToolError }
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out.Error.Reason
+}
+
+func TestSessions(t *testing.T) {
+	st := storage.NewStore(t.TempDir())
+	SetStore(st)
+
+	hooks := &server.Hooks{}
+	AddSessionHooks(hooks)
+	s := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+	Register(s, ToolConfig{}, ScopeTools())
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "alice-token", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+		{Token: "bob-token", Principal: auth.Principal{Name: "bob", UserID: "bob"}},
+	})
+	srv := httptest.NewServer(a.Middleware(server.NewStreamableHTTPServer(s)))
+	defer srv.Close()
+
+	alice := connect(t, srv.URL, "alice-token")
+
+	// user_id defaults to the session's scope and cannot leave it
+	res := call(t, alice, "write_artifact", map[string]any{"filename": "plan.md", "content": "# Plan", "virtual_path": "/plan.md"})
+	require.False(t, res.IsError)
+	_, meta, err := st.Read(t.Context(), "/plan.md", storage.ReadOptions{UserID: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", meta.UserID)
+
+	res = call(t, alice, "read_artifact", map[string]any{"id": "/plan.md", "user_id": "bob"})
+	assert.Equal(t, ReasonPermissionDenied, reason(t, res))
+
+	// Session artifacts go away with the session
+	res = call(t, alice, "write_artifact", map[string]any{"filename": "scratch.txt", "content": "tmp", "ttl": TTLSession, "virtual_path": "/scratch.txt"})
+	require.False(t, res.IsError)
+	_, _, err = st.Read(t.Context(), "/scratch.txt", storage.ReadOptions{UserID: "alice"})
+	require.NoError(t, err)
+
+	// Bob cannot use Alice's session
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_artifacts","arguments":{}}}`)
+	req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(server.HeaderKeySessionID, alice.GetSessionId())
+	hijack, err := (&http.Client{Transport: bearer("bob-token")}).Do(req)
+	require.NoError(t, err)
+	defer hijack.Body.Close()
+	var rpc struct{ Result mcp.CallToolResult }
+	require.NoError(t, json.NewDecoder(hijack.Body).Decode(&rpc))
+	assert.True(t, rpc.Result.IsError)
+	assert.Contains(t, rpc.Result.Content[0].(mcp.TextContent).Text, ErrSessionPrincipal.Error())
+
+	require.NoError(t, alice.Close())
+	assert.Eventually(t, func() bool {
+		_, _, err := st.Read(t.Context(), "/scratch.txt", storage.ReadOptions{UserID: "alice"})
+		return err != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	_, _, err = st.Read(t.Context(), "/plan.md", storage.ReadOptions{UserID: "alice"})
+	assert.NoError(t, err, "only session artifacts are deleted")
+}
+
+func TestSessions_NoHooks(t *testing.T) {
+	SetStore(storage.NewStore(t.TempDir()))
+	s := server.NewMCPServer("test", "1.0.0")
+	Register(s, ToolConfig{}, ScopeTools())
+	c, err := client.NewInProcessClient(s)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	// Nothing would delete a session artifact
+	res := call(t, c, "write_artifact", map[string]any{"filename": "scratch.txt", "content": "tmp", "ttl": TTLSession})
+	assert.Equal(t, ReasonUnavailable, reason(t, res))
+	data, _, err := store.Read(t.Context(), "scratch.txt", storage.ReadOptions{})
+	assert.Nil(t, data)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+// listResources pages through the resource list of c.
+func listResources(t *testing.T, c *client.Client) []string {
+	var uris []string
+	req := mcp.ListResourcesRequest{}
+	for {
+		res, err := c.ListResources(t.Context(), req)
+		require.NoError(t, err)
+		if len(res.Resources) == 0 {
+			require.Empty(t, res.NextCursor, "empty page before the end")
+		}
+		for _, r := range res.Resources {
+			uris = append(uris, r.URI)
+		}
+		if res.NextCursor == "" {
+			return uris
+		}
+		req.Params.Cursor = res.NextCursor
+	}
+}
+
+func TestSessions_Resources(t *testing.T) {
+	st := storage.NewStore(t.TempDir())
+	SetStore(st)
+	for _, a := range []struct{ userID, path string }{
+		{"alice", "/a.md"}, {"alice", "/docs/b.md"}, {"bob", "/a.md"}, {"bob", "/c.md"}, {"", "/global.md"},
+	} {
+		_, err := st.Write(t.Context(), "x.md", []byte("x"), storage.WriteOptions{UserID: a.userID, VirtualPath: a.path})
+		require.NoError(t, err)
+	}
+
+	hooks := &server.Hooks{}
+	AddSessionHooks(hooks)
+	s := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks), server.WithPaginationLimit(1))
+	require.NoError(t, RegisterResources(t.Context(), s))
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "alice-token", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+		{Token: "bob-token", Principal: auth.Principal{Name: "bob", UserID: "bob"}},
+	})
+	srv := httptest.NewServer(a.Middleware(server.NewStreamableHTTPServer(s)))
+	defer srv.Close()
+
+	// Every session lists only its own scope, on full pages
+	alice := connect(t, srv.URL, "alice-token")
+	defer alice.Close()
+	bob := connect(t, srv.URL, "bob-token")
+	defer bob.Close()
+	assert.Equal(t, []string{"artifact://alice/a.md", "artifact://alice/docs/b.md"}, listResources(t, alice))
+	assert.Equal(t, []string{"artifact://bob/a.md", "artifact://bob/c.md"}, listResources(t, bob))
+
+	// The lists follow the store
+	_, err := st.Write(t.Context(), "x.md", []byte("x"), storage.WriteOptions{UserID: "alice", VirtualPath: "/new.md"})
+	require.NoError(t, err)
+	_, err = st.Delete(t.Context(), "/c.md", storage.DeleteOptions{UserID: "bob"})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(listResources(t, alice)) == 3 && len(listResources(t, bob)) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"artifact://bob/a.md"}, listResources(t, bob))
+}
+
+// sampler lets an in-process client register a session.
+type sampler struct{}
+
+func (sampler) CreateMessage(context.Context, mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestSessions_SharedResources(t *testing.T) {
+	st := storage.NewStore(t.TempDir())
+	SetStore(st)
+	_, err := st.Write(t.Context(), "x.md", []byte("x"), storage.WriteOptions{UserID: "alice", VirtualPath: "/a.md"})
+	require.NoError(t, err)
+
+	hooks := &server.Hooks{}
+	AddSessionHooks(hooks)
+	s := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+	require.NoError(t, RegisterResources(t.Context(), s))
+
+	// Without a session, nothing is listed
+	c, err := client.NewInProcessClient(s)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, listResources(t, c))
+
+	// In-process sessions share the server's list, like stdio
+	shared, err := client.NewInProcessClientWithSamplingHandler(s, sampler{})
+	require.NoError(t, err)
+	require.NoError(t, shared.Start(t.Context()))
+	_, err = shared.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"artifact://alice/a.md"}, listResources(t, shared))
+
+	require.NoError(t, shared.Close())
+	assert.Empty(t, listResources(t, c))
+}
@@ -0,0 +1,331 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// MCP methods handled by Subscriptions.
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+)
+
+// StdioSessionID is the session ID of the single client of the stdio
+// transport.
+const StdioSessionID = "stdio"
+
+// Subscriptions implements resources/subscribe and resources/unsubscribe and
+// sends the notifications of the subscribed artifact resources:
+//
+//   - notifications/resources/updated when the artifact at a subscribed URI is
+//     patched, rewritten, moved away, deleted or expires.
+//   - notifications/resources/list_changed when an artifact below a
+//     subscribed directory URI, one ending in "/", is created, moved or
+//     deleted.
+//
+// The mcp-go server answers both methods with "method not found", so
+// Subscriptions takes them out of the message stream before the server sees
+// them: Handler wraps the HTTP transports and Stdin the stdio transport. A
+// subscription is recorded and the request passed on as a ping, whose empty
+// result is the result of a subscription. A request for an invalid URI is
+// passed on as a resources/read of that URI, which fails with the reason;
+// so is a subscription to a user scope the session's principal may not
+// access.
+type Subscriptions struct {
+	srv *server.MCPServer
+
+	mu       sync.Mutex
+	sessions map[string]map[string]string // session ID -> canonical URI -> URI as subscribed
+}
+
+// NewSubscriptions enables resource subscriptions on srv. Notifications are
+// sent until ctx is cancelled.
+func NewSubscriptions(ctx context.Context, srv *server.MCPServer) (*Subscriptions, error) {
+	server.WithResourceCapabilities(true, true)(srv)
+	s := &Subscriptions{srv: srv, sessions: make(map[string]map[string]string)}
+
+	filter := storage.WatchFilter{AnyUser: true}
+	sub, err := store.Watch(ctx, filter, "")
+	if err != nil {
+		return nil, err
+	}
+	go s.follow(ctx, filter, sub)
+	return s, nil
+}
+
+// Subscribe subscribes a session to an artifact URI, or to a virtual
+// directory if uri ends in "/". The artifact does not need to exist yet.
+func (s *Subscriptions) Subscribe(sessionID, uri string) error {
+	key, err := subscriptionKey(uri)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions[sessionID] == nil {
+		s.sessions[sessionID] = make(map[string]string)
+	}
+	s.sessions[sessionID][key] = uri
+	return nil
+}
+
+// Unsubscribe ends a subscription of a session.
+func (s *Subscriptions) Unsubscribe(sessionID, uri string) {
+	key, err := subscriptionKey(uri)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions[sessionID], key)
+	if len(s.sessions[sessionID]) == 0 {
+		delete(s.sessions, sessionID)
+	}
+}
+
+// subscriptionKey returns uri in the form storage.URI produces, so that
+// differently escaped URIs of the same artifact match. Directory keys keep
+// their trailing slash.
+func subscriptionKey(uri string) (string, error) {
+	if !strings.HasSuffix(uri, "/") {
+		userID, path, err := storage.ParseURI(uri)
+		if err != nil {
+			return "", err
+		}
+		return storage.URI(userID, path), nil
+	}
+	userID, path, err := storage.ParseURI(uri + ".")
+	if err != nil {
+		return "", err
+	}
+	if path == "/" {
+		return storage.URI(userID, "/"), nil
+	}
+	return storage.URI(userID, path) + "/", nil
+}
+
+// follow turns store events into notifications until ctx is cancelled. If
+// it falls behind, it resumes after the last event; events that are gone
+// are lost.
+func (s *Subscriptions) follow(ctx context.Context, filter storage.WatchFilter, sub *storage.Subscription) {
+	cursor := ""
+	for {
+		for ev := range sub.C {
+			s.notify(ev)
+			cursor = ev.Cursor
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		var err error
+		if sub, err = store.Watch(ctx, filter, cursor); err != nil {
+			slog.Warn("MCP resource notifications lost, resuming with new events", "error", err)
+			if sub, err = store.Watch(ctx, filter, ""); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// notify sends the notifications of one event to the subscribed sessions.
+func (s *Subscriptions) notify(ev storage.Event) {
+	meta := ev.Artifact
+	uri := meta.URI()
+	keys := []string{uri}
+	if meta.VirtualPath != "" {
+		keys = append(keys, storage.URI(meta.UserID, "/"+meta.ID))
+	}
+	listChanged := ev.Type != storage.EventPatched && meta.VirtualPath != ""
+
+	type notification struct {
+		sessionID, method string
+		params            map[string]any
+	}
+	var pending []notification
+
+	s.mu.Lock()
+	for sessionID, uris := range s.sessions {
+		for _, key := range keys {
+			if subscribed, ok := uris[key]; ok {
+				pending = append(pending, notification{sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": subscribed}})
+			}
+		}
+		if !listChanged {
+			continue
+		}
+		for key := range uris {
+			if strings.HasSuffix(key, "/") && strings.HasPrefix(uri, key) {
+				pending = append(pending, notification{sessionID, mcp.MethodNotificationResourcesListChanged, nil})
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, n := range pending {
+		err := s.srv.SendNotificationToSpecificClient(n.sessionID, n.method, n.params)
+		if errors.Is(err, server.ErrSessionNotFound) {
+			s.drop(n.sessionID)
+		}
+	}
+}
+
+// drop removes all subscriptions of a session.
+func (s *Subscriptions) drop(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// MaxMessageSize is the size limit of the MCP messages that Handler reads,
+// on top of the base64 content of an artifact of the store's maximum size.
+// Larger requests fail with 413 Request Entity Too Large.
+const MaxMessageSize = 32 << 20
+
+// maxMessageSize returns the size limit of an MCP message.
+func maxMessageSize() int64 {
+	return MaxMessageSize + (store.MaxArtifactSize()+2)/3*4
+}
+
+// Handler wraps an MCP HTTP transport, streamable HTTP or SSE, so that its
+// clients can subscribe to resources. Subscriptions of a streamable HTTP
+// session end when the client deletes the session. Messages larger than
+// MaxMessageSize are rejected.
+func (s *Subscriptions) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.Header.Get(server.HeaderKeySessionID)
+		if sessionID == "" {
+			sessionID = r.URL.Query().Get("sessionId") // SSE transport
+		}
+		switch {
+		case sessionID == "":
+		case r.Method == http.MethodDelete:
+			s.drop(sessionID)
+		case r.Method == http.MethodPost && r.Body != nil:
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize()))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, fmt.Sprintf("request exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, "reading request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = s.intercept(sessionID, auth.FromContext(r.Context()), body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Stdin wraps the input of the stdio transport so that its client can
+// subscribe to resources.
+func (s *Subscriptions) Stdin(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				if _, werr := pw.Write(s.intercept(StdioSessionID, auth.Anonymous, line)); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// rpcRequest is the part of a JSON-RPC request that intercept looks at.
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params struct {
+		URI string `json:"uri"`
+	} `json:"params"`
+}
+
+// intercept handles the subscribe and unsubscribe requests in a JSON-RPC
+// message or batch and rewrites them as described on Subscriptions. Other
+// messages are returned unchanged.
+func (s *Subscriptions) intercept(sessionID string, p *auth.Principal, msg []byte) []byte {
+	if !bytes.Contains(msg, []byte(methodSubscribe)) && !bytes.Contains(msg, []byte(methodUnsubscribe)) {
+		return msg
+	}
+
+	// Keep the line ending the stdio transport splits messages on
+	trimmed := bytes.TrimSpace(msg)
+	newline := msg[len(bytes.TrimRight(msg, "\r\n")):]
+
+	var out []byte
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return msg
+		}
+		for i, m := range batch {
+			batch[i] = s.interceptOne(sessionID, p, m)
+		}
+		out, _ = json.Marshal(batch)
+	} else {
+		out = s.interceptOne(sessionID, p, trimmed)
+	}
+	return append(out, newline...)
+}
+
+func (s *Subscriptions) interceptOne(sessionID string, p *auth.Principal, msg json.RawMessage) json.RawMessage {
+	var req rpcRequest
+	if err := json.Unmarshal(msg, &req); err != nil || req.ID == nil {
+		return msg
+	}
+
+	switch req.Method {
+	case methodSubscribe:
+		if p, err := checkSessionPrincipal(sessionID, p); err != nil || allowURI(p, req.Params.URI) != nil {
+			return rewrite(req.ID, mcp.MethodResourcesRead, map[string]string{"uri": req.Params.URI})
+		}
+		if err := s.Subscribe(sessionID, req.Params.URI); err != nil {
+			return rewrite(req.ID, mcp.MethodResourcesRead, map[string]string{"uri": req.Params.URI})
+		}
+	case methodUnsubscribe:
+		s.Unsubscribe(sessionID, req.Params.URI)
+	default:
+		return msg
+	}
+	return rewrite(req.ID, mcp.MethodPing, nil)
+}
+
+// rewrite returns a JSON-RPC request with the given ID.
+func rewrite(id json.RawMessage, method mcp.MCPMethod, params any) json.RawMessage {
+	out, _ := json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  mcp.MCPMethod   `json:"method"`
+		Params  any             `json:"params,omitempty"`
+	}{mcp.JSONRPC_VERSION, id, method, params})
+	return out
+}
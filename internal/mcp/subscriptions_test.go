@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptions(t *testing.T) {
+	st := storage.NewStore(t.TempDir())
+	SetStore(st)
+
+	s := server.NewMCPServer("test", "1.0.0")
+	subs, err := NewSubscriptions(t.Context(), s)
+	require.NoError(t, err)
+	srv := httptest.NewServer(subs.Handler(server.NewStreamableHTTPServer(s)))
+	defer srv.Close()
+
+	c, err := client.NewStreamableHttpClient(srv.URL, transport.WithContinuousListening())
+	require.NoError(t, err)
+	defer c.Close()
+	notifications := make(chan mcp.JSONRPCNotification, 16)
+	c.OnNotification(func(n mcp.JSONRPCNotification) { notifications <- n })
+	require.NoError(t, c.Start(t.Context()))
+	res, err := c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	require.NotNil(t, res.Capabilities.Resources)
+	assert.True(t, res.Capabilities.Resources.Subscribe)
+
+	next := func() mcp.JSONRPCNotification {
+		select {
+		case n := <-notifications:
+			return n
+		case <-time.After(2 * time.Second):
+			t.Fatal("no notification")
+			return mcp.JSONRPCNotification{}
+		}
+	}
+
+	// The plan does not exist yet
+	require.NoError(t, c.Subscribe(t.Context(), mcp.SubscribeRequest{Params: mcp.SubscribeParams{URI: "artifact:///plan.md"}}))
+	require.NoError(t, c.Subscribe(t.Context(), mcp.SubscribeRequest{Params: mcp.SubscribeParams{URI: "artifact:///docs/"}}))
+	require.Error(t, c.Subscribe(t.Context(), mcp.SubscribeRequest{Params: mcp.SubscribeParams{URI: "file:///etc/passwd"}}))
+
+	_, err = st.Write(t.Context(), "plan.md", []byte("1. write"), storage.WriteOptions{VirtualPath: "/plan.md"})
+	require.NoError(t, err)
+	n := next()
+	assert.Equal(t, mcp.MethodNotificationResourceUpdated, n.Method)
+	assert.Equal(t, "artifact:///plan.md", n.Params.AdditionalFields["uri"])
+
+	_, err = st.Patch(t.Context(), "/plan.md", []byte("2. test"), storage.PatchOptions{Append: true})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.MethodNotificationResourceUpdated, next().Method)
+
+	// Changes below a subscribed directory change the list
+	_, err = st.Write(t.Context(), "a.md", []byte("a"), storage.WriteOptions{VirtualPath: "/docs/a.md"})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.MethodNotificationResourcesListChanged, next().Method)
+
+	require.NoError(t, c.Unsubscribe(t.Context(), mcp.UnsubscribeRequest{Params: mcp.UnsubscribeParams{URI: "artifact:///plan.md"}}))
+	_, err = st.Delete(t.Context(), "/plan.md", storage.DeleteOptions{})
+	require.NoError(t, err)
+	_, err = st.Delete(t.Context(), "/docs/a.md", storage.DeleteOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, mcp.MethodNotificationResourcesListChanged, next().Method, "no update after unsubscribing")
+}
+
+func TestSubscriptions_Stdin(t *testing.T) {
+	subs := &Subscriptions{srv: server.NewMCPServer("test", "1.0.0"), sessions: make(map[string]map[string]string)}
+	in := `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"artifact://u1/docs/my%20plan.md"}}
+{"jsonrpc":"2.0","id":2,"method":"tools/list"}
+[{"jsonrpc":"2.0","id":3,"method":"resources/unsubscribe","params":{"uri":"artifact://u1/docs/my plan.md"}}]
+`
+	out := bufio.NewReader(subs.Stdin(strings.NewReader(in)))
+
+	line, err := out.ReadString('\n')
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, line)
+	assert.Equal(t, map[string]string{"artifact://u1/docs/my%20plan.md": "artifact://u1/docs/my%20plan.md"}, subs.sessions[StdioSessionID])
+
+	line, err = out.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`+"\n", line)
+
+	line, err = out.ReadString('\n')
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"jsonrpc":"2.0","id":3,"method":"ping"}]`, line)
+	assert.Empty(t, subs.sessions)
+
+	_, err = out.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestSubscriptions_MaxMessageSize(t *testing.T) {
+	st := storage.NewStore(t.TempDir())
+	st.SetMaxArtifactSize(3)
+	SetStore(st)
+	subs := &Subscriptions{srv: server.NewMCPServer("test", "1.0.0"), sessions: make(map[string]map[string]string)}
+	h := subs.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	post := func(size int) int {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(bytes.Repeat([]byte(" "), size)))
+		req.Header.Set(server.HeaderKeySessionID, "s1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusAccepted, post(MaxMessageSize+4))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(MaxMessageSize+5))
+}
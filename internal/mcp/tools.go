@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"encoding/json"
+	"maps"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// VFSUsagePrompt is the name of the prompt served by HandleVFSUsagePrompt.
+const VFSUsagePrompt = "vfs_usage"
+
+// Tools returns the artifact tools, ready to be added to an MCP server. The
+// input schemas are generated from the *Args types and the output schemas
+// from the *Result types. The handlers use the store set with SetStore.
+func Tools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.NewTool("write_artifact",
+				mcp.WithDescription("Save a file to the artifact store and return its ID and a reference tag."),
+				annotations("Write artifact", false, true, false),
+				mcp.WithInputSchema[WriteArtifactArgs](),
+				withOutputSchema[WriteArtifactResult](),
+			),
+			Handler: WriteArtifact,
+		},
+		{
+			Tool: mcp.NewTool("read_artifact",
+				mcp.WithDescription("Read an artifact's content by ID, filename or virtual path. Text is returned as text, images as images and other binary files as embedded blobs."),
+				annotations("Read artifact", true, false, true),
+				mcp.WithInputSchema[ReadArtifactArgs](),
+				withOutputSchema[ReadArtifactResult](),
+			),
+			Handler: ReadArtifact,
+		},
+		{
+			Tool: mcp.NewTool("list_artifacts",
+				mcp.WithDescription("List stored artifacts."),
+				annotations("List artifacts", true, false, true),
+				mcp.WithInputSchema[ListArtifactsArgs](),
+				withOutputSchema[ArtifactList](),
+			),
+			Handler: ListArtifacts,
+		},
+		{
+			Tool: mcp.NewTool("delete_artifact",
+				mcp.WithDescription("Delete an artifact permanently."),
+				annotations("Delete artifact", false, true, true),
+				mcp.WithInputSchema[DeleteArtifactArgs](),
+				withOutputSchema[DeleteArtifactResult](),
+			),
+			Handler: DeleteArtifact,
+		},
+		{
+			Tool: mcp.NewTool("vfs_patch",
+				mcp.WithDescription("Replace lines of an artifact or append to it."),
+				annotations("Patch artifact", false, true, false),
+				mcp.WithInputSchema[VFSPatchArgs](),
+				withOutputSchema[VFSPatchResult](),
+			),
+			Handler: VFSPatch,
+		},
+		{
+			Tool: mcp.NewTool("vfs_ls",
+				mcp.WithDescription("List the files and directories of a virtual directory."),
+				annotations("List directory", true, false, true),
+				mcp.WithInputSchema[VFSListArgs](),
+				withOutputSchema[ArtifactList](),
+			),
+			Handler: VFSList,
+		},
+		{
+			Tool: mcp.NewTool("vfs_find",
+				mcp.WithDescription("Find artifacts whose virtual path matches a glob pattern."),
+				annotations("Find artifacts", true, false, true),
+				mcp.WithInputSchema[VFSFindArgs](),
+				withOutputSchema[ArtifactList](),
+			),
+			Handler: VFSFind,
+		},
+		{
+			Tool: mcp.NewTool("create_share_link",
+				mcp.WithDescription("Create a signed, expiring HTTP download link for a human."),
+				annotations("Create share link", true, false, false),
+				mcp.WithInputSchema[CreateShareLinkArgs](),
+				withOutputSchema[CreateShareLinkResult](),
+			),
+			Handler: CreateShareLink,
+		},
+	}
+}
+
+// annotations describes a tool to hosts. None of the tools reach beyond the
+// artifact store, so none is open-world. Writes are destructive because
+// they can take over the virtual path of an existing artifact.
+func annotations(title string, readOnly, destructive, idempotent bool) mcp.ToolOption {
+	return mcp.WithToolAnnotation(mcp.ToolAnnotation{
+		Title:           title,
+		ReadOnlyHint:    &readOnly,
+		DestructiveHint: &destructive,
+		IdempotentHint:  &idempotent,
+		OpenWorldHint:   mcp.ToBoolPtr(false),
+	})
+}
+
+// withOutputSchema declares T as the structured content of successful
+// results. Error results carry a ToolError under "error" instead, see
+// ErrorResult, so the schema requires either the fields T requires or
+// "error".
+func withOutputSchema[T any]() mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		var result, failure mcp.Tool
+		mcp.WithOutputSchema[T]()(&result)
+		mcp.WithOutputSchema[struct {
+			Error ToolError `json:"error"`
+		}]()(&failure)
+
+		properties := maps.Clone(result.OutputSchema.Properties)
+		properties["error"] = failure.OutputSchema.Properties["error"]
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(result.OutputSchema.Required) > 0 {
+			schema["anyOf"] = []any{
+				map[string]any{"required": result.OutputSchema.Required},
+				map[string]any{"required": []string{"error"}},
+			}
+		}
+		t.RawOutputSchema, _ = json.Marshal(schema)
+	}
+}
+
+// Register adds the artifact tools selected by cfg and the VFS usage prompt
+// to s. The tool handlers are wrapped in mw, the first one outermost, for
+// servers that were created without these middlewares. Tool calls with a
+// progress token report the progress of long store operations, and calls
+// can be cancelled if s was created with the hooks of AddSessionHooks.
+func Register(s *server.MCPServer, cfg ToolConfig, mw ...server.ToolHandlerMiddleware) {
+	tools := cfg.filter(Tools())
+	for i := range tools {
+		for j := len(mw) - 1; j >= 0; j-- {
+			tools[i].Handler = mw[j](tools[i].Handler)
+		}
+		tools[i].Handler = trackCall(tools[i].Handler)
+	}
+	s.AddTools(tools...)
+	s.AddNotificationHandler(methodCancelled, cancelCall)
+	s.AddPrompt(mcp.NewPrompt(VFSUsagePrompt,
+		mcp.WithPromptDescription("Guidelines for using the mlcartifact VFS capabilities."),
+		mcp.WithArgument("directory", mcp.ArgumentDescription("Virtual directory to keep the artifacts of the task in")),
+	), HandleVFSUsagePrompt)
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTools_Schemas(t *testing.T) {
+	for _, tool := range Tools() {
+		var in, out struct {
+			Type       string                     `json:"type"`
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		}
+		require.NoError(t, json.Unmarshal(tool.Tool.RawInputSchema, &in), tool.Tool.Name)
+		require.NoError(t, json.Unmarshal(tool.Tool.RawOutputSchema, &out), tool.Tool.Name)
+		assert.Equal(t, "object", in.Type, tool.Tool.Name)
+		assert.Equal(t, "object", out.Type, tool.Tool.Name)
+		assert.Contains(t, in.Properties, "user_id", tool.Tool.Name)
+		assert.Contains(t, out.Properties, "error", "%s: error results must fit the schema", tool.Tool.Name)
+	}
+
+	write := Tools()[0].Tool
+	var schema struct {
+		Properties map[string]struct {
+			Type        string   `json:"type"`
+			Description string   `json:"description"`
+			Enum        []string `json:"enum"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(write.RawInputSchema, &schema))
+	assert.ElementsMatch(t, []string{"filename", "content"}, schema.Required)
+	assert.Equal(t, "integer", schema.Properties["expires_in_hours"].Type)
+	assert.Equal(t, []string{EncodingUTF8, EncodingBase64}, schema.Properties["encoding"].Enum)
+	assert.NotEmpty(t, schema.Properties["virtual_path"].Description)
+}
+
+func TestTools_StructuredContent(t *testing.T) {
+	SetStore(storage.NewStore(t.TempDir()))
+	s := server.NewMCPServer("test", "1.0.0")
+	Register(s, ToolConfig{})
+	c, err := client.NewInProcessClient(s)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+
+	decode := func(res *mcp.CallToolResult, v any) {
+		require.False(t, res.IsError)
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, v))
+	}
+
+	var written WriteArtifactResult
+	decode(call(t, c, "write_artifact", map[string]any{"filename": "plan.md", "content": "# Plan", "virtual_path": "/docs/plan.md"}), &written)
+	assert.NotEmpty(t, written.ID)
+	assert.Equal(t, int64(6), written.Size)
+	assert.Equal(t, "artifact:///docs/plan.md", written.URI)
+
+	var patched VFSPatchResult
+	decode(call(t, c, "vfs_patch", map[string]any{"id": "/docs/plan.md", "content": "\n1. write", "append": true}), &patched)
+	assert.Equal(t, int64(15), patched.NewSize)
+
+	var listed ArtifactList
+	decode(call(t, c, "vfs_ls", map[string]any{"path": "/docs"}), &listed)
+	require.Len(t, listed.Artifacts, 1)
+	assert.Equal(t, written.ID, listed.Artifacts[0].ID)
+
+	decode(call(t, c, "vfs_find", map[string]any{"pattern": "/none/*"}), &listed)
+	assert.NotNil(t, listed.Artifacts)
+	assert.Empty(t, listed.Artifacts)
+
+	// Arguments of the wrong type are rejected
+	res := call(t, c, "read_artifact", map[string]any{"id": 42})
+	assert.Equal(t, storage.ReasonInvalidArgument, reason(t, res))
+	res = call(t, c, "vfs_patch", map[string]any{"content": "x", "append": true})
+	assert.Equal(t, storage.ReasonInvalidArgument, reason(t, res))
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package mcp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ReasonConfirmationRequired reports a destructive call that has to be
+// repeated with the confirmation token of the error, see
+// ToolConfig.ConfirmDestructive.
+const ReasonConfirmationRequired = "CONFIRMATION_REQUIRED"
+
+// ConfirmationTTL is how long a confirmation token stays valid.
+const ConfirmationTTL = 5 * time.Minute
+
+// ToolMode selects the tools by what they may do to the store.
+type ToolMode string
+
+const (
+	// ModeFull registers every tool.
+	ModeFull ToolMode = "full"
+	// ModeSafe registers the tools that neither delete nor modify
+	// artifacts: no delete_artifact and vfs_patch, and write_artifact
+	// refuses virtual paths that are taken.
+	ModeSafe ToolMode = "safe"
+	// ModeReadOnly registers the tools annotated as read-only.
+	ModeReadOnly ToolMode = "read-only"
+)
+
+// ToolConfig selects the tools Register adds and how destructive calls are
+// guarded. The zero value registers all tools without confirmation.
+type ToolConfig struct {
+	Mode     ToolMode `json:"mode,omitempty"`     // ModeFull if empty
+	Enabled  []string `json:"enabled,omitempty"`  // Only these tools of the mode; all if empty
+	Disabled []string `json:"disabled,omitempty"` // Never these tools
+
+	// ConfirmDestructive makes calls that delete or modify an artifact,
+	// or take over the virtual path of one, fail with
+	// ReasonConfirmationRequired. The error carries a confirmation token
+	// that confirms exactly this call, with the same arguments, within
+	// ConfirmationTTL, so that the host can ask its user first.
+	ConfirmDestructive bool `json:"confirm_destructive,omitempty"`
+}
+
+// Validate reports an unknown mode or tool name.
+func (c ToolConfig) Validate() error {
+	switch c.Mode {
+	case "", ModeFull, ModeSafe, ModeReadOnly:
+	default:
+		return fmt.Errorf("unknown MCP tool mode %q, use %q, %q or %q", c.Mode, ModeFull, ModeSafe, ModeReadOnly)
+	}
+	names := make([]string, 0, len(Tools()))
+	for _, t := range Tools() {
+		names = append(names, t.Tool.Name)
+	}
+	for _, name := range slices.Concat(c.Enabled, c.Disabled) {
+		if !slices.Contains(names, name) {
+			return fmt.Errorf("unknown MCP tool %q", name)
+		}
+	}
+	return nil
+}
+
+// filter returns the tools of c, with the guards of the mode and of
+// ConfirmDestructive around their handlers.
+func (c ToolConfig) filter(tools []server.ServerTool) []server.ServerTool {
+	var confirm *confirmations
+	if c.ConfirmDestructive {
+		confirm = newConfirmations()
+	}
+
+	var out []server.ServerTool
+	for _, t := range tools {
+		name := t.Tool.Name
+		readOnly := t.Tool.Annotations.ReadOnlyHint != nil && *t.Tool.Annotations.ReadOnlyHint
+		switch {
+		case slices.Contains(c.Disabled, name),
+			len(c.Enabled) > 0 && !slices.Contains(c.Enabled, name),
+			c.Mode == ModeReadOnly && !readOnly,
+			c.Mode == ModeSafe && (name == "delete_artifact" || name == "vfs_patch"):
+			continue
+		}
+		if c.Mode == ModeSafe && name == "write_artifact" {
+			t.Handler = noOverwrite(t.Handler)
+		}
+		if destroys := destructive[name]; confirm != nil && destroys != nil {
+			t.Handler = confirm.guard(name, destroys, t.Handler)
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// destructive tells for each tool that can destroy data whether a call
+// would. A write only does if it takes over a virtual path.
+var destructive = map[string]func(ctx context.Context, args map[string]any) bool{
+	"delete_artifact": func(context.Context, map[string]any) bool { return true },
+	"vfs_patch":       func(context.Context, map[string]any) bool { return true },
+	"write_artifact":  pathTaken,
+}
+
+// pathTaken reports whether the virtual_path argument of a write names an
+// existing artifact.
+func pathTaken(ctx context.Context, args map[string]any) bool {
+	path, _ := args["virtual_path"].(string)
+	userID, _ := args["user_id"].(string)
+	if path == "" {
+		return false
+	}
+	_, _, err := store.Stat(ctx, path, storage.StatOptions{UserID: userID})
+	return err == nil
+}
+
+// noOverwrite makes write_artifact fail with ALREADY_EXISTS instead of
+// taking over the virtual path of an existing artifact.
+func noOverwrite(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if pathTaken(ctx, req.GetArguments()) {
+			path, _ := req.GetArguments()["virtual_path"].(string)
+			userID, _ := req.GetArguments()["user_id"].(string)
+			return toolError(&storage.Error{Op: "write", Path: path, UserID: userID, Err: storage.ErrAlreadyExists}), nil
+		}
+		return next(ctx, req)
+	}
+}
+
+// confirmations issues and checks single-use confirmation tokens. A token
+// is the expiry time and an HMAC of the expiry, the caller, the tool and
+// its arguments under a key that lives as long as the process.
+type confirmations struct {
+	key []byte
+
+	mu   sync.Mutex
+	used map[string]time.Time // token -> expiry
+}
+
+func newConfirmations() *confirmations {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return &confirmations{key: key, used: make(map[string]time.Time)}
+}
+
+// guard wraps the handler of a destructive tool so that calls that would
+// destroy data need a confirmation token.
+func (c *confirmations) guard(name string, destroys func(context.Context, map[string]any) bool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := maps.Clone(req.GetArguments())
+		token, _ := args["confirmation_token"].(string)
+		delete(args, "confirmation_token")
+		if !destroys(ctx, args) {
+			return next(ctx, req)
+		}
+
+		err := c.redeem(token, caller(ctx), name, args)
+		if err == nil {
+			return next(ctx, req)
+		}
+		expires := time.Now().Add(ConfirmationTTL)
+		return toolErrorResult(ToolError{
+			Reason:            ReasonConfirmationRequired,
+			Message:           fmt.Sprintf("%v: %s would delete or replace data; repeat the call with confirmation_token once the user agreed", err, name),
+			ConfirmationToken: c.issue(expires, caller(ctx), name, args),
+		}), nil
+	}
+}
+
+// Errors of redeem.
+var (
+	errNoConfirmation      = errors.New("confirmation required")
+	errInvalidConfirmation = errors.New("confirmation token invalid, expired or used")
+)
+
+// issue returns a token for one call of a tool with args.
+func (c *confirmations) issue(expires time.Time, caller, name string, args map[string]any) string {
+	buf := binary.BigEndian.AppendUint64(nil, uint64(expires.Unix()))
+	buf = append(buf, c.mac(buf, caller, name, args)...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// redeem checks a token for the call and marks it used.
+func (c *confirmations) redeem(token, caller, name string, args map[string]any) error {
+	if token == "" {
+		return errNoConfirmation
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) <= 8 {
+		return errInvalidConfirmation
+	}
+	expires := time.Unix(int64(binary.BigEndian.Uint64(buf[:8])), 0)
+	if time.Now().After(expires) || !hmac.Equal(buf[8:], c.mac(buf[:8], caller, name, args)) {
+		return errInvalidConfirmation
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for t, exp := range c.used {
+		if now.After(exp) {
+			delete(c.used, t)
+		}
+	}
+	if _, ok := c.used[token]; ok {
+		return errInvalidConfirmation
+	}
+	c.used[token] = expires
+	return nil
+}
+
+func (c *confirmations) mac(expiry []byte, caller, name string, args map[string]any) []byte {
+	// json.Marshal sorts map keys, so equal arguments give equal bytes
+	encoded, _ := json.Marshal(args)
+	h := hmac.New(sha256.New, c.key)
+	for _, part := range [][]byte{expiry, []byte(caller), []byte(name), encoded} {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(part))))
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// caller identifies who may redeem a token: the MCP session, or the
+// principal for transports without sessions.
+func caller(ctx context.Context) string {
+	if cs := server.ClientSessionFromContext(ctx); cs != nil {
+		return "session:" + cs.SessionID()
+	}
+	return "principal:" + auth.FromContext(ctx).Name
+}
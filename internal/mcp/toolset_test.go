@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTools(t *testing.T, cfg ToolConfig) *client.Client {
+	SetStore(storage.NewStore(t.TempDir()))
+	s := server.NewMCPServer("test", "1.0.0")
+	Register(s, cfg)
+	c, err := client.NewInProcessClient(s)
+	require.NoError(t, err)
+	require.NoError(t, c.Start(t.Context()))
+	_, err = c.Initialize(t.Context(), mcp.InitializeRequest{})
+	require.NoError(t, err)
+	return c
+}
+
+func toolNames(t *testing.T, c *client.Client) []string {
+	res, err := c.ListTools(t.Context(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, tool := range res.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestToolConfig_Validate(t *testing.T) {
+	assert.NoError(t, ToolConfig{}.Validate())
+	assert.NoError(t, ToolConfig{Mode: ModeSafe, Disabled: []string{"create_share_link"}}.Validate())
+	assert.ErrorContains(t, ToolConfig{Mode: "careful"}.Validate(), "careful")
+	assert.ErrorContains(t, ToolConfig{Enabled: []string{"rm_rf"}}.Validate(), "rm_rf")
+}
+
+func TestToolConfig_Modes(t *testing.T) {
+	assert.ElementsMatch(t, []string{"read_artifact", "list_artifacts", "vfs_ls", "vfs_find", "create_share_link"},
+		toolNames(t, startTools(t, ToolConfig{Mode: ModeReadOnly})))
+	assert.ElementsMatch(t, []string{"read_artifact", "vfs_ls"},
+		toolNames(t, startTools(t, ToolConfig{Mode: ModeReadOnly, Enabled: []string{"read_artifact", "vfs_ls", "write_artifact"}})))
+	assert.NotContains(t, toolNames(t, startTools(t, ToolConfig{Disabled: []string{"vfs_patch"}})), "vfs_patch")
+
+	c := startTools(t, ToolConfig{Mode: ModeSafe})
+	assert.ElementsMatch(t, []string{"write_artifact", "read_artifact", "list_artifacts", "vfs_ls", "vfs_find", "create_share_link"}, toolNames(t, c))
+
+	// Safe mode writes new paths but does not take over existing ones
+	args := map[string]any{"filename": "plan.md", "content": "# Plan", "virtual_path": "/plan.md"}
+	require.False(t, call(t, c, "write_artifact", args).IsError)
+	assert.Equal(t, storage.ReasonAlreadyExists, reason(t, call(t, c, "write_artifact", args)))
+	require.False(t, call(t, c, "write_artifact", map[string]any{"filename": "plan.md", "content": "# Plan"}).IsError)
+}
+
+func TestToolConfig_ConfirmDestructive(t *testing.T) {
+	c := startTools(t, ToolConfig{ConfirmDestructive: true})
+
+	confirmation := func(res *mcp.CallToolResult) string {
+		data, err := json.Marshal(res.StructuredContent)
+		require.NoError(t, err)
+		var out struct{ Error ToolError }
+		require.NoError(t, json.Unmarshal(data, &out))
+		require.Equal(t, ReasonConfirmationRequired, out.Error.Reason)
+		require.NotEmpty(t, out.Error.ConfirmationToken)
+		return out.Error.ConfirmationToken
+	}
+
+	// Writing a new path needs no confirmation, replacing it does
+	args := map[string]any{"filename": "plan.md", "content": "# Plan", "virtual_path": "/plan.md"}
+	require.False(t, call(t, c, "write_artifact", args).IsError)
+	token := confirmation(call(t, c, "write_artifact", args))
+	args["confirmation_token"] = token
+	require.False(t, call(t, c, "write_artifact", args).IsError)
+
+	token = confirmation(call(t, c, "delete_artifact", map[string]any{"id": "/plan.md"}))
+
+	// The token confirms only the call it was issued for
+	confirmation(call(t, c, "delete_artifact", map[string]any{"id": "/other.md", "confirmation_token": token}))
+	confirmation(call(t, c, "vfs_patch", map[string]any{"id": "/plan.md", "content": "x", "append": true, "confirmation_token": token}))
+
+	require.False(t, call(t, c, "delete_artifact", map[string]any{"id": "/plan.md", "confirmation_token": token}).IsError)
+
+	// and only once
+	res := call(t, c, "delete_artifact", map[string]any{"id": "/plan.md", "confirmation_token": token})
+	confirmation(res)
+	assert.Contains(t, res.Content[0].(mcp.TextContent).Text, errInvalidConfirmation.Error())
+}
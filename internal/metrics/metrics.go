@@ -0,0 +1,315 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package metrics exports Prometheus metrics for the artifact server.
+//
+// RPCs are measured by a Connect interceptor and MCP tool calls by a tool
+// handler middleware, both labelled by method (or tool) and result code.
+// Storage gauges (artifacts and bytes per user scope and MIME class, VFS index
+// size) are reported when the endpoint is scraped. MIME classes are a fixed
+// set, and WithCollapsedScopes merges the user scopes, so that clients cannot
+// create series at will. The usage walk behind them
+// is cached and only repeated, at most every UsageRefresh, after the store
+// reported a write, delete or expiry. Cleanup runs are observed through a
+// storage hook.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is the URL path under which the Handler is usually mounted.
+const Path = "/metrics"
+
+const namespace = "mlcartifact"
+
+const (
+	// UsageRefresh is the minimum age of the cached storage usage before a
+	// change to the store makes a scrape walk the store again.
+	UsageRefresh = 15 * time.Second
+	// UsageTimeout bounds a walk of the store for its usage.
+	UsageTimeout = 10 * time.Second
+)
+
+// Metrics holds the collectors of one server.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	rpcRequests  *prometheus.CounterVec
+	rpcDuration  *prometheus.HistogramVec
+	toolCalls    *prometheus.CounterVec
+	toolDuration *prometheus.HistogramVec
+
+	cleanupDuration prometheus.Histogram
+	reclaimed       prometheus.Counter
+	reclaimedBytes  prometheus.Counter
+
+	usage          *storeCollector
+	collapseScopes bool
+}
+
+// Option is a functional option for configuring the Metrics.
+type Option func(*Metrics)
+
+// WithCollapsedScopes reports the storage gauges of all user scopes under
+// the scope "user", next to "global", so that the number of series does not
+// grow with the number of users.
+func WithCollapsedScopes() Option {
+	return func(m *Metrics) {
+		m.collapseScopes = true
+	}
+}
+
+// New creates the metrics for a server backed by store and registers the
+// storage collector and cleanup hook. store may be nil to export only RPC and
+// tool metrics.
+func New(store *storage.Store, opts ...Option) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rpc_requests_total",
+			Help:      "Number of RPCs handled, by method and Connect code.",
+		}, []string{"method", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rpc_duration_seconds",
+			Help:      "RPC latency, by method and Connect code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mcp_tool_calls_total",
+			Help:      "Number of MCP tool calls, by tool and result code.",
+		}, []string{"tool", "code"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mcp_tool_duration_seconds",
+			Help:      "MCP tool call latency, by tool and result code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tool", "code"}),
+		cleanupDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "cleanup_duration_seconds",
+			Help:      "Duration of expiry cleanup runs.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+		}),
+		reclaimed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cleanup_reclaimed_artifacts_total",
+			Help:      "Number of expired artifacts removed by cleanup.",
+		}),
+		reclaimedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cleanup_reclaimed_bytes_total",
+			Help:      "Content bytes reclaimed by cleanup.",
+		}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.rpcRequests, m.rpcDuration,
+		m.toolCalls, m.toolDuration,
+		m.cleanupDuration, m.reclaimed, m.reclaimedBytes,
+	)
+
+	if store != nil {
+		m.usage = &storeCollector{store: store, collapseScopes: m.collapseScopes}
+		m.usage.changed.Store(true)
+		m.registry.MustRegister(m.usage)
+		store.OnOperation(func(_ context.Context, op storage.Operation) {
+			switch op.Name {
+			case "Write", "Replace", "Patch", "Delete":
+				if op.Err == nil {
+					m.usage.changed.Store(true)
+				}
+			}
+		})
+		store.OnCleanup(func(st storage.CleanupStats) {
+			m.cleanupDuration.Observe(st.Duration.Seconds())
+			m.reclaimed.Add(float64(st.Removed))
+			m.reclaimedBytes.Add(float64(st.Bytes))
+			if st.Removed > 0 {
+				m.usage.changed.Store(true)
+			}
+		})
+	}
+	return m
+}
+
+// Registry returns the registry, e.g. to add application collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns the HTTP handler serving the metrics in the Prometheus
+// exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Interceptor returns a Connect interceptor that counts and times every RPC.
+func (m *Metrics) Interceptor() connect.Interceptor {
+	return &interceptor{m: m}
+}
+
+type interceptor struct {
+	m *Metrics
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		start := time.Now()
+		res, err := next(ctx, req)
+		i.m.observeRPC(req.Spec().Procedure, err, time.Since(start))
+		return res, err
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		err := next(ctx, conn)
+		i.m.observeRPC(conn.Spec().Procedure, err, time.Since(start))
+		return err
+	}
+}
+
+func (m *Metrics) observeRPC(procedure string, err error, d time.Duration) {
+	method := procedure[strings.LastIndex(procedure, "/")+1:]
+	code := "ok"
+	if err != nil {
+		code = connect.CodeOf(err).String()
+	}
+	m.rpcRequests.WithLabelValues(method, code).Inc()
+	m.rpcDuration.WithLabelValues(method, code).Observe(d.Seconds())
+}
+
+// ToolMiddleware returns an MCP tool handler middleware that counts and times
+// every tool call. Results flagged with isError are reported with code
+// "tool_error", failed handlers with "error".
+func (m *Metrics) ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			res, err := next(ctx, req)
+
+			code := "ok"
+			switch {
+			case err != nil:
+				code = "error"
+			case res != nil && res.IsError:
+				code = "tool_error"
+			}
+			tool := req.Params.Name
+			m.toolCalls.WithLabelValues(tool, code).Inc()
+			m.toolDuration.WithLabelValues(tool, code).Observe(time.Since(start).Seconds())
+			return res, err
+		}
+	}
+}
+
+// storeCollector reports storage gauges at scrape time.
+type storeCollector struct {
+	store          *storage.Store
+	collapseScopes bool
+	changed        atomic.Bool // The store changed since the last walk
+
+	mu      sync.Mutex
+	stats   []storage.UsageStat
+	updated time.Time // Time of the last walk
+}
+
+var (
+	artifactsDesc = prometheus.NewDesc(namespace+"_artifacts",
+		"Number of stored artifacts, by user scope and MIME class.", []string{"scope", "mime_class"}, nil)
+	bytesDesc = prometheus.NewDesc(namespace+"_artifact_bytes",
+		"Stored content bytes, by user scope and MIME class.", []string{"scope", "mime_class"}, nil)
+	indexDesc = prometheus.NewDesc(namespace+"_vfs_index_entries",
+		"Number of virtual paths in the VFS index.", nil, nil)
+)
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- artifactsDesc
+	ch <- bytesDesc
+	ch <- indexDesc
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(indexDesc, prometheus.GaugeValue, float64(c.store.IndexSize()))
+
+	usage, err := c.load()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(artifactsDesc, err)
+		return
+	}
+	type key struct{ scope, class string }
+	totals := make(map[key]storage.UsageStat)
+	for _, u := range usage {
+		scope := u.UserID
+		switch {
+		case scope == "":
+			scope = "global"
+		case c.collapseScopes:
+			scope = "user"
+		}
+		k := key{scope, u.MimeClass}
+		t := totals[k]
+		t.Artifacts += u.Artifacts
+		t.Bytes += u.Bytes
+		totals[k] = t
+	}
+	for k, t := range totals {
+		ch <- prometheus.MustNewConstMetric(artifactsDesc, prometheus.GaugeValue, float64(t.Artifacts), k.scope, k.class)
+		ch <- prometheus.MustNewConstMetric(bytesDesc, prometheus.GaugeValue, float64(t.Bytes), k.scope, k.class)
+	}
+}
+
+// load returns the cached usage, walking the store again if it changed and
+// the cache is older than UsageRefresh. If the walk fails, the last usage is
+// kept.
+func (c *storeCollector) load() ([]storage.UsageStat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats != nil && (!c.changed.Load() || time.Since(c.updated) < UsageRefresh) {
+		return c.stats, nil
+	}
+
+	c.changed.Store(false)
+	ctx, cancel := context.WithTimeout(context.Background(), UsageTimeout)
+	defer cancel()
+	stats, err := c.store.Usage(ctx)
+	if err != nil {
+		c.changed.Store(true)
+		if c.stats != nil {
+			return c.stats, nil
+		}
+		return nil, err
+	}
+	c.stats, c.updated = stats, time.Now()
+	return stats, nil
+}
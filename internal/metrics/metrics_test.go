@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"connectrpc.com/connect"
+	artifactgrpc "github.com/hmsoft0815/mlcartifact/internal/grpc"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	pb "github.com/hmsoft0815/mlcartifact/proto"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+	res, err := http.Get(srv.URL + Path)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics_RPCAndStorage(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-metrics-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := storage.NewStore(tempDir)
+	m := New(store)
+
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewArtifactServiceHandler(artifactgrpc.NewConnectServer(store),
+		connect.WithInterceptors(m.Interceptor())))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := protoconnect.NewArtifactServiceClient(srv.Client(), srv.URL)
+	_, err = client.Write(ctx, connect.NewRequest(&pb.WriteRequest{Filename: "a.md", Content: []byte("hello"), MimeType: "text/markdown", UserId: "u1"}))
+	require.NoError(t, err)
+	_, err = client.Read(ctx, connect.NewRequest(&pb.ReadRequest{Id: "missing", UserId: "u1"}))
+	require.Error(t, err)
+	store.Cleanup(t.Context())
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `mlcartifact_rpc_requests_total{code="ok",method="Write"} 1`)
+	assert.Contains(t, out, `mlcartifact_rpc_requests_total{code="`+connect.CodeOf(err).String()+`",method="Read"} 1`)
+	assert.Contains(t, out, `mlcartifact_rpc_duration_seconds_count{code="ok",method="Write"} 1`)
+	assert.Contains(t, out, `mlcartifact_artifacts{mime_class="text",scope="u1"} 1`)
+	assert.Contains(t, out, `mlcartifact_artifact_bytes{mime_class="text",scope="u1"} 5`)
+	assert.Contains(t, out, `mlcartifact_vfs_index_entries 0`)
+	assert.Contains(t, out, `mlcartifact_cleanup_duration_seconds_count 1`)
+	assert.Contains(t, out, "go_goroutines")
+}
+
+func TestMetrics_UsageCache(t *testing.T) {
+	store := storage.NewStore(t.TempDir())
+	m := New(store)
+
+	_, err := store.Write(t.Context(), "a.md", []byte("hello"), storage.WriteOptions{MimeType: "text/markdown"})
+	require.NoError(t, err)
+	assert.Contains(t, scrape(t, m), `mlcartifact_artifacts{mime_class="text",scope="global"} 1`)
+
+	// Within UsageRefresh the cached usage is reported
+	meta, err := store.Write(t.Context(), "b.md", []byte("hello"), storage.WriteOptions{MimeType: "text/markdown"})
+	require.NoError(t, err)
+	assert.Contains(t, scrape(t, m), `mlcartifact_artifacts{mime_class="text",scope="global"} 1`)
+
+	m.usage.updated = m.usage.updated.Add(-UsageRefresh)
+	assert.Contains(t, scrape(t, m), `mlcartifact_artifacts{mime_class="text",scope="global"} 2`)
+
+	// Reads do not invalidate the cache, deletes do
+	_, _, err = store.Read(t.Context(), meta.ID, storage.ReadOptions{})
+	require.NoError(t, err)
+	m.usage.updated = m.usage.updated.Add(-UsageRefresh)
+	assert.False(t, m.usage.changed.Load())
+	_, err = store.Delete(t.Context(), meta.ID, storage.DeleteOptions{})
+	require.NoError(t, err)
+	assert.True(t, m.usage.changed.Load())
+	assert.Contains(t, scrape(t, m), `mlcartifact_artifacts{mime_class="text",scope="global"} 1`)
+}
+
+func TestMetrics_CollapsedScopes(t *testing.T) {
+	store := storage.NewStore(t.TempDir())
+	m := New(store, WithCollapsedScopes())
+
+	for _, opts := range []storage.WriteOptions{
+		{MimeType: "text/plain", UserID: "u1"},
+		{MimeType: "text/plain", UserID: "u2"},
+		{MimeType: "x-custom/anything", UserID: "u3"},
+		{MimeType: "text/plain"},
+	} {
+		_, err := store.Write(t.Context(), "a.txt", []byte("hello"), opts)
+		require.NoError(t, err)
+	}
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `mlcartifact_artifacts{mime_class="text",scope="user"} 2`)
+	assert.Contains(t, out, `mlcartifact_artifact_bytes{mime_class="text",scope="user"} 10`)
+	assert.Contains(t, out, `mlcartifact_artifacts{mime_class="other",scope="user"} 1`)
+	assert.Contains(t, out, `mlcartifact_artifacts{mime_class="text",scope="global"} 1`)
+	assert.NotContains(t, out, `scope="u1"`)
+	assert.NotContains(t, out, "x-custom")
+}
+
+func TestMetrics_ToolMiddleware(t *testing.T) {
+	m := New(nil)
+	mw := m.ToolMiddleware()
+
+	ok := mw(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("done"), nil
+	})
+	toolErr := mw(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("not found"), nil
+	})
+	failed := mw(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("boom")
+	})
+
+	call := func(h func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), name string) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = name
+		_, _ = h(context.Background(), req)
+	}
+	call(ok, "read_artifact")
+	call(ok, "read_artifact")
+	call(toolErr, "read_artifact")
+	call(failed, "write_artifact")
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `mlcartifact_mcp_tool_calls_total{code="ok",tool="read_artifact"} 2`)
+	assert.Contains(t, out, `mlcartifact_mcp_tool_calls_total{code="tool_error",tool="read_artifact"} 1`)
+	assert.Contains(t, out, `mlcartifact_mcp_tool_calls_total{code="error",tool="write_artifact"} 1`)
+	assert.NotContains(t, out, "mlcartifact_artifacts{")
+}
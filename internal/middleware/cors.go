@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Headers browsers may send to and read from CORS-enabled handlers. They
+// cover the MCP HTTP transports, the Connect protocol and HTTP Basic or
+// bearer authentication.
+var (
+	corsAllowHeaders = strings.Join([]string{
+		"Authorization", "Content-Type", "Accept", "Last-Event-ID",
+		"Mcp-Session-Id", "Mcp-Protocol-Version", RequestIDHeader,
+		"Connect-Protocol-Version", "Connect-Timeout-Ms", "X-User-Agent", "X-Grpc-Web",
+	}, ", ")
+	corsExposeHeaders = strings.Join([]string{
+		"Mcp-Session-Id", RequestIDHeader, "WWW-Authenticate",
+		"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin",
+	}, ", ")
+)
+
+// CORS wraps next so that browser pages from the allowed origins can call
+// it. "*" allows every origin, but without credentials: only the origins
+// listed explicitly may send the browser's cookies or cached Basic
+// credentials. Preflight requests are answered without calling next, so put
+// CORS in front of authentication.
+//
+// Requests with an Origin header from any other origin are rejected with 403,
+// which protects local MCP servers against DNS rebinding. Requests without
+// an Origin header, i.e. not from a browser, pass unchanged.
+func CORS(origins []string, next http.Handler) http.Handler {
+	anyOrigin := slices.Contains(origins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		listed := slices.Contains(origins, origin)
+		if !anyOrigin && !listed {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if listed {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+		h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			h.Set("Access-Control-Max-Age", "7200")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
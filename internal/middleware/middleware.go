@@ -0,0 +1,385 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package middleware provides the standard request handling stack of the
+// artifact server: panic recovery, request IDs, deadlines and access logging.
+//
+// The stack exists in three forms that behave alike:
+//
+//   - Interceptors for the Connect handler, which also serves gRPC and
+//     gRPC-Web clients,
+//   - Handler for plain HTTP handlers such as the MCP HTTP transport,
+//   - ToolMiddleware for MCP tool calls.
+//
+// Every request carries a request ID, taken from the X-Request-Id header if
+// the caller sent a valid one and generated otherwise. It is echoed in the
+// response header and stored in the context; LogHandler adds it to every
+// record logged with that context, so log lines of one request can be
+// correlated.
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"connectrpc.com/connect"
+	artifactmcp "github.com/hmsoft0815/mlcartifact/internal/mcp"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RequestIDHeader is the header carrying the request ID.
+const RequestIDHeader = "X-Request-Id"
+
+// Default deadlines of unary calls and tool calls.
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxTimeout = 5 * time.Minute
+)
+
+// Deadline bounds the run time of a call. Default applies when the caller
+// sets no deadline; a caller's deadline later than Max is shortened to Max.
+// Zero values disable the respective bound.
+type Deadline struct {
+	Default time.Duration
+	Max     time.Duration
+}
+
+// Config configures the stack.
+type Config struct {
+	// Logger receives access log records; slog.Default() if nil.
+	Logger *slog.Logger
+
+	// Deadline applies to unary RPCs and tool calls without an entry in
+	// Deadlines. The zero value means DefaultTimeout and DefaultMaxTimeout.
+	Deadline Deadline
+
+	// Deadlines overrides Deadline per Connect procedure (e.g.
+	// "/artifact.v1.ArtifactService/Write") or MCP tool name. Streaming RPCs
+	// such as Watch only get a deadline from an entry here.
+	Deadlines map[string]Deadline
+}
+
+func (c Config) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
+// deadline returns the deadline of the named procedure or tool and whether
+// one is configured explicitly.
+func (c Config) deadline(name string) (Deadline, bool) {
+	if d, ok := c.Deadlines[name]; ok {
+		return d, true
+	}
+	if c.Deadline == (Deadline{}) {
+		return Deadline{Default: DefaultTimeout, Max: DefaultMaxTimeout}, false
+	}
+	return c.Deadline, false
+}
+
+// withDeadline applies d to ctx.
+func withDeadline(ctx context.Context, d Deadline) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if d.Max > 0 && time.Until(deadline) > d.Max {
+			return context.WithTimeout(ctx, d.Max)
+		}
+		return ctx, func() {}
+	}
+	if d.Default > 0 {
+		return context.WithTimeout(ctx, d.Default)
+	}
+	return ctx, func() {}
+}
+
+type requestIDKey struct{}
+
+// NewContext returns a copy of ctx carrying the request ID.
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type peerKey struct{}
+
+// Peer returns the network address of the client whose HTTP request, passed
+// through Handler, ctx belongs to, or "".
+func Peer(ctx context.Context) string {
+	addr, _ := ctx.Value(peerKey{}).(string)
+	return addr
+}
+
+// requestID returns the valid ID sent by the caller or a new one.
+func requestID(sent string) string {
+	if validRequestID(sent) {
+		return sent
+	}
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID accepts short IDs of URL-safe characters, so that callers
+// cannot inject arbitrary content into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// LogHandler wraps h so that records logged with a context carrying a
+// request ID get a "request_id" attribute. Install it with
+// slog.SetDefault(slog.New(middleware.LogHandler(h))).
+func LogHandler(h slog.Handler) slog.Handler {
+	return &logHandler{Handler: h}
+}
+
+type logHandler struct {
+	slog.Handler
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// errInternal is returned to callers instead of the panic value, which may
+// contain internals.
+var errInternal = errors.New("internal error")
+
+// logPanic logs a recovered panic with its stack.
+func logPanic(ctx context.Context, logger *slog.Logger, name string, p any) {
+	logger.ErrorContext(ctx, "Recovered from panic", "call", name, "panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+}
+
+// Interceptors returns the Connect interceptors of the stack, outermost
+// first. Pass them before other interceptors, so that the access log and the
+// recovery cover those as well.
+func Interceptors(cfg Config) []connect.Interceptor {
+	return []connect.Interceptor{&interceptor{cfg: cfg}}
+}
+
+type interceptor struct {
+	cfg Config
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (resp connect.AnyResponse, err error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		procedure := req.Spec().Procedure
+		id := requestID(req.Header().Get(RequestIDHeader))
+		ctx = NewContext(ctx, id)
+		start := time.Now()
+
+		defer func() {
+			if p := recover(); p != nil {
+				logPanic(ctx, i.cfg.logger(), procedure, p)
+				resp, err = nil, connect.NewError(connect.CodeInternal, errInternal)
+			}
+			var cerr *connect.Error
+			if errors.As(err, &cerr) {
+				cerr.Meta().Set(RequestIDHeader, id)
+			} else if resp != nil {
+				resp.Header().Set(RequestIDHeader, id)
+			}
+			i.log(ctx, procedure, req.Peer().Addr, err, time.Since(start))
+		}()
+
+		d, _ := i.cfg.deadline(procedure)
+		ctx, cancel := withDeadline(ctx, d)
+		defer cancel()
+		return next(ctx, req)
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) (err error) {
+		procedure := conn.Spec().Procedure
+		id := requestID(conn.RequestHeader().Get(RequestIDHeader))
+		ctx = NewContext(ctx, id)
+		conn.ResponseHeader().Set(RequestIDHeader, id)
+		start := time.Now()
+
+		defer func() {
+			if p := recover(); p != nil {
+				logPanic(ctx, i.cfg.logger(), procedure, p)
+				err = connect.NewError(connect.CodeInternal, errInternal)
+			}
+			i.log(ctx, procedure, conn.Peer().Addr, err, time.Since(start))
+		}()
+
+		if d, ok := i.cfg.deadline(procedure); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = withDeadline(ctx, d)
+			defer cancel()
+		}
+		return next(ctx, conn)
+	}
+}
+
+// log writes the access log record of an RPC. Server-side failures are
+// logged as errors, everything else at info level.
+func (i *interceptor) log(ctx context.Context, procedure, peer string, err error, d time.Duration) {
+	code := "ok"
+	level := slog.LevelInfo
+	if err != nil {
+		c := connect.CodeOf(err)
+		code = c.String()
+		if c == connect.CodeInternal || c == connect.CodeUnknown || c == connect.CodeDataLoss {
+			level = slog.LevelError
+		}
+	}
+	i.cfg.logger().LogAttrs(ctx, level, "RPC finished",
+		slog.String("procedure", procedure),
+		slog.String("code", code),
+		slog.Duration("duration", d),
+		slog.String("peer", peer))
+}
+
+// Handler wraps an HTTP handler, e.g. the MCP SSE or streamable HTTP
+// transport, with request IDs, panic recovery and access logging. It sets no
+// deadline, because such handlers serve long-lived streams; tool calls get
+// theirs from ToolMiddleware.
+func Handler(cfg Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestID(r.Header.Get(RequestIDHeader))
+		ctx := context.WithValue(NewContext(r.Context(), id), peerKey{}, r.RemoteAddr)
+		w.Header().Set(RequestIDHeader, id)
+		rec := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				logPanic(ctx, cfg.logger(), r.Method+" "+r.URL.Path, p)
+				if rec.status == 0 {
+					http.Error(rec, errInternal.Error(), http.StatusInternalServerError)
+				}
+			}
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if rec.status >= 500 {
+				level = slog.LevelError
+			}
+			cfg.logger().LogAttrs(ctx, level, "HTTP request finished",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int64("bytes", rec.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("peer", r.RemoteAddr))
+		}()
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+// statusRecorder records the status and size of a response. It keeps
+// streaming responses working through Flush and Unwrap.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// ToolMiddleware returns an MCP tool handler middleware with request IDs,
+// panic recovery, deadlines and access logging. A panicking tool returns an
+// INTERNAL error result instead of taking down the server.
+func ToolMiddleware(cfg Config) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (res *mcp.CallToolResult, err error) {
+			tool := req.Params.Name
+			if RequestID(ctx) == "" {
+				ctx = NewContext(ctx, requestID(""))
+			}
+			start := time.Now()
+
+			defer func() {
+				if p := recover(); p != nil {
+					logPanic(ctx, cfg.logger(), tool, p)
+					res, err = artifactmcp.ErrorResult(storage.ReasonInternal, errInternal.Error()), nil
+				}
+				level := slog.LevelInfo
+				if err != nil {
+					level = slog.LevelError
+				}
+				cfg.logger().LogAttrs(ctx, level, "MCP tool call finished",
+					slog.String("tool", tool),
+					slog.Bool("is_error", res != nil && res.IsError),
+					slog.Duration("duration", time.Since(start)))
+			}()
+
+			d, _ := cfg.deadline(tool)
+			ctx, cancel := withDeadline(ctx, d)
+			defer cancel()
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	artifactmcp "github.com/hmsoft0815/mlcartifact/internal/mcp"
+	pb "github.com/hmsoft0815/mlcartifact/proto"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// service panics on Read and reports the deadline and request ID it sees on
+// Write.
+type service struct {
+	protoconnect.UnimplementedArtifactServiceHandler
+	deadline  time.Duration
+	requestID string
+}
+
+func (s *service) Write(ctx context.Context, req *connect.Request[pb.WriteRequest]) (*connect.Response[pb.WriteResponse], error) {
+	if d, ok := ctx.Deadline(); ok {
+		s.deadline = time.Until(d)
+	}
+	s.requestID = RequestID(ctx)
+	slog.InfoContext(ctx, "writing")
+	return connect.NewResponse(&pb.WriteResponse{Id: "1"}), nil
+}
+
+func (s *service) Read(ctx context.Context, req *connect.Request[pb.ReadRequest]) (*connect.Response[pb.ReadResponse], error) {
+	var m map[string]int
+	m["boom"]++
+	return nil, nil
+}
+
+// captureLogs routes the default logger through LogHandler into a buffer.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(LogHandler(slog.NewTextHandler(&buf, nil))))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestInterceptors(t *testing.T) {
+	logs := captureLogs(t)
+	svc := &service{}
+	cfg := Config{Deadlines: map[string]Deadline{
+		protoconnect.ArtifactServiceWriteProcedure: {Default: time.Second, Max: 2 * time.Second},
+	}}
+
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewArtifactServiceHandler(svc, connect.WithInterceptors(Interceptors(cfg)...)))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	cli := protoconnect.NewArtifactServiceClient(srv.Client(), srv.URL)
+
+	// A panicking handler becomes an internal error
+	_, err := cli.Read(context.Background(), connect.NewRequest(&pb.ReadRequest{Id: "x"}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeInternal, connect.CodeOf(err))
+	var cerr *connect.Error
+	require.ErrorAs(t, err, &cerr)
+	assert.Equal(t, "internal error", cerr.Message())
+	assert.NotEmpty(t, cerr.Meta().Get(RequestIDHeader))
+	assert.Contains(t, logs.String(), "Recovered from panic")
+
+	// The caller's request ID is kept; no deadline gets the default
+	req := connect.NewRequest(&pb.WriteRequest{Filename: "a.txt"})
+	req.Header().Set(RequestIDHeader, "req-42")
+	res, err := cli.Write(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "req-42", res.Header().Get(RequestIDHeader))
+	assert.Equal(t, "req-42", svc.requestID)
+	assert.InDelta(t, time.Second, svc.deadline, float64(200*time.Millisecond))
+	assert.Contains(t, logs.String(), `msg=writing request_id=req-42`)
+	assert.Contains(t, logs.String(), "procedure=/artifact.v1.ArtifactService/Write code=ok")
+
+	// A longer caller deadline is capped, an invalid request ID replaced
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	req = connect.NewRequest(&pb.WriteRequest{Filename: "a.txt"})
+	req.Header().Set(RequestIDHeader, "bad id")
+	res, err = cli.Write(ctx, req)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, svc.deadline, 2*time.Second)
+	assert.Len(t, res.Header().Get(RequestIDHeader), 32)
+}
+
+func TestHandler(t *testing.T) {
+	logs := captureLogs(t)
+	h := Handler(Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		_, ok := w.(http.Flusher)
+		assert.True(t, ok, "streaming transports need a Flusher")
+		slog.InfoContext(r.Context(), "handled")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(RequestIDHeader))
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/message", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "abc", rec.Header().Get(RequestIDHeader))
+	assert.Contains(t, logs.String(), "msg=handled request_id=abc")
+	assert.Contains(t, logs.String(), "path=/message status=202")
+}
+
+func TestCORS(t *testing.T) {
+	h := CORS([]string{"https://app.example.com"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	serve := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/mcp", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodOptions, "https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, rec.Code, "preflight is answered without next")
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Mcp-Session-Id")
+
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec = serve(http.MethodPost, "https://app.example.com")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), "Mcp-Session-Id")
+
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "http://evil.example").Code)
+	rec = serve(http.MethodPost, "")
+	assert.Equal(t, http.StatusAccepted, rec.Code, "non-browser clients send no Origin")
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	h := CORS([]string{"*", "https://app.example.com"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	serve := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Any origin may call, but the browser sends no credentials
+	rec := serve("https://other.example")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Listed origins may send credentials
+	rec = serve("https://app.example.com")
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestToolMiddleware(t *testing.T) {
+	mw := ToolMiddleware(Config{Deadlines: map[string]Deadline{"slow": {Default: 10 * time.Millisecond}}})
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "write_artifact"
+	res, err := mw(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		panic("boom")
+	})(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, res.IsError)
+	assert.Equal(t, map[string]artifactmcp.ToolError{"error": {Reason: "INTERNAL", Message: "internal error"}}, res.StructuredContent)
+
+	req.Params.Name = "slow"
+	_, err = mw(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		assert.NotEmpty(t, RequestID(ctx))
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})(context.Background(), req)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package ratelimit throttles callers of the artifact service with token
+// buckets and limits the size of requests.
+//
+// Every combination of RPC (or MCP tool) and caller gets its own bucket, so
+// one agent looping on write_artifact cannot starve the others. The caller
+// is the authenticated principal, or the host of an anonymous client; the
+// user_id and source a client sends cannot buy it a fresh bucket.
+// Calls over the limit fail with ResourceExhausted (RPC) or a QUOTA_EXCEEDED
+// error result (MCP) that says when to retry.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	artifactgrpc "github.com/hmsoft0815/mlcartifact/internal/grpc"
+	artifactmcp "github.com/hmsoft0815/mlcartifact/internal/mcp"
+	"github.com/hmsoft0815/mlcartifact/internal/middleware"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterHeader carries the retry delay in whole seconds, like the HTTP
+// header of the same name.
+const RetryAfterHeader = "Retry-After"
+
+// Limit is a token bucket: Rate calls per second on average, with bursts of
+// up to Burst calls. A zero Rate means unlimited.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Config configures a Limiter.
+type Config struct {
+	// Default applies to every RPC and tool without an entry in Limits.
+	Default Limit `json:"default"`
+
+	// Limits overrides Default per Connect procedure (e.g.
+	// "/artifact.v1.ArtifactService/Write") or MCP tool name.
+	Limits map[string]Limit `json:"limits,omitempty"`
+
+	// MaxRequestBytes limits the size of a request message or of the
+	// arguments of a tool call. 0 means unlimited.
+	MaxRequestBytes int64 `json:"max_request_bytes,omitempty"`
+}
+
+// Limiter keeps the token buckets of all callers. It is safe for concurrent
+// use.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[key]*rate.Limiter
+}
+
+type key struct {
+	name, caller string
+}
+
+// maxBuckets bounds the memory of the limiter; beyond it, idle buckets are
+// dropped.
+const maxBuckets = 10000
+
+// New creates a Limiter.
+func New(cfg Config) *Limiter {
+	return &Limiter{cfg: cfg, now: time.Now, buckets: make(map[key]*rate.Limiter)}
+}
+
+// Allow takes a token from the bucket of the named RPC or tool for the
+// given caller, see Caller. If none is left, it returns false and the time
+// until the next token.
+func (l *Limiter) Allow(name, caller string) (bool, time.Duration) {
+	limit, ok := l.cfg.Limits[name]
+	if !ok {
+		limit = l.cfg.Default
+	}
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	k := key{name, caller}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[k]
+	if b == nil {
+		if len(l.buckets) >= maxBuckets {
+			l.evict(now)
+		}
+		b = rate.NewLimiter(rate.Limit(limit.Rate), max(limit.Burst, 1))
+		l.buckets[k] = b
+	}
+
+	r := b.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// evict drops buckets that have been idle long enough to be full again, and
+// all buckets if that frees nothing.
+func (l *Limiter) evict(now time.Time) {
+	for k, b := range l.buckets {
+		if b.TokensAt(now) >= float64(b.Burst()) {
+			delete(l.buckets, k)
+		}
+	}
+	if len(l.buckets) >= maxBuckets {
+		clear(l.buckets)
+	}
+}
+
+// Caller returns the caller that owns the buckets of a call: the principal
+// authenticated for ctx or, for anonymous calls, the host of peer, the
+// client's network address.
+func Caller(ctx context.Context, peer string) string {
+	if p := auth.FromContext(ctx); p != auth.Anonymous {
+		return "principal:" + p.Name
+	}
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	return "peer:" + peer
+}
+
+// Error returns the ResourceExhausted error of a rejected RPC. It carries an
+// ErrorInfo with reason QUOTA_EXCEEDED, a RetryInfo with the delay and the
+// delay in the Retry-After header.
+func Error(msg string, retryAfter time.Duration) error {
+	err := connect.NewError(connect.CodeResourceExhausted, errors.New(msg))
+	if d, derr := connect.NewErrorDetail(&errdetails.ErrorInfo{Reason: storage.ReasonQuotaExceeded, Domain: artifactgrpc.ErrorDomain}); derr == nil {
+		err.AddDetail(d)
+	}
+	if retryAfter > 0 {
+		if d, derr := connect.NewErrorDetail(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); derr == nil {
+			err.AddDetail(d)
+		}
+		err.Meta().Set(RetryAfterHeader, strconv.Itoa(retrySeconds(retryAfter)))
+	}
+	return err
+}
+
+// retrySeconds rounds d up to whole seconds.
+func retrySeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// HandlerOptions returns the Connect handler options enforcing
+// MaxRequestBytes. Connect rejects larger messages with ResourceExhausted
+// before they are decoded.
+func (l *Limiter) HandlerOptions() []connect.HandlerOption {
+	if l.cfg.MaxRequestBytes <= 0 {
+		return nil
+	}
+	return []connect.HandlerOption{connect.WithReadMaxBytes(int(min(l.cfg.MaxRequestBytes, math.MaxInt)))}
+}
+
+// Interceptor returns a Connect interceptor that rate limits unary RPCs and
+// the start of streams. It must run after the auth interceptor, which
+// authenticates the caller. The "source" field of a request only labels
+// the rejection in the log.
+func (l *Limiter) Interceptor() connect.Interceptor {
+	return &interceptor{limiter: l}
+}
+
+type interceptor struct {
+	limiter *Limiter
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		source := ""
+		if m, ok := req.Any().(proto.Message); ok {
+			source = stringField(m, "source")
+		}
+		if err := i.check(ctx, req.Spec().Procedure, req.Peer().Addr, source); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := i.check(ctx, conn.Spec().Procedure, conn.Peer().Addr, ""); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+func (i *interceptor) check(ctx context.Context, procedure, peer, source string) error {
+	caller := Caller(ctx, peer)
+	if ok, retry := i.limiter.Allow(procedure, caller); !ok {
+		slog.WarnContext(ctx, "Rate limit exceeded", "procedure", procedure, "caller", caller, "source", source)
+		return Error(fmt.Sprintf("rate limit exceeded for %s, retry in %d s", procedure, retrySeconds(retry)), retry)
+	}
+	return nil
+}
+
+// stringField returns the value of the named string field of msg, or "".
+func stringField(msg proto.Message, name protoreflect.Name) string {
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(name)
+	if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+		return ""
+	}
+	return m.Get(fd).String()
+}
+
+// ToolMiddleware returns an MCP tool handler middleware that enforces the
+// rate limits and MaxRequestBytes. It must run after the middleware that
+// puts the session's principal into the context; for anonymous sessions
+// the peer of middleware.Handler is used. The name of the MCP client only
+// labels the rejection in the log.
+func (l *Limiter) ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			tool := req.Params.Name
+			if l.cfg.MaxRequestBytes > 0 {
+				args, _ := json.Marshal(req.Params.Arguments)
+				if int64(len(args)) > l.cfg.MaxRequestBytes {
+					return artifactmcp.ErrorResult(storage.ReasonQuotaExceeded,
+						fmt.Sprintf("arguments of %s exceed the maximum request size of %d bytes", tool, l.cfg.MaxRequestBytes)), nil
+				}
+			}
+
+			caller := Caller(ctx, middleware.Peer(ctx))
+			if ok, retry := l.Allow(tool, caller); !ok {
+				slog.WarnContext(ctx, "Rate limit exceeded", "tool", tool, "caller", caller, "source", clientName(ctx))
+				secs := retrySeconds(retry)
+				return artifactmcp.RetryResult(storage.ReasonQuotaExceeded,
+					fmt.Sprintf("rate limit exceeded for %s, retry in %d s", tool, secs), secs), nil
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// clientName returns the name the MCP client gave at initialization.
+func clientName(ctx context.Context) string {
+	if s, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		return s.GetClientInfo().Name
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/client"
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	artifactgrpc "github.com/hmsoft0815/mlcartifact/internal/grpc"
+	artifactmcp "github.com/hmsoft0815/mlcartifact/internal/mcp"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := New(Config{
+		Default: Limit{Rate: 1, Burst: 2},
+		Limits:  map[string]Limit{"read_artifact": {}},
+	})
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("write_artifact", "principal:alice")
+	assert.True(t, ok)
+	ok, _ = l.Allow("write_artifact", "principal:alice")
+	assert.True(t, ok)
+	ok, retry := l.Allow("write_artifact", "principal:alice")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retry)
+
+	// Other callers and unlimited tools have their own budget
+	ok, _ = l.Allow("write_artifact", "principal:bob")
+	assert.True(t, ok)
+	for i := 0; i < 10; i++ {
+		ok, _ = l.Allow("read_artifact", "principal:alice")
+		assert.True(t, ok)
+	}
+
+	now = now.Add(time.Second)
+	ok, _ = l.Allow("write_artifact", "principal:alice")
+	assert.True(t, ok)
+}
+
+func TestCaller(t *testing.T) {
+	alice := auth.NewContext(context.Background(), &auth.Principal{Name: "alice", UserID: "alice"})
+	assert.Equal(t, "principal:alice", Caller(alice, "10.0.0.1:1234"))
+	assert.Equal(t, "peer:10.0.0.1", Caller(context.Background(), "10.0.0.1:1234"))
+	assert.Equal(t, "peer:10.0.0.1", Caller(context.Background(), "10.0.0.1:5678"))
+	assert.Equal(t, "peer:", Caller(context.Background(), ""))
+}
+
+// bearer authenticates the requests of an HTTP client with a token.
+type bearer string
+
+func (b bearer) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(b))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestInterceptor(t *testing.T) {
+	l := New(Config{
+		Limits:          map[string]Limit{protoconnect.ArtifactServiceWriteProcedure: {Rate: 0.1, Burst: 1}},
+		MaxRequestBytes: 1024,
+	})
+	a := auth.NewAuthenticator([]auth.Credential{
+		{Token: "alice-token", Principal: auth.Principal{Name: "alice", UserID: "alice"}},
+		{Token: "bob-token", Principal: auth.Principal{Name: "bob", UserID: "bob"}},
+	})
+	opts := append(l.HandlerOptions(), connect.WithInterceptors(a.Interceptor(), l.Interceptor()))
+
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewArtifactServiceHandler(artifactgrpc.NewConnectServer(storage.NewStore(t.TempDir())), opts...))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	alice, err := client.NewClientWithAddr(srv.URL, client.WithHTTPClient(&http.Client{Transport: bearer("alice-token")}))
+	require.NoError(t, err)
+	bob, err := client.NewClientWithAddr(srv.URL, client.WithHTTPClient(&http.Client{Transport: bearer("bob-token")}))
+	require.NoError(t, err)
+
+	_, err = alice.Write(t.Context(), "a.txt", []byte("a"), client.WithSource("agent-1"))
+	require.NoError(t, err)
+
+	// Another source does not get a fresh bucket
+	_, err = alice.Write(t.Context(), "b.txt", []byte("b"), client.WithSource("agent-2"))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	assert.Equal(t, "QUOTA_EXCEEDED", client.ErrorReason(err))
+	delay, ok := client.RetryDelay(err)
+	require.True(t, ok)
+	assert.InDelta(t, 10*time.Second, delay, float64(time.Second))
+	var cerr *connect.Error
+	require.ErrorAs(t, err, &cerr)
+	assert.Equal(t, "10", cerr.Meta().Get(RetryAfterHeader))
+
+	_, err = bob.Write(t.Context(), "b.txt", []byte("b"))
+	assert.NoError(t, err)
+
+	// Oversized requests are rejected before they reach the store
+	_, err = alice.Write(t.Context(), "big.txt", []byte(strings.Repeat("x", 2048)))
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+}
+
+func TestToolMiddleware(t *testing.T) {
+	l := New(Config{Default: Limit{Rate: 1, Burst: 1}, MaxRequestBytes: 64})
+	calls := 0
+	handler := l.ToolMiddleware()(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "write_artifact"
+	req.Params.Arguments = map[string]any{"filename": "a.txt", "user_id": "u1"}
+	res, err := handler(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+
+	// Another user_id does not get a fresh bucket, another principal does
+	req.Params.Arguments = map[string]any{"filename": "a.txt", "user_id": "u2"}
+	res, err = handler(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, res.IsError)
+	te := res.StructuredContent.(map[string]artifactmcp.ToolError)["error"]
+	assert.Equal(t, "QUOTA_EXCEEDED", te.Reason)
+	assert.Equal(t, 1, te.RetryAfterSeconds)
+	res, err = handler(auth.NewContext(context.Background(), &auth.Principal{Name: "alice"}), req)
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+
+	req.Params.Arguments = map[string]any{"content": strings.Repeat("x", 100), "user_id": "u2"}
+	res, err = handler(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "maximum request size")
+	assert.Equal(t, 2, calls)
+}
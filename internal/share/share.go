@@ -0,0 +1,187 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package share implements signed, expiring download links for artifacts.
+//
+// A link carries the artifact ID, the user scope and an expiry timestamp,
+// authenticated with an HMAC-SHA256 signature. The Handler verifies the
+// signature and serves the raw artifact bytes over plain HTTP, so that humans
+// can open artifacts in a browser without speaking Connect RPC.
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/auth"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+)
+
+// PathPrefix is the URL path under which the download Handler is mounted.
+const PathPrefix = "/share/"
+
+// LinkPrincipal is the principal name of share link downloads, e.g. in the
+// audit log.
+const LinkPrincipal = "share-link"
+
+// DefaultTTL is the lifetime of a link when the caller does not request one.
+const DefaultTTL = time.Hour
+
+// MaxTTL caps the lifetime of any link regardless of the requested duration.
+const MaxTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidSignature is returned when a link was not signed by this Signer.
+	ErrInvalidSignature = errors.New("invalid link signature")
+	// ErrLinkExpired is returned when a link is past its expiry time.
+	ErrLinkExpired = errors.New("link expired")
+)
+
+// Signer creates and verifies signed download links.
+type Signer struct {
+	secret  []byte
+	baseURL string // e.g. "https://artifacts.example.com"
+}
+
+// NewSigner creates a Signer using the given HMAC secret and public base URL.
+// If secret is empty, a random secret is generated; links then stop working
+// when the process restarts.
+func NewSigner(secret []byte, baseURL string) *Signer {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return &Signer{
+		secret:  secret,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Link is a signed download URL together with its expiry time.
+type Link struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// Sign returns a link for the artifact id in the given user scope.
+// The ttl is clamped to (0, MaxTTL] and never outlives artifactExpiry,
+// unless artifactExpiry is zero.
+func (s *Signer) Sign(id, userID string, ttl time.Duration, artifactExpiry time.Time) Link {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if ttl > MaxTTL {
+		ttl = MaxTTL
+	}
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	if !artifactExpiry.IsZero() && artifactExpiry.Before(expiresAt) {
+		expiresAt = artifactExpiry.Truncate(time.Second)
+	}
+
+	q := url.Values{}
+	if userID != "" {
+		q.Set("u", userID)
+	}
+	exp := expiresAt.Unix()
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", s.signature(id, userID, exp))
+
+	return Link{
+		URL:       s.baseURL + PathPrefix + url.PathEscape(id) + "?" + q.Encode(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Verify checks the signature and expiry of a link's components.
+func (s *Signer) Verify(id, userID string, exp int64, sig string) error {
+	expected := s.signature(id, userID, exp)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > exp {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+// signature computes the URL-safe HMAC over the link components.
+func (s *Signer) signature(id, userID string, exp int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d", id, userID, exp)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Handler returns an http.Handler serving artifact bytes for signed links.
+// It must be mounted at PathPrefix. Appending "inline=1" to a link asks the
+// browser to display the artifact instead of downloading it, which is
+// honoured for passive types such as text and images only.
+func (s *Signer) Handler(store *storage.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), PathPrefix))
+		if err != nil || id == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		q := r.URL.Query()
+		userID := q.Get("u")
+		exp, err := strconv.ParseInt(q.Get("exp"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid link", http.StatusBadRequest)
+			return
+		}
+		if err := s.Verify(id, userID, exp, q.Get("sig")); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, ErrLinkExpired) {
+				status = http.StatusGone
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		// Downloads are made on behalf of the link, not of a principal
+		ctx := auth.NewContext(r.Context(), &auth.Principal{Name: LinkPrincipal, UserID: userID})
+		f, meta, err := store.Open(ctx, id, storage.OpenOptions{UserID: userID})
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+
+		modTime := meta.CreatedAt
+		if info, err := f.Stat(); err == nil {
+			modTime = info.ModTime()
+		}
+
+		// Active content such as HTML or SVG is never displayed, or it
+		// would run script on the server's origin
+		disposition := "attachment"
+		if q.Get("inline") == "1" && storage.IsPassiveMimeType(meta.MimeType) {
+			disposition = "inline"
+		}
+		w.Header().Set("Content-Type", meta.MimeType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": meta.Filename}))
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(exp-time.Now().Unix(), 10))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Security-Policy", "sandbox")
+
+		slog.InfoContext(r.Context(), "artifact served via share link", "id", meta.ID, "user_id", userID)
+		http.ServeContent(w, r, meta.Filename, modTime, f)
+	})
+}
@@ -0,0 +1,145 @@
+package share
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_Handler(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-share-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := storage.NewStore(tempDir)
+	meta, err := store.Write(t.Context(), "report.csv", []byte("a,b\n1,2"), storage.WriteOptions{ExpiresHours: 1, Source: "test", UserID: "alice"})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	signer := NewSigner([]byte("secret"), srv.URL)
+	mux.Handle(PathPrefix, signer.Handler(store))
+
+	t.Run("ValidLink", func(t *testing.T) {
+		link := signer.Sign(meta.ID, "alice", 10*time.Minute, meta.ExpiresAt)
+		res, err := http.Get(link.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(res.Body)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "a,b\n1,2", string(body))
+		assert.Equal(t, "text/csv", res.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=report.csv`, res.Header.Get("Content-Disposition"))
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		link := signer.Sign(meta.ID, "alice", time.Minute, time.Time{})
+		req, err := http.NewRequest(http.MethodGet, link.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Range", "bytes=4-6")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(res.Body)
+		assert.Equal(t, http.StatusPartialContent, res.StatusCode)
+		assert.Equal(t, "1,2", string(body))
+		assert.Equal(t, "bytes 4-6/7", res.Header.Get("Content-Range"))
+	})
+
+	t.Run("NotModified", func(t *testing.T) {
+		link := signer.Sign(meta.ID, "alice", time.Minute, time.Time{})
+		res, err := http.Get(link.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+		lastModified := res.Header.Get("Last-Modified")
+		require.NotEmpty(t, lastModified)
+
+		req, err := http.NewRequest(http.MethodGet, link.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("If-Modified-Since", lastModified)
+		res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
+	})
+
+	t.Run("Inline", func(t *testing.T) {
+		notes, err := store.Write(t.Context(), "notes.txt", []byte("hello"), storage.WriteOptions{UserID: "alice"})
+		require.NoError(t, err)
+		link := signer.Sign(notes.ID, "alice", 0, time.Time{})
+		res, err := http.Get(link.URL + "&inline=1")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.True(t, strings.HasPrefix(res.Header.Get("Content-Disposition"), "inline"))
+		assert.Equal(t, "sandbox", res.Header.Get("Content-Security-Policy"))
+	})
+
+	t.Run("ActiveContent", func(t *testing.T) {
+		for _, name := range []string{"page.html", "logo.svg"} {
+			active, err := store.Write(t.Context(), name, []byte("<script>alert(1)</script>"), storage.WriteOptions{UserID: "alice"})
+			require.NoError(t, err)
+			link := signer.Sign(active.ID, "alice", 0, time.Time{})
+			res, err := http.Get(link.URL + "&inline=1")
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.True(t, strings.HasPrefix(res.Header.Get("Content-Disposition"), "attachment"), name)
+			assert.Equal(t, "sandbox", res.Header.Get("Content-Security-Policy"), name)
+			assert.Equal(t, "nosniff", res.Header.Get("X-Content-Type-Options"), name)
+		}
+	})
+
+	t.Run("TamperedScope", func(t *testing.T) {
+		link := signer.Sign(meta.ID, "alice", time.Minute, time.Time{})
+		res, err := http.Get(strings.Replace(link.URL, "u=alice", "u=bob", 1))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("OtherSecret", func(t *testing.T) {
+		link := NewSigner([]byte("other"), srv.URL).Sign(meta.ID, "alice", time.Minute, time.Time{})
+		res, err := http.Get(link.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		link := signer.Sign(meta.ID, "alice", time.Minute, time.Now().Add(-time.Minute))
+		res, err := http.Get(link.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusGone, res.StatusCode)
+	})
+
+	t.Run("MissingArtifact", func(t *testing.T) {
+		link := signer.Sign("does-not-exist", "alice", time.Minute, time.Time{})
+		res, err := http.Get(link.URL)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestSigner_TTLClamp(t *testing.T) {
+	signer := NewSigner(nil, "http://localhost")
+
+	link := signer.Sign("id", "", 30*24*time.Hour, time.Time{})
+	assert.WithinDuration(t, time.Now().Add(MaxTTL), link.ExpiresAt, 2*time.Second)
+
+	artifactExpiry := time.Now().Add(5 * time.Minute)
+	link = signer.Sign("id", "", time.Hour, artifactExpiry)
+	assert.WithinDuration(t, artifactExpiry, link.ExpiresAt, 2*time.Second)
+}
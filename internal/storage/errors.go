@@ -0,0 +1,96 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors returned by the store, wrapped in an *Error. Test for them
+// with errors.Is.
+var (
+	ErrNotFound        = errors.New("artifact not found")
+	ErrAlreadyExists   = errors.New("artifact already exists")
+	ErrInvalidPath     = errors.New("invalid path")
+	ErrInvalidArgument = errors.New("invalid argument")
+	ErrQuotaExceeded   = errors.New("quota exceeded")
+	ErrConflict        = errors.New("conflict")
+)
+
+// Error describes a failed store operation, like os.PathError.
+type Error struct {
+	Op     string // Store method, e.g. "read"
+	Path   string // ID, filename or virtual path the operation was called with
+	UserID string // User scope ("" = global)
+	Err    error  // One of the sentinel errors, possibly wrapped with a reason
+}
+
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return e.Op + ": " + e.Err.Error()
+	}
+	return fmt.Sprintf("%s %q: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError returns an *Error for op on path. A non-empty reason is appended
+// to the sentinel, e.g. "invalid path: filename must not contain '/'".
+func newError(op, path, userID string, sentinel error, reason string) error {
+	err := sentinel
+	if reason != "" {
+		err = fmt.Errorf("%w: %s", sentinel, reason)
+	}
+	return &Error{Op: op, Path: path, UserID: userID, Err: err}
+}
+
+// wrapError returns err as an *Error for op on path, unless it already is one.
+func wrapError(op, path, userID string, err error) error {
+	var se *Error
+	if err == nil || errors.As(err, &se) {
+		return err
+	}
+	return &Error{Op: op, Path: path, UserID: userID, Err: err}
+}
+
+// checkScope rejects user IDs that would escape the users directory.
+func checkScope(userID string) error {
+	if userID == "." || userID == ".." || strings.ContainsAny(userID, "/\\\x00") {
+		return fmt.Errorf("%w: invalid user ID %q", ErrInvalidPath, userID)
+	}
+	return nil
+}
+
+// Reasons reported by Reason.
+const (
+	ReasonNotFound        = "NOT_FOUND"
+	ReasonAlreadyExists   = "ALREADY_EXISTS"
+	ReasonInvalidPath     = "INVALID_PATH"
+	ReasonInvalidArgument = "INVALID_ARGUMENT"
+	ReasonQuotaExceeded   = "QUOTA_EXCEEDED"
+	ReasonConflict        = "CONFLICT"
+	ReasonInternal        = "INTERNAL"
+)
+
+// Reason returns a stable, machine-readable reason for err, as reported to
+// RPC and MCP clients. Errors that are not store errors are INTERNAL.
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return ReasonNotFound
+	case errors.Is(err, ErrAlreadyExists):
+		return ReasonAlreadyExists
+	case errors.Is(err, ErrInvalidPath):
+		return ReasonInvalidPath
+	case errors.Is(err, ErrInvalidArgument):
+		return ReasonInvalidArgument
+	case errors.Is(err, ErrQuotaExceeded):
+		return ReasonQuotaExceeded
+	case errors.Is(err, ErrConflict):
+		return ReasonConflict
+	default:
+		return ReasonInternal
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType describes what happened to an artifact.
+type EventType string
+
+const (
+	EventCreated EventType = "created" // A new artifact was written
+	EventPatched EventType = "patched" // The content of an artifact changed
+	EventDeleted EventType = "deleted" // An artifact was deleted
+	EventExpired EventType = "expired" // An artifact was removed by Cleanup
+)
+
+const (
+	// eventHistory is the number of past events kept for resuming watchers.
+	eventHistory = 4096
+	// watchBuffer is the number of events a watcher may fall behind before
+	// it is disconnected.
+	watchBuffer = 256
+)
+
+var (
+	// ErrCursorExpired is returned by Watch when the cursor is unknown, e.g.
+	// because the server restarted or the events have left the history.
+	// Consumers should re-list and watch again without a cursor.
+	ErrCursorExpired = errors.New("watch cursor expired")
+	// ErrWatchOverflow is reported by Subscription.Err when the consumer did
+	// not keep up with the events. It can resume from its last cursor.
+	ErrWatchOverflow = errors.New("watcher fell behind")
+)
+
+// Event is a change to an artifact, delivered to watchers.
+type Event struct {
+	Cursor    string            // Opaque position, pass to Watch to resume after this event
+	Type      EventType         // What happened
+	Time      time.Time         // When it happened
+	Artifact  *ArtifactMetadata // The artifact as of this event
+	SizeBytes int64             // Content size, 0 for deleted and expired artifacts
+	seq       uint64
+}
+
+// WatchFilter selects the events a watcher receives.
+type WatchFilter struct {
+	UserID     string // User scope ("" = global)
+	AnyUser    bool   // Match events of every user scope, ignoring UserID
+	PathPrefix string // Optional, only artifacts at or below this virtual path
+	Source     string // Optional, only artifacts from this source
+}
+
+func (f WatchFilter) match(meta *ArtifactMetadata) bool {
+	if !f.AnyUser && meta.UserID != f.UserID {
+		return false
+	}
+	if f.Source != "" && meta.Source != f.Source {
+		return false
+	}
+	if f.PathPrefix != "" {
+		if meta.VirtualPath == "" {
+			return false
+		}
+		prefix := NormalizePath(f.PathPrefix)
+		if prefix != "/" && meta.VirtualPath != prefix && !strings.HasPrefix(meta.VirtualPath, prefix+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// Subscription delivers events to a watcher until its context is cancelled
+// or it falls behind.
+type Subscription struct {
+	// C receives the events. It is closed when the subscription ends.
+	C <-chan Event
+
+	c      chan Event
+	done   chan struct{}
+	filter WatchFilter
+	err    error
+}
+
+// Err returns why the subscription ended once C is closed: ErrWatchOverflow
+// if the watcher fell behind, or nil if its context was cancelled.
+func (sub *Subscription) Err() error {
+	return sub.err
+}
+
+// eventHub fans store events out to subscriptions and keeps a bounded history
+// for resuming. Cursors carry a per-process epoch, so cursors issued before a
+// restart are rejected instead of silently skipping events.
+type eventHub struct {
+	mu      sync.Mutex
+	epoch   string
+	seq     uint64
+	history []Event // ring buffer of the last eventHistory events
+	subs    map[*Subscription]struct{}
+}
+
+func newEventHub() *eventHub {
+	epoch := make([]byte, 4)
+	_, _ = rand.Read(epoch)
+	return &eventHub{
+		epoch: fmt.Sprintf("%x", epoch),
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+func (h *eventHub) publish(typ EventType, meta *ArtifactMetadata, size int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	snapshot := *meta
+	ev := Event{
+		Cursor:    fmt.Sprintf("%s.%d", h.epoch, h.seq),
+		Type:      typ,
+		Time:      time.Now(),
+		Artifact:  &snapshot,
+		SizeBytes: size,
+		seq:       h.seq,
+	}
+	if len(h.history) < eventHistory {
+		h.history = append(h.history, ev)
+	} else {
+		h.history[int((h.seq-1)%eventHistory)] = ev
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.match(ev.Artifact) {
+			continue
+		}
+		select {
+		case sub.c <- ev:
+		default:
+			h.remove(sub, ErrWatchOverflow)
+		}
+	}
+}
+
+// since returns the events after seq in order, or false if some of them are
+// no longer in the history.
+func (h *eventHub) since(seq uint64) ([]Event, bool) {
+	if seq > h.seq {
+		return nil, false
+	}
+	n := h.seq - seq
+	if n > uint64(len(h.history)) {
+		return nil, false
+	}
+	events := make([]Event, 0, n)
+	for s := seq + 1; s <= h.seq; s++ {
+		events = append(events, h.history[int((s-1)%eventHistory)])
+	}
+	return events, true
+}
+
+// remove ends a subscription. The caller must hold h.mu.
+func (h *eventHub) remove(sub *Subscription, err error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.done)
+	close(sub.c)
+}
+
+// Watch subscribes to events matching filter. If cursor is empty, only events
+// from now on are delivered; otherwise delivery resumes right after the event
+// with that cursor. The subscription ends when ctx is cancelled.
+func (s *Store) Watch(ctx context.Context, filter WatchFilter, cursor string) (*Subscription, error) {
+	h := s.events
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if cursor != "" {
+		epoch, seqStr, _ := strings.Cut(cursor, ".")
+		seq, err := strconv.ParseUint(seqStr, 10, 64)
+		if err != nil || epoch != h.epoch {
+			return nil, ErrCursorExpired
+		}
+		events, ok := h.since(seq)
+		if !ok {
+			return nil, ErrCursorExpired
+		}
+		for _, ev := range events {
+			if filter.match(ev.Artifact) {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	c := make(chan Event, len(replay)+watchBuffer)
+	for _, ev := range replay {
+		c <- ev
+	}
+	sub := &Subscription{C: c, c: c, done: make(chan struct{}), filter: filter}
+	h.subs[sub] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.mu.Lock()
+			h.remove(sub, nil)
+			h.mu.Unlock()
+		case <-sub.done:
+		}
+	}()
+	return sub, nil
+}
+
+// publish records an event for meta, looking up the content size on disk.
+func (s *Store) publish(typ EventType, meta *ArtifactMetadata) {
+	var size int64
+	if typ == EventCreated || typ == EventPatched {
+		storageName := fmt.Sprintf("%s_%s", meta.ID, meta.Filename)
+		if info, err := os.Stat(filepath.Join(s.prefixDir(meta.UserID), storageName)); err == nil {
+			size = info.Size()
+		}
+	}
+	s.events.publish(typ, meta, size)
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Watch(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := NewStore(tempDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub, err := store.Watch(ctx, WatchFilter{UserID: "u1", Source: "agent"}, "")
+	require.NoError(t, err)
+
+	meta, err := store.Write(t.Context(), "a.txt", []byte("a"), WriteOptions{ExpiresHours: 1, Source: "agent", UserID: "u1", VirtualPath: "/a.txt"})
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "b.txt", []byte("b"), WriteOptions{ExpiresHours: 1, Source: "other", UserID: "u1", VirtualPath: "/b.txt"})
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "c.txt", []byte("c"), WriteOptions{ExpiresHours: 1, Source: "agent", UserID: "u2", VirtualPath: "/c.txt"})
+	require.NoError(t, err)
+	_, err = store.Patch(t.Context(), meta.ID, []byte("more"), PatchOptions{UserID: "u1", Append: true})
+	require.NoError(t, err)
+	_, err = store.Delete(t.Context(), meta.ID, DeleteOptions{UserID: "u1"})
+	require.NoError(t, err)
+
+	var got []EventType
+	for len(got) < 3 {
+		ev := <-sub.C
+		assert.Equal(t, meta.ID, ev.Artifact.ID)
+		got = append(got, ev.Type)
+	}
+	assert.Equal(t, []EventType{EventCreated, EventPatched, EventDeleted}, got)
+
+	cancel()
+	_, open := <-sub.C
+	assert.False(t, open)
+	assert.NoError(t, sub.Err())
+}
+
+func TestStore_WatchResumeAndExpire(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := NewStore(tempDir)
+	ctx := context.Background()
+
+	sub, err := store.Watch(ctx, WatchFilter{}, "")
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "old.txt", []byte("x"), WriteOptions{ExpiresHours: 1, Source: "test", VirtualPath: "/old.txt"})
+	require.NoError(t, err)
+	first := <-sub.C
+
+	// Force expiry and clean up; the resumed watcher sees the expire event
+	_, err = store.Write(t.Context(), "gone.txt", []byte("x"), WriteOptions{ExpiresHours: 1, Source: "test", VirtualPath: "/gone.txt"})
+	require.NoError(t, err)
+	_, meta, err := store.Read(t.Context(), "/gone.txt", ReadOptions{})
+	require.NoError(t, err)
+	fullPath, _, err := store.locate(t.Context(), meta.ID, "")
+	require.NoError(t, err)
+	meta.ExpiresAt = time.Now().Add(-time.Hour)
+	metaBytes, _ := json.Marshal(meta)
+	require.NoError(t, os.WriteFile(fullPath+".json", metaBytes, 0644))
+	store.Cleanup(t.Context())
+	_, _, err = store.Read(t.Context(), "/gone.txt", ReadOptions{})
+	assert.Error(t, err)
+
+	resumed, err := store.Watch(ctx, WatchFilter{PathPrefix: "/gone.txt"}, first.Cursor)
+	require.NoError(t, err)
+	ev := <-resumed.C
+	assert.Equal(t, EventCreated, ev.Type)
+	ev = <-resumed.C
+	assert.Equal(t, EventExpired, ev.Type)
+
+	// Cursors from another process are rejected
+	_, err = NewStore(tempDir).Watch(ctx, WatchFilter{}, first.Cursor)
+	assert.ErrorIs(t, err, ErrCursorExpired)
+
+	// Slow consumers are disconnected
+	for i := 0; i < watchBuffer+5; i++ {
+		store.publish(EventPatched, meta)
+	}
+	for range sub.C {
+	}
+	assert.ErrorIs(t, sub.Err(), ErrWatchOverflow)
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"fmt"
+	"os"
+)
+
+// Indexed reports whether the VFS index has been built from the data
+// directory. Virtual paths do not resolve before that.
+func (s *Store) Indexed() bool {
+	return s.indexed.Load()
+}
+
+// CheckWritable verifies that artifacts can be written to the data directory
+// by creating and removing a probe file.
+func (s *Store) CheckWritable() error {
+	f, err := os.CreateTemp(s.BaseDir, ".probe-*")
+	if err != nil {
+		return fmt.Errorf("data directory is not writable: %w", err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("data directory is not writable: %w", err)
+	}
+	return nil
+}
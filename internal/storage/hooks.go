@@ -0,0 +1,54 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import "context"
+
+// Operation describes a finished operation on artifacts, see OnOperation.
+type Operation struct {
+	Name     string            // "Write", "Read", "Replace", "SetExpiry", "List", "Find", "Patch", "Delete", "Move" or one passed to Report
+	Ref      string            // Requested ID, filename, virtual path, directory or pattern
+	UserID   string            // User scope ("" = global)
+	Source   string            // Source the artifact was written with, if known
+	Artifact *ArtifactMetadata // Artifact operated on; nil for listings and failed lookups
+	Bytes    int64             // Content bytes read or written
+	Err      error             // nil on success; ErrNotFound if Delete found nothing
+}
+
+// OnOperation registers fn to be called after every operation on artifacts,
+// whichever front end started it: Write, Read and Open (both "Read"),
+// Replace, SetExpiry, List and ListVFS (both "List"), Find, Patch, Delete
+// and Move. DeleteTree reports a Delete for every artifact it removes. Stat,
+// Mkdir, Watch and Cleanup are not reported. fn runs synchronously with the
+// context of the operation, so it must not block.
+func (s *Store) OnOperation(fn func(context.Context, Operation)) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.opHooks = append(s.opHooks, fn)
+}
+
+// Report passes an operation that a front end performed on the store's
+// artifacts without a store method, such as creating a share link or
+// watching, to the hooks of OnOperation, so that they see it like the
+// store's own operations.
+func (s *Store) Report(ctx context.Context, op Operation, err error) {
+	s.finish(ctx, &op, err)
+}
+
+// finish reports a finished operation to the hooks of OnOperation.
+func (s *Store) finish(ctx context.Context, op *Operation, err error) {
+	s.hooksMu.RLock()
+	hooks := s.opHooks
+	s.hooksMu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	op.Err = err
+	if op.Artifact != nil && op.Source == "" {
+		op.Source = op.Artifact.Source
+	}
+	for _, fn := range hooks {
+		fn(ctx, *op)
+	}
+}
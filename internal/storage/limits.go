@@ -0,0 +1,57 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"fmt"
+	"io"
+)
+
+// SetMaxArtifactSize limits the content size of artifacts written, replaced
+// or patched to n bytes. Larger content fails with ErrQuotaExceeded and
+// leaves the store unchanged. n <= 0 removes the limit.
+func (s *Store) SetMaxArtifactSize(n int64) {
+	s.maxSize.Store(n)
+}
+
+// MaxArtifactSize returns the limit set with SetMaxArtifactSize, 0 if none.
+func (s *Store) MaxArtifactSize() int64 {
+	return max(s.maxSize.Load(), 0)
+}
+
+// limitSize returns r limited to the maximum artifact size.
+func (s *Store) limitSize(r io.Reader) io.Reader {
+	n := s.MaxArtifactSize()
+	if n == 0 {
+		return r
+	}
+	return &sizeLimitReader{r: r, remaining: n, max: n}
+}
+
+// sizeLimitReader fails with ErrQuotaExceeded once more than max bytes have
+// been read.
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, r.err()
+	}
+	// Read one byte more than allowed to tell "exactly max" from "too large"
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, r.err()
+	}
+	return n, err
+}
+
+func (r *sizeLimitReader) err() error {
+	return fmt.Errorf("%w: artifact exceeds the maximum size of %d bytes", ErrQuotaExceeded, r.max)
+}
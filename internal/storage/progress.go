@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+)
+
+// ProgressFunc receives the progress of a store operation: done of total
+// units, bytes for reads and artifacts for listings, searches and recursive
+// deletes. It is called from the goroutine of the operation and should
+// return quickly.
+type ProgressFunc func(done, total int64)
+
+type progressKey struct{}
+
+// WithProgress returns a context that makes the store operations called
+// with it report their progress to fn: Read, List, ListVFS, Find and
+// DeleteTree.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressFrom returns the ProgressFunc of ctx, or one that does nothing.
+func progressFrom(ctx context.Context) ProgressFunc {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		return fn
+	}
+	return func(int64, int64) {}
+}
+
+// readChunk is the unit in which Read reports progress and checks for
+// cancellation.
+const readChunk = 1 << 20
+
+// readFile reads the file at path like os.ReadFile, but in chunks, see
+// readAll.
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readAll(ctx, f, info.Size())
+}
+
+// readAll reads r of the given size in chunks, reporting progress and
+// stopping when ctx is done.
+func readAll(ctx context.Context, r io.Reader, size int64) ([]byte, error) {
+	progress := progressFrom(ctx)
+	var buf bytes.Buffer
+	buf.Grow(int(size))
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		_, err := io.CopyN(&buf, r, readChunk)
+		progress(int64(buf.Len()), max(size, int64(buf.Len())))
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
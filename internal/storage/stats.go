@@ -0,0 +1,112 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UsageStat aggregates the stored artifacts of one user scope and MIME class.
+type UsageStat struct {
+	UserID    string // User scope ("" = global)
+	MimeClass string // "text", "image", "application" or "other"
+	Artifacts int
+	Bytes     int64
+}
+
+// CleanupStats describes one run of Cleanup.
+type CleanupStats struct {
+	Duration time.Duration
+	Removed  int   // Number of expired artifacts removed
+	Bytes    int64 // Content bytes reclaimed
+}
+
+// OnCleanup registers fn to be called after every Cleanup run.
+func (s *Store) OnCleanup(fn func(CleanupStats)) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.cleanupHooks = append(s.cleanupHooks, fn)
+}
+
+// IndexSize returns the number of virtual paths in the VFS index.
+func (s *Store) IndexSize() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, userIdx := range s.index {
+		n += len(userIdx)
+	}
+	return n
+}
+
+// mimeClass maps a MIME type onto one of a fixed set of classes, since the
+// type is chosen by the writer.
+func mimeClass(mimeType string) string {
+	class, _, _ := strings.Cut(mimeType, "/")
+	switch class = strings.ToLower(strings.TrimSpace(class)); class {
+	case "text", "image", "application":
+		return class
+	}
+	return "other"
+}
+
+// Usage walks the store and returns the artifact count and content size per
+// user scope and MIME class, sorted by scope and class.
+func (s *Store) Usage(ctx context.Context) (_ []UsageStat, err error) {
+	ctx, span := startSpan(ctx, "Usage", "", "")
+	defer func() { endSpan(span, err) }()
+
+	type key struct{ userID, class string }
+	totals := make(map[key]*UsageStat)
+
+	err = filepath.Walk(s.BaseDir, func(path string, info os.FileInfo, err error) error {
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		var meta ArtifactMetadata
+		if json.Unmarshal(data, &meta) != nil || !isMetaFile(path, &meta) {
+			return nil
+		}
+		content, err := os.Stat(strings.TrimSuffix(path, ".json"))
+		if err != nil {
+			return nil
+		}
+
+		class := mimeClass(meta.MimeType)
+		k := key{meta.UserID, class}
+		if totals[k] == nil {
+			totals[k] = &UsageStat{UserID: meta.UserID, MimeClass: class}
+		}
+		totals[k].Artifacts++
+		totals[k].Bytes += content.Size()
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, wrapError("usage", "", "", err)
+	}
+
+	stats := make([]UsageStat, 0, len(totals))
+	for _, st := range totals {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].UserID != stats[j].UserID {
+			return stats[i].UserID < stats[j].UserID
+		}
+		return stats[i].MimeClass < stats[j].MimeClass
+	})
+	return stats, nil
+}
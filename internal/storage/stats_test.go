@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_UsageAndCleanupHook(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-stats-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := NewStore(tempDir)
+	_, err = store.Write(t.Context(), "a.md", []byte("hello"), WriteOptions{MimeType: "text/markdown", ExpiresHours: 1, Source: "test", VirtualPath: "/a.md"})
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "b.txt", []byte("abc"), WriteOptions{MimeType: "text/plain", ExpiresHours: 1, Source: "test", VirtualPath: "/b.txt"})
+	require.NoError(t, err)
+	// A JSON artifact must not be mistaken for a metadata file
+	old, err := store.Write(t.Context(), "data.json", []byte(`{"id":"x"}`), WriteOptions{MimeType: "application/json", ExpiresHours: 1, Source: "test", UserID: "u1", VirtualPath: "/data.json"})
+	require.NoError(t, err)
+	// Unknown MIME types do not add classes
+	_, err = store.Write(t.Context(), "c.bin", []byte("xy"), WriteOptions{MimeType: "made-up-1/x", ExpiresHours: 1, Source: "test", UserID: "u1", VirtualPath: "/c.bin"})
+	require.NoError(t, err)
+
+	usage, err := store.Usage(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []UsageStat{
+		{UserID: "", MimeClass: "text", Artifacts: 2, Bytes: 8},
+		{UserID: "u1", MimeClass: "application", Artifacts: 1, Bytes: 10},
+		{UserID: "u1", MimeClass: "other", Artifacts: 1, Bytes: 2},
+	}, usage)
+	assert.Equal(t, 4, store.IndexSize())
+
+	var runs []CleanupStats
+	store.OnCleanup(func(st CleanupStats) { runs = append(runs, st) })
+
+	fullPath, meta, err := store.locate(t.Context(), old.ID, "u1")
+	require.NoError(t, err)
+	meta.ExpiresAt = time.Now().Add(-time.Hour)
+	metaBytes, _ := json.Marshal(meta)
+	require.NoError(t, os.WriteFile(fullPath+".json", metaBytes, 0644))
+	store.Cleanup(t.Context())
+
+	require.Len(t, runs, 1)
+	assert.Equal(t, 1, runs[0].Removed)
+	assert.Equal(t, int64(10), runs[0].Bytes)
+	assert.Equal(t, 3, store.IndexSize())
+}
@@ -9,16 +9,23 @@
 package storage
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // utf8Valid reports whether s is a valid UTF-8 string.
@@ -47,6 +54,20 @@ type Store struct {
 	mu      sync.RWMutex
 	// Index map[userID]map[virtualPath]artifactID
 	index map[string]map[string]string
+	// dirs map[userID]set of explicitly created (possibly empty) directories
+	dirs map[string]map[string]bool
+	// events fans out change notifications to watchers
+	events *eventHub
+
+	hooksMu      sync.RWMutex
+	cleanupHooks []func(CleanupStats)
+	opHooks      []func(context.Context, Operation)
+
+	// maxSize is the content size limit in bytes, see SetMaxArtifactSize
+	maxSize atomic.Int64
+
+	// indexed is set once the VFS index has been built, see Indexed
+	indexed atomic.Bool
 }
 
 // NewStore initializes a new Store with the given base directory and rebuilds the index.
@@ -54,6 +75,8 @@ func NewStore(baseDir string) *Store {
 	s := &Store{
 		BaseDir: baseDir,
 		index:   make(map[string]map[string]string),
+		dirs:    make(map[string]map[string]bool),
+		events:  newEventHub(),
 	}
 	s.rebuildIndex()
 	return s
@@ -92,6 +115,7 @@ func (s *Store) rebuildIndex() {
 		}
 		return nil
 	})
+	s.indexed.Store(true)
 }
 
 // NormalizePath ensures a path starts with / and is cleaned.
@@ -106,6 +130,17 @@ func NormalizePath(p string) string {
 	return cleaned
 }
 
+// WriteOptions are the optional attributes of a new artifact.
+type WriteOptions struct {
+	MimeType     string                 // Detected from the filename if empty
+	ExpiresHours int                    // Hours until expiry, 24 if <= 0
+	Source       string                 // Tool or server that produced the artifact
+	UserID       string                 // User scope ("" = global)
+	Description  string                 // Must be valid UTF-8
+	Metadata     map[string]interface{} // Arbitrary custom metadata
+	VirtualPath  string                 // Hierarchical path (VFS); not indexed if empty
+}
+
 // Write saves content and its metadata to the store.
 //
 // It performs several steps:
@@ -115,23 +150,45 @@ func NormalizePath(p string) string {
 // 4. Detects the MIME type if not provided.
 // 5. Writes the binary content and the JSON metadata to disk.
 // 6. Updates the in-memory VFS index.
-func (s *Store) Write(filename string, content []byte, mimeType string, expiresHours int, source string, userID string, description string, metadata map[string]interface{}, virtualPath string) (*ArtifactMetadata, error) {
+func (s *Store) Write(ctx context.Context, filename string, content []byte, opts WriteOptions) (*ArtifactMetadata, error) {
+	return s.WriteStream(ctx, filename, bytes.NewReader(content), opts)
+}
+
+// WriteStream is like Write but copies the content from r, so that large
+// uploads never have to be held in memory. The content is written to a
+// temporary file first and renamed into place once complete. Cancelling ctx
+// aborts the copy and discards the partial file.
+func (s *Store) WriteStream(ctx context.Context, filename string, r io.Reader, opts WriteOptions) (_ *ArtifactMetadata, err error) {
+	ctx, span := startSpan(ctx, "Write", opts.VirtualPath, opts.UserID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Write", Ref: filename, UserID: opts.UserID, Source: opts.Source}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	mimeType, expiresHours, userID := opts.MimeType, opts.ExpiresHours, opts.UserID
+	description, virtualPath := opts.Description, opts.VirtualPath
+
 	// 1. Validates input (UTF-8 checks).
 	if description != "" && !utf8Valid(description) {
-		return nil, fmt.Errorf("description contains invalid UTF-8 characters")
+		return nil, newError("write", filename, userID, ErrInvalidArgument, "description contains invalid UTF-8 characters")
+	}
+	if err := checkScope(userID); err != nil {
+		return nil, wrapError("write", filename, userID, err)
+	}
+	if base := filepath.Base(filename); filename == "" || base == "." || base == "/" || base == ".." {
+		return nil, newError("write", filename, userID, ErrInvalidPath, "filename is required")
+	}
+	if strings.ContainsRune(virtualPath, 0) {
+		return nil, newError("write", virtualPath, userID, ErrInvalidPath, "virtual path contains a NUL byte")
 	}
 	if err := os.MkdirAll(s.BaseDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create base directory: %w", err)
+		return nil, wrapError("write", filename, userID, fmt.Errorf("failed to create base directory: %w", err))
 	}
 
 	// 1. Determine storage prefix (global vs user)
-	prefixDir := filepath.Join(s.BaseDir, "global")
-	if userID != "" {
-		prefixDir = filepath.Join(s.BaseDir, "users", userID)
-	}
+	prefixDir := s.prefixDir(userID)
 
 	if err := os.MkdirAll(prefixDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create prefix directory: %w", err)
+		return nil, wrapError("write", filename, userID, fmt.Errorf("failed to create prefix directory: %w", err))
 	}
 
 	// 2. Generate unique ID
@@ -157,8 +214,11 @@ func (s *Store) Write(filename string, content []byte, mimeType string, expiresH
 	}
 
 	// 6. Write file
-	if err := os.WriteFile(fullPath, content, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write data: %w", err)
+	if err := writeFileAtomic(ctx, fullPath, s.limitSize(r)); err != nil {
+		return nil, wrapError("write", filename, userID, fmt.Errorf("failed to write data: %w", err))
+	}
+	if info, err := os.Stat(fullPath); err == nil {
+		op.Bytes = info.Size()
 	}
 
 	// 7. Write metadata
@@ -173,16 +233,16 @@ func (s *Store) Write(filename string, content []byte, mimeType string, expiresH
 		VirtualPath: vPath,
 		MimeType:    mimeType,
 		Description: description,
-		Source:      source,
+		Source:      opts.Source,
 		UserID:      userID,
 		CreatedAt:   time.Now(),
 		ExpiresAt:   expiresAt,
-		Metadata:    metadata,
+		Metadata:    opts.Metadata,
 	}
 
 	metaBytes, _ := json.MarshalIndent(meta, "", "  ")
 	if err := os.WriteFile(metaPath, metaBytes, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write metadata: %w", err)
+		return nil, wrapError("write", filename, userID, fmt.Errorf("failed to write metadata: %w", err))
 	}
 
 	// 8. Update index
@@ -199,12 +259,166 @@ func (s *Store) Write(filename string, content []byte, mimeType string, expiresH
 		s.mu.Unlock()
 	}
 
+	op.Artifact = meta
+	s.publish(EventCreated, meta)
 	return meta, nil
 }
 
+// ReadOptions select the artifact to read.
+type ReadOptions struct {
+	UserID string // User scope ("" = global)
+}
+
 // Read retrieves content and metadata for a given ID, filename, or virtual path.
 // If multiple files match a filename, the newest one (highest ID prefix) is returned.
-func (s *Store) Read(idOrPath string, userID string) ([]byte, *ArtifactMetadata, error) {
+func (s *Store) Read(ctx context.Context, idOrPath string, opts ReadOptions) (_ []byte, _ *ArtifactMetadata, err error) {
+	ctx, span := startSpan(ctx, "Read", idOrPath, opts.UserID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Read", Ref: idOrPath, UserID: opts.UserID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	fullPath, meta, err := s.locate(ctx, idOrPath, opts.UserID)
+	if err != nil {
+		return nil, nil, wrapError("read", idOrPath, opts.UserID, err)
+	}
+
+	data, err := readFile(ctx, fullPath)
+	if err != nil {
+		return nil, nil, wrapError("read", idOrPath, opts.UserID, err)
+	}
+	span.SetAttributes(attribute.Int("artifact.size", len(data)))
+	op.Artifact, op.Bytes = meta, int64(len(data))
+	return data, meta, nil
+}
+
+// OpenOptions configure Open.
+type OpenOptions struct {
+	UserID string // User scope ("" = global)
+}
+
+// Open returns a read-only handle to an artifact's content together with its
+// metadata, so that large artifacts can be streamed instead of buffered.
+// The caller must close the returned file.
+func (s *Store) Open(ctx context.Context, idOrPath string, opts OpenOptions) (_ *os.File, _ *ArtifactMetadata, err error) {
+	userID := opts.UserID
+	ctx, span := startSpan(ctx, "Open", idOrPath, userID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Read", Ref: idOrPath, UserID: userID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	fullPath, meta, err := s.locate(ctx, idOrPath, userID)
+	if err != nil {
+		return nil, nil, wrapError("open", idOrPath, userID, err)
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, nil, wrapError("open", idOrPath, userID, err)
+	}
+	op.Artifact = meta
+	if info, err := f.Stat(); err == nil {
+		op.Bytes = info.Size()
+		span.SetAttributes(attribute.Int64("artifact.size", info.Size()))
+	}
+	return f, meta, nil
+}
+
+// ReplaceOptions configure Replace.
+type ReplaceOptions struct {
+	UserID string // User scope ("" = global)
+}
+
+// Replace overwrites the content of an existing artifact with the data from r.
+// The artifact keeps its ID, virtual path and metadata.
+func (s *Store) Replace(ctx context.Context, idOrPath string, r io.Reader, opts ReplaceOptions) (_ *ArtifactMetadata, err error) {
+	userID := opts.UserID
+	ctx, span := startSpan(ctx, "Replace", idOrPath, userID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Replace", Ref: idOrPath, UserID: userID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	fullPath, meta, err := s.locate(ctx, idOrPath, userID)
+	if err != nil {
+		return nil, wrapError("replace", idOrPath, userID, err)
+	}
+
+	if err := writeFileAtomic(ctx, fullPath, s.limitSize(r)); err != nil {
+		return nil, wrapError("replace", idOrPath, userID, fmt.Errorf("failed to update data: %w", err))
+	}
+	op.Artifact = meta
+	if info, err := os.Stat(fullPath); err == nil {
+		op.Bytes = info.Size()
+	}
+	s.publish(EventPatched, meta)
+	return meta, nil
+}
+
+// SetExpiryOptions configure SetExpiry.
+type SetExpiryOptions struct {
+	UserID string // User scope ("" = global)
+}
+
+// SetExpiry changes the expiration time of an artifact to expiresHours from
+// now (24 if expiresHours <= 0) and returns the updated metadata.
+func (s *Store) SetExpiry(ctx context.Context, idOrPath string, expiresHours int, opts SetExpiryOptions) (_ *ArtifactMetadata, err error) {
+	userID := opts.UserID
+	ctx, span := startSpan(ctx, "SetExpiry", idOrPath, userID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "SetExpiry", Ref: idOrPath, UserID: userID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	fullPath, meta, err := s.locate(ctx, idOrPath, userID)
+	if err != nil {
+		return nil, wrapError("set expiry", idOrPath, userID, err)
+	}
+
+	if expiresHours <= 0 {
+		expiresHours = 24
+	}
+	meta.ExpiresAt = time.Now().Add(time.Duration(expiresHours) * time.Hour)
+
+	metaBytes, _ := json.MarshalIndent(meta, "", "  ")
+	if err := writeFileAtomic(ctx, fullPath+".json", bytes.NewReader(metaBytes)); err != nil {
+		return nil, wrapError("set expiry", idOrPath, userID, fmt.Errorf("failed to write metadata: %w", err))
+	}
+	op.Artifact = meta
+	return meta, nil
+}
+
+// writeFileAtomic copies r into a temporary file next to path and renames it
+// into place, so readers never observe a partially written artifact.
+func writeFileAtomic(ctx context.Context, path string, r io.Reader) error {
+	_, span := tracer().Start(ctx, "storage.io.write")
+	defer span.End()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, ctxReader{ctx, r})
+	span.SetAttributes(attribute.Int64("io.bytes", n))
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// locate resolves an ID, filename, or virtual path to the content file on disk
+// and loads its metadata.
+func (s *Store) locate(ctx context.Context, idOrPath string, userID string) (_ string, _ *ArtifactMetadata, err error) {
+	if err := checkScope(userID); err != nil {
+		return "", nil, err
+	}
 	uID := userID
 	if uID == "" {
 		uID = "global"
@@ -224,18 +438,24 @@ func (s *Store) Read(idOrPath string, userID string) ([]byte, *ArtifactMetadata,
 		s.mu.RUnlock()
 	}
 
-	prefixDir := filepath.Join(s.BaseDir, "global")
-	if userID != "" {
-		prefixDir = filepath.Join(s.BaseDir, "users", userID)
-	}
+	prefixDir := s.prefixDir(userID)
 
 	// Find the file. ID is prefix of storage name: {id}_{filename}
+	ctx, span := tracer().Start(ctx, "storage.scan")
+	defer func() { endSpan(span, err) }()
 	files, err := os.ReadDir(prefixDir)
 	if err != nil {
-		return nil, nil, err
+		if os.IsNotExist(err) {
+			return "", nil, ErrNotFound // Nothing was ever written to this scope
+		}
+		return "", nil, err
 	}
 
+	span.SetAttributes(attribute.Int("scan.entries", len(files)))
 	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
 		// CRITICAL: We must NOT match the metadata file here when looking for content.
 		// Artifact files are named {id}_{filename}.
 		// Metadata files are named {id}_{filename}.json.
@@ -245,16 +465,6 @@ func (s *Store) Read(idOrPath string, userID string) ([]byte, *ArtifactMetadata,
 		// Special case: if filename itself ends in .json, the artifact is {id}_file.json
 		// and metadata is {id}_file.json.json.
 		// So we only skip if it's the metadata file.
-		
-		// If it's a metadata file, it always has .json added to the full storage name
-		// We can check if a file without the .json suffix exists to be sure,
-		// but a simpler way is to check the suffix ".json" AND ensure it's not the artifact itself.
-		
-		// Let's use a more robust way: metadata files ALWAYS end in .json
-		// Artifact files might end in .json too.
-		// But metadata files are ALWAYS artifactName + ".json"
-		
-		// Revised logic:
 		if strings.HasSuffix(f.Name(), ".json") {
 			// Check if this is a metadata file by looking for the artifact file
 			artifactName := strings.TrimSuffix(f.Name(), ".json")
@@ -275,41 +485,59 @@ func (s *Store) Read(idOrPath string, userID string) ([]byte, *ArtifactMetadata,
 			fullPath := filepath.Join(prefixDir, f.Name())
 			metaPath := fullPath + ".json"
 
-			data, err := os.ReadFile(fullPath)
-			if err != nil {
-				return nil, nil, err
-			}
-
 			metaData, err := os.ReadFile(metaPath)
 			if err != nil {
-				return nil, nil, err
+				return "", nil, err
 			}
 
 			var meta ArtifactMetadata
 			if err := json.Unmarshal(metaData, &meta); err != nil {
-				return nil, nil, err
+				return "", nil, err
 			}
 
-			return data, &meta, nil
+			return fullPath, &meta, nil
 		}
 	}
 
-	return nil, nil, fmt.Errorf("artifact not found")
+	return "", nil, ErrNotFound
+}
+
+// prefixDir returns the storage directory for a user scope (global if empty).
+func (s *Store) prefixDir(userID string) string {
+	if userID != "" {
+		return filepath.Join(s.BaseDir, "users", userID)
+	}
+	return filepath.Join(s.BaseDir, "global")
+}
+
+// ListOptions select and paginate the artifacts returned by List.
+type ListOptions struct {
+	UserID  string // User scope ("" = global)
+	DirPath string // Virtual directory to list; all artifacts if empty
+	Limit   int    // Maximum number of items, unlimited if <= 0
+	Offset  int    // Number of items to skip
 }
 
 // List returns artifacts for a specific user.
-// If dirPath is empty, it returns a flat list of all artifacts.
-// If dirPath is set, it returns items (files and virtual folders) in that virtual directory.
-func (s *Store) List(userID string, limit, offset int, dirPath string) ([]*ArtifactMetadata, error) {
-	if dirPath != "" {
-		return s.ListVFS(userID, dirPath, limit, offset)
+// If opts.DirPath is empty, it returns a flat list of all artifacts.
+// If opts.DirPath is set, it returns items (files and virtual folders) in that virtual directory.
+func (s *Store) List(ctx context.Context, opts ListOptions) (_ []*ArtifactMetadata, err error) {
+	userID, limit, offset := opts.UserID, opts.Limit, opts.Offset
+	if opts.DirPath != "" {
+		return s.ListVFS(ctx, opts.DirPath, ListVFSOptions{UserID: userID, Limit: limit, Offset: offset})
 	}
 
-	prefixDir := filepath.Join(s.BaseDir, "global")
-	if userID != "" {
-		prefixDir = filepath.Join(s.BaseDir, "users", userID)
+	ctx, span := startSpan(ctx, "List", "", userID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "List", UserID: userID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	if err := checkScope(userID); err != nil {
+		return nil, wrapError("list", opts.DirPath, userID, err)
 	}
 
+	prefixDir := s.prefixDir(userID)
+
 	files, err := os.ReadDir(prefixDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -318,8 +546,13 @@ func (s *Store) List(userID string, limit, offset int, dirPath string) ([]*Artif
 		return nil, err
 	}
 
+	progress := progressFrom(ctx)
 	var results []*ArtifactMetadata
-	for _, f := range files {
+	for i, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, wrapError("list", "", userID, err)
+		}
+		progress(int64(i+1), int64(len(files)))
 		if !f.IsDir() && strings.HasSuffix(f.Name(), ".json") {
 			metaData, err := os.ReadFile(filepath.Join(prefixDir, f.Name()))
 			if err != nil {
@@ -348,8 +581,25 @@ func (s *Store) List(userID string, limit, offset int, dirPath string) ([]*Artif
 	return results[offset:end], nil
 }
 
+// ListVFSOptions paginate the entries returned by ListVFS.
+type ListVFSOptions struct {
+	UserID string // User scope ("" = global)
+	Limit  int    // Maximum number of entries, unlimited if <= 0
+	Offset int    // Number of entries to skip
+}
+
 // ListVFS handles hierarchical directory listing using the in-memory index.
-func (s *Store) ListVFS(userID string, dirPath string, limit, offset int) ([]*ArtifactMetadata, error) {
+func (s *Store) ListVFS(ctx context.Context, dirPath string, opts ListVFSOptions) (_ []*ArtifactMetadata, err error) {
+	userID, limit, offset := opts.UserID, opts.Limit, opts.Offset
+	ctx, span := startSpan(ctx, "ListVFS", dirPath, userID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "List", Ref: dirPath, UserID: userID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	if err := checkScope(userID); err != nil {
+		return nil, wrapError("list", dirPath, userID, err)
+	}
+
 	uID := userID
 	if uID == "" {
 		uID = "global"
@@ -361,8 +611,8 @@ func (s *Store) ListVFS(userID string, dirPath string, limit, offset int) ([]*Ar
 	}
 
 	s.mu.RLock()
-	userIdx, ok := s.index[uID]
-	if !ok {
+	userIdx := s.index[uID]
+	if userIdx == nil && s.dirs[uID] == nil {
 		s.mu.RUnlock()
 		return []*ArtifactMetadata{}, nil
 	}
@@ -370,9 +620,9 @@ func (s *Store) ListVFS(userID string, dirPath string, limit, offset int) ([]*Ar
 	// 1. Find all artifacts starting with dir
 	// 2. Identify direct children (files) and sub-directories
 	folders := make(map[string]bool)
-	var fileIDs []string
+	var filePaths []string
 
-	for path, id := range userIdx {
+	for path := range userIdx {
 		if strings.HasPrefix(path, dir) {
 			sub := strings.TrimPrefix(path, dir)
 			if sub == "" {
@@ -381,13 +631,25 @@ func (s *Store) ListVFS(userID string, dirPath string, limit, offset int) ([]*Ar
 			parts := strings.Split(sub, "/")
 			if len(parts) == 1 {
 				// Direct file
-				fileIDs = append(fileIDs, id)
+				filePaths = append(filePaths, path)
 			} else {
 				// Sub-directory
 				folders[parts[0]] = true
 			}
 		}
 	}
+	// Explicitly created directories show up even while they are empty
+	for path := range s.dirs[uID] {
+		if sub := strings.TrimPrefix(path, dir); sub != path && sub != "" {
+			folders[strings.Split(sub, "/")[0]] = true
+		}
+	}
+	// Sort files by path, so that pages of a listing fit together
+	sort.Strings(filePaths)
+	fileIDs := make([]string, len(filePaths))
+	for i, path := range filePaths {
+		fileIDs[i] = userIdx[path]
+	}
 	s.mu.RUnlock()
 
 	var results []*ArtifactMetadata
@@ -409,10 +671,14 @@ func (s *Store) ListVFS(userID string, dirPath string, limit, offset int) ([]*Ar
 	}
 
 	// Add files
-	for _, id := range fileIDs {
-		_, meta, err := s.Read(id, userID)
+	progress := progressFrom(ctx)
+	for i, id := range fileIDs {
+		progress(int64(i+1), int64(len(fileIDs)))
+		_, meta, err := s.locate(ctx, id, userID)
 		if err == nil {
 			results = append(results, meta)
+		} else if ctx.Err() != nil {
+			return nil, wrapError("list", dirPath, userID, ctx.Err())
 		}
 	}
 
@@ -431,8 +697,22 @@ func (s *Store) ListVFS(userID string, dirPath string, limit, offset int) ([]*Ar
 	return results[offset:end], nil
 }
 
-// Find returns all artifacts matching a pattern in their virtual path.
-func (s *Store) Find(userID string, pattern string) ([]*ArtifactMetadata, error) {
+// FindOptions restrict the artifacts returned by Find.
+type FindOptions struct {
+	UserID string // User scope ("" = global)
+	Limit  int    // Maximum number of items, unlimited if <= 0
+	Offset int    // Number of items to skip
+}
+
+// Find returns the artifacts matching a pattern in their virtual path,
+// sorted by path. Only the page of opts.Offset and opts.Limit is loaded.
+func (s *Store) Find(ctx context.Context, pattern string, opts FindOptions) (_ []*ArtifactMetadata, err error) {
+	ctx, span := startSpan(ctx, "Find", pattern, opts.UserID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Find", Ref: pattern, UserID: opts.UserID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	userID := opts.UserID
 	uID := userID
 	if uID == "" {
 		uID = "global"
@@ -445,33 +725,67 @@ func (s *Store) Find(userID string, pattern string) ([]*ArtifactMetadata, error)
 		return []*ArtifactMetadata{}, nil
 	}
 
-	var matchIDs []string
-	for path, id := range userIdx {
+	var matchPaths []string
+	for path := range userIdx {
 		matched, _ := filepath.Match(pattern, path)
 		
 		// Also check as substring (ignoring wildcards for simple search)
 		cleanPattern := strings.ReplaceAll(pattern, "*", "")
 		if matched || strings.Contains(strings.ToLower(path), strings.ToLower(cleanPattern)) {
-			matchIDs = append(matchIDs, id)
+			matchPaths = append(matchPaths, path)
 		}
 	}
+	sort.Strings(matchPaths)
+
+	// Pagination
+	matchPaths = matchPaths[min(max(opts.Offset, 0), len(matchPaths)):]
+	if opts.Limit > 0 && len(matchPaths) > opts.Limit {
+		matchPaths = matchPaths[:opts.Limit]
+	}
+	matchIDs := make([]string, len(matchPaths))
+	for i, path := range matchPaths {
+		matchIDs[i] = userIdx[path]
+	}
 	s.mu.RUnlock()
 
+	progress := progressFrom(ctx)
 	var results []*ArtifactMetadata
-	for _, id := range matchIDs {
-		_, meta, err := s.Read(id, userID)
+	for i, id := range matchIDs {
+		progress(int64(i+1), int64(len(matchIDs)))
+		_, meta, err := s.locate(ctx, id, userID)
 		if err == nil {
 			results = append(results, meta)
+		} else if ctx.Err() != nil {
+			return nil, wrapError("find", pattern, userID, ctx.Err())
 		}
 	}
 	return results, nil
 }
 
-// Patch modifies an existing artifact's content.
-func (s *Store) Patch(idOrPath string, userID string, patchContent []byte, lineStart, lineEnd int, shouldAppend bool) (int64, error) {
-	oldContent, meta, err := s.Read(idOrPath, userID)
+// PatchOptions describe where Patch inserts its content.
+type PatchOptions struct {
+	UserID    string // User scope ("" = global)
+	LineStart int    // First line (0-based) to replace
+	LineEnd   int    // Line after the last one to replace; LineStart inserts
+	Append    bool   // Append to the end instead of replacing lines
+}
+
+// Patch modifies an existing artifact's content and returns its new size.
+func (s *Store) Patch(ctx context.Context, idOrPath string, patchContent []byte, opts PatchOptions) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "Patch", idOrPath, opts.UserID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Patch", Ref: idOrPath, UserID: opts.UserID, Bytes: int64(len(patchContent))}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	userID, lineStart, lineEnd, shouldAppend := opts.UserID, opts.LineStart, opts.LineEnd, opts.Append
+	contentPath, meta, err := s.locate(ctx, idOrPath, userID)
+	if err != nil {
+		return 0, wrapError("patch", idOrPath, userID, err)
+	}
+	op.Artifact = meta
+	oldContent, err := os.ReadFile(contentPath)
 	if err != nil {
-		return 0, err
+		return 0, wrapError("patch", idOrPath, userID, err)
 	}
 
 	var newContent []byte
@@ -511,26 +825,41 @@ func (s *Store) Patch(idOrPath string, userID string, patchContent []byte, lineS
 		}
 	}
 
-	// Overwrite existing file
-	prefixDir := filepath.Join(s.BaseDir, "global")
-	if userID != "" {
-		prefixDir = filepath.Join(s.BaseDir, "users", userID)
-	}
-
-	// We need to find the actual file on disk (ID_Filename)
+	// Overwrite existing file (ID_Filename)
 	storageName := fmt.Sprintf("%s_%s", meta.ID, meta.Filename)
-	fullPath := filepath.Join(prefixDir, storageName)
+	fullPath := filepath.Join(s.prefixDir(userID), storageName)
 
-	if err := os.WriteFile(fullPath, newContent, 0644); err != nil {
-		return 0, fmt.Errorf("failed to update data: %w", err)
+	if err := writeFileAtomic(ctx, fullPath, s.limitSize(bytes.NewReader(newContent))); err != nil {
+		return 0, wrapError("patch", idOrPath, userID, fmt.Errorf("failed to update data: %w", err))
 	}
+	s.publish(EventPatched, meta)
 
 	return int64(len(newContent)), nil
 }
 
+// DeleteOptions configure Delete.
+type DeleteOptions struct {
+	UserID string // User scope ("" = global)
+}
+
 // Delete removes an artifact and its associated metadata JSON file.
 // Returns true if the artifact was found and deleted, false otherwise.
-func (s *Store) Delete(idOrPath string, userID string) (bool, error) {
+func (s *Store) Delete(ctx context.Context, idOrPath string, opts DeleteOptions) (deleted bool, err error) {
+	userID := opts.UserID
+	ctx, span := startSpan(ctx, "Delete", idOrPath, userID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Delete", Ref: idOrPath, UserID: userID}
+	defer func() {
+		opErr := err
+		if !deleted && err == nil {
+			opErr = newError("delete", idOrPath, userID, ErrNotFound, "")
+		}
+		s.finish(ctx, &op, opErr)
+	}()
+
+	if err := checkScope(userID); err != nil {
+		return false, wrapError("delete", idOrPath, userID, err)
+	}
 	uID := userID
 	if uID == "" {
 		uID = "global"
@@ -552,17 +881,20 @@ func (s *Store) Delete(idOrPath string, userID string) (bool, error) {
 		s.mu.RUnlock()
 	}
 
-	prefixDir := filepath.Join(s.BaseDir, "global")
-	if userID != "" {
-		prefixDir = filepath.Join(s.BaseDir, "users", userID)
-	}
+	prefixDir := s.prefixDir(userID)
 
 	files, err := os.ReadDir(prefixDir)
 	if err != nil {
-		return false, err
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, wrapError("delete", idOrPath, userID, err)
 	}
 
 	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return false, wrapError("delete", idOrPath, userID, err)
+		}
 		if f.IsDir() || strings.HasSuffix(f.Name(), ".json.json") {
 			continue
 		}
@@ -583,13 +915,12 @@ func (s *Store) Delete(idOrPath string, userID string) (bool, error) {
 			fullPath := filepath.Join(prefixDir, f.Name())
 			metaPath := fullPath + ".json"
 
-			// If we didn't have the path yet (looked up by ID), try to find it in meta
-			if vPath == "" {
-				if metaData, err := os.ReadFile(metaPath); err == nil {
-					var meta ArtifactMetadata
-					if err := json.Unmarshal(metaData, &meta); err == nil {
-						vPath = meta.VirtualPath
-					}
+			// Load the metadata for the event and, if we didn't have the path
+			// yet (looked up by ID), to find it in the index
+			var meta *ArtifactMetadata
+			if metaData, err := os.ReadFile(metaPath); err == nil {
+				if err := json.Unmarshal(metaData, &meta); err == nil && vPath == "" {
+					vPath = meta.VirtualPath
 				}
 			}
 
@@ -605,6 +936,10 @@ func (s *Store) Delete(idOrPath string, userID string) (bool, error) {
 				s.mu.Unlock()
 			}
 
+			if meta != nil {
+				op.Artifact = meta
+				s.publish(EventDeleted, meta)
+			}
 			return true, nil
 		}
 	}
@@ -613,9 +948,20 @@ func (s *Store) Delete(idOrPath string, userID string) (bool, error) {
 }
 
 // Cleanup performs a recursive walk of the BaseDir and removes any artifacts
-// whose expiration time (ExpiresAt) has passed.
-func (s *Store) Cleanup() {
-	_ = filepath.Walk(s.BaseDir, func(path string, info os.FileInfo, err error) error {
+// whose expiration time (ExpiresAt) has passed. Watchers receive an
+// EventExpired for each of them. The walk stops early if ctx is cancelled;
+// the cleanup hooks still receive the statistics of the partial run.
+func (s *Store) Cleanup(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "Cleanup", "", "")
+	defer func() { endSpan(span, err) }()
+
+	start := time.Now()
+	var stats CleanupStats
+
+	err = filepath.Walk(s.BaseDir, func(path string, info os.FileInfo, err error) error {
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
 		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".json") {
 			return nil
 		}
@@ -626,14 +972,48 @@ func (s *Store) Cleanup() {
 		}
 
 		var meta ArtifactMetadata
-		if err := json.Unmarshal(data, &meta); err == nil {
+		if err := json.Unmarshal(data, &meta); err == nil && isMetaFile(path, &meta) {
 			if time.Now().After(meta.ExpiresAt) {
-				_ = os.Remove(strings.TrimSuffix(path, ".json"))
+				contentPath := strings.TrimSuffix(path, ".json")
+				if info, err := os.Stat(contentPath); err == nil {
+					stats.Bytes += info.Size()
+				}
+				_ = os.Remove(contentPath)
 				_ = os.Remove(path)
+				stats.Removed++
+
+				if meta.VirtualPath != "" {
+					s.mu.Lock()
+					if userIdx, ok := s.index[scopeKey(meta.UserID)]; ok && userIdx[meta.VirtualPath] == meta.ID {
+						delete(userIdx, meta.VirtualPath)
+					}
+					s.mu.Unlock()
+				}
+				s.publish(EventExpired, &meta)
 			}
 		}
 		return nil
 	})
+	if err != nil && !os.IsNotExist(err) {
+		err = wrapError("cleanup", "", "", err)
+	} else {
+		err = nil
+	}
+
+	stats.Duration = time.Since(start)
+	span.SetAttributes(attribute.Int("cleanup.removed", stats.Removed))
+	s.hooksMu.RLock()
+	defer s.hooksMu.RUnlock()
+	for _, fn := range s.cleanupHooks {
+		fn(stats)
+	}
+	return err
+}
+
+// isMetaFile reports whether path is the metadata file of meta. Artifacts may
+// be JSON themselves, so parsing alone does not identify a metadata file.
+func isMetaFile(path string, meta *ArtifactMetadata) bool {
+	return meta.ID != "" && filepath.Base(path) == meta.ID+"_"+meta.Filename+".json"
 }
 
 // DetectMimeType returns a MIME type string based on the file extension.
@@ -665,3 +1045,21 @@ func DetectMimeType(filename string) string {
 		return "application/octet-stream"
 	}
 }
+
+// IsPassiveMimeType reports whether browsers can display content of
+// mimeType without running script: plain text, JSON, PDF and images other
+// than SVG. Artifacts of any other type, such as HTML, must only be offered
+// as downloads, or they run script on the origin serving them.
+func IsPassiveMimeType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "text/plain", "application/json", "application/pdf":
+		return true
+	case "image/svg+xml":
+		return false
+	}
+	return strings.HasPrefix(mediaType, "image/")
+}
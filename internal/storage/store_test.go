@@ -1,10 +1,13 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,7 +27,7 @@ func TestStore_WriteRead(t *testing.T) {
 	metadata := map[string]interface{}{"key": "value"}
 
 	// Test Write
-	meta, err := store.Write(filename, content, mimeType, 1, source, userID, "test description", metadata, "")
+	meta, err := store.Write(t.Context(), filename, content, WriteOptions{MimeType: mimeType, ExpiresHours: 1, Source: source, UserID: userID, Description: "test description", Metadata: metadata})
 	require.NoError(t, err)
 	assert.NotEmpty(t, meta.ID)
 	assert.Equal(t, filename, meta.Filename)
@@ -32,7 +35,7 @@ func TestStore_WriteRead(t *testing.T) {
 	assert.Equal(t, userID, meta.UserID)
 
 	// Test Read by ID
-	readData, readMeta, err := store.Read(meta.ID, userID)
+	readData, readMeta, err := store.Read(t.Context(), meta.ID, ReadOptions{UserID: userID})
 	require.NoError(t, err)
 	assert.Equal(t, content, readData)
 	assert.Equal(t, meta.ID, readMeta.ID)
@@ -40,11 +43,11 @@ func TestStore_WriteRead(t *testing.T) {
 	assert.Equal(t, metadata["key"], readMeta.Metadata["key"])
 
 	// Test Read by ID (wrong user should fail)
-	_, _, err = store.Read(meta.ID, "wrong-user")
+	_, _, err = store.Read(t.Context(), meta.ID, ReadOptions{UserID: "wrong-user"})
 	assert.Error(t, err)
 
 	// Test Read by Filename (prefix)
-	readData2, _, err := store.Read(meta.Filename, userID)
+	readData2, _, err := store.Read(t.Context(), meta.Filename, ReadOptions{UserID: userID})
 	require.NoError(t, err)
 	assert.Equal(t, content, readData2)
 }
@@ -57,36 +60,36 @@ func TestStore_List(t *testing.T) {
 	store := NewStore(tempDir)
 	userID := "user456"
 
-	_, _ = store.Write("file1.txt", []byte("1"), "", 1, "src", userID, "", nil, "")
-	_, _ = store.Write("file2.txt", []byte("2"), "", 1, "src", userID, "", nil, "")
-	_, _ = store.Write("global.txt", []byte("3"), "", 1, "src", "", "", nil, "")
+	_, _ = store.Write(t.Context(), "file1.txt", []byte("1"), WriteOptions{ExpiresHours: 1, Source: "src", UserID: userID})
+	_, _ = store.Write(t.Context(), "file2.txt", []byte("2"), WriteOptions{ExpiresHours: 1, Source: "src", UserID: userID})
+	_, _ = store.Write(t.Context(), "global.txt", []byte("3"), WriteOptions{ExpiresHours: 1, Source: "src"})
 
 	// List user artifacts
-	items, err := store.List(userID, 100, 0, "")
+	items, err := store.List(t.Context(), ListOptions{UserID: userID, Limit: 100})
 	require.NoError(t, err)
 	assert.Len(t, items, 2)
 
 	// List global artifacts
-	globalItems, err := store.List("", 100, 0, "")
+	globalItems, err := store.List(t.Context(), ListOptions{Limit: 100})
 	require.NoError(t, err)
 	assert.Len(t, globalItems, 1)
 
 	// Test Pagination
 	// Create more global artifacts
 	for i := 0; i < 5; i++ {
-		_, _ = store.Write(fmt.Sprintf("paginated-%d.txt", i), []byte("test"), "", 1, "src", "", "", nil, "")
+		_, _ = store.Write(t.Context(), fmt.Sprintf("paginated-%d.txt", i), []byte("test"), WriteOptions{ExpiresHours: 1, Source: "src"})
 	}
 
-	pItems, err := store.List("", 2, 0, "") // limit 2, offset 0
+	pItems, err := store.List(t.Context(), ListOptions{Limit: 2}) // limit 2, offset 0
 	require.NoError(t, err)
 	assert.Len(t, pItems, 2)
 
-	pItems2, err := store.List("", 2, 2, "") // limit 2, offset 2
+	pItems2, err := store.List(t.Context(), ListOptions{Limit: 2, Offset: 2}) // limit 2, offset 2
 	require.NoError(t, err)
 	assert.Len(t, pItems2, 2)
 	assert.NotEqual(t, pItems[0].ID, pItems2[0].ID)
 
-	pItems3, err := store.List("", 10, 5, "") // limit 10, offset 5
+	pItems3, err := store.List(t.Context(), ListOptions{Limit: 10, Offset: 5}) // limit 10, offset 5
 	require.NoError(t, err)
 	assert.Len(t, pItems3, 1) // 6 global files total, offset 5 -> only 1 left
 }
@@ -102,24 +105,24 @@ func TestStore_VFS(t *testing.T) {
 	// 1. Write with virtual path
 	path := "/projects/alpha/readme.md"
 	content := []byte("# Project Alpha")
-	meta, err := store.Write("readme.md", content, "text/markdown", 1, "test", userID, "", nil, path)
+	meta, err := store.Write(t.Context(), "readme.md", content, WriteOptions{MimeType: "text/markdown", ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: path})
 	require.NoError(t, err)
 	assert.Equal(t, path, meta.VirtualPath)
 
 	// 2. Read by virtual path
-	readData, readMeta, err := store.Read(path, userID)
+	readData, readMeta, err := store.Read(t.Context(), path, ReadOptions{UserID: userID})
 	require.NoError(t, err)
 	assert.Equal(t, content, readData)
 	assert.Equal(t, path, readMeta.VirtualPath)
 
 	// 3. List VFS (root)
 	// Add another file in different folder
-	_, _ = store.Write("other.txt", []byte("other"), "", 1, "test", userID, "", nil, "/docs/manual.txt")
+	_, _ = store.Write(t.Context(), "other.txt", []byte("other"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: "/docs/manual.txt"})
 	// Add another file in same folder
-	_, _ = store.Write("todo.md", []byte("todo"), "", 1, "test", userID, "", nil, "/projects/alpha/todo.md")
+	_, _ = store.Write(t.Context(), "todo.md", []byte("todo"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: "/projects/alpha/todo.md"})
 
 	// List /
-	rootItems, err := store.List(userID, 100, 0, "/")
+	rootItems, err := store.List(t.Context(), ListOptions{UserID: userID, DirPath: "/", Limit: 100})
 	require.NoError(t, err)
 	// Should contain "projects" and "docs" folders
 	assert.Equal(t, 2, len(rootItems))
@@ -138,42 +141,47 @@ func TestStore_VFS(t *testing.T) {
 	assert.True(t, foundDocs, "docs folder should be found in /")
 
 	// List /projects/alpha/
-	alphaItems, err := store.List(userID, 100, 0, "/projects/alpha")
+	alphaItems, err := store.List(t.Context(), ListOptions{UserID: userID, DirPath: "/projects/alpha", Limit: 100})
 	require.NoError(t, err)
 	// Should contain "readme.md" and "todo.md" files
 	assert.Len(t, alphaItems, 2)
 	assert.NotEqual(t, "directory", alphaItems[0].MimeType)
 
 	// 4. Find
-	findItems, err := store.Find(userID, "*readme*")
+	findItems, err := store.Find(t.Context(), "*readme*", FindOptions{UserID: userID})
 	require.NoError(t, err)
 	assert.Len(t, findItems, 1)
 	assert.Equal(t, "readme.md", findItems[0].Filename)
 
+	findItems, err = store.Find(t.Context(), "/projects/alpha/*", FindOptions{UserID: userID, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, findItems, 1)
+	assert.Equal(t, "todo.md", findItems[0].Filename)
+
 	// 5. Patch (Append)
-	newSize, err := store.Patch(path, userID, []byte("\n- Task 1"), 0, 0, true)
+	newSize, err := store.Patch(t.Context(), path, []byte("\n- Task 1"), PatchOptions{UserID: userID, Append: true})
 	require.NoError(t, err)
 	assert.Greater(t, newSize, int64(len(content)))
 
-	patchedData, _, _ := store.Read(path, userID)
+	patchedData, _, _ := store.Read(t.Context(), path, ReadOptions{UserID: userID})
 	assert.Contains(t, string(patchedData), "- Task 1")
 
 	// 6. Patch (Line replacement)
 	// Original: "# Project Alpha\n- Task 1"
 	// Replace line 0 (index 0)
-	_, err = store.Patch(path, userID, []byte("# NEW TITLE"), 0, 1, false)
+	_, err = store.Patch(t.Context(), path, []byte("# NEW TITLE"), PatchOptions{UserID: userID, LineEnd: 1})
 	require.NoError(t, err)
-	patchedData2, _, _ := store.Read(path, userID)
+	patchedData2, _, _ := store.Read(t.Context(), path, ReadOptions{UserID: userID})
 	assert.True(t, strings.HasPrefix(string(patchedData2), "# NEW TITLE"))
 	assert.Contains(t, string(patchedData2), "- Task 1")
 
 	// 7. Delete by path
-	deleted, err := store.Delete(path, userID)
+	deleted, err := store.Delete(t.Context(), path, DeleteOptions{UserID: userID})
 	require.NoError(t, err)
 	assert.True(t, deleted)
 
 	// Verify gone from index
-	_, _, err = store.Read(path, userID)
+	_, _, err = store.Read(t.Context(), path, ReadOptions{UserID: userID})
 	assert.Error(t, err)
 }
 
@@ -185,14 +193,14 @@ func TestStore_IndexRebuild(t *testing.T) {
 	// 1. Create a store and write a file
 	store1 := NewStore(tempDir)
 	path := "/persistent/file.txt"
-	_, err = store1.Write("file.txt", []byte("data"), "", 1, "test", "user1", "", nil, path)
+	_, err = store1.Write(t.Context(), "file.txt", []byte("data"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "user1", VirtualPath: path})
 	require.NoError(t, err)
 
 	// 2. Create a new store instance pointing to same dir
 	store2 := NewStore(tempDir)
 	
 	// 3. Verify it found the file in index
-	_, _, err = store2.Read(path, "user1")
+	_, _, err = store2.Read(t.Context(), path, ReadOptions{UserID: "user1"})
 	require.NoError(t, err, "New store instance should rebuild index from disk")
 }
 
@@ -201,3 +209,286 @@ func TestDetectMimeType(t *testing.T) {
 	assert.Equal(t, "image/svg+xml", DetectMimeType("logo.svg"))
 	assert.Equal(t, "application/octet-stream", DetectMimeType("random.dat"))
 }
+
+func TestIsPassiveMimeType(t *testing.T) {
+	for _, mimeType := range []string{"text/plain; charset=utf-8", "application/json", "application/pdf", "image/png"} {
+		assert.True(t, IsPassiveMimeType(mimeType), mimeType)
+	}
+	for _, mimeType := range []string{"text/html", "image/svg+xml", "application/javascript", "text/xml", "", "text/html;;"} {
+		assert.False(t, IsPassiveMimeType(mimeType), mimeType)
+	}
+}
+
+func TestArtifactURI(t *testing.T) {
+	for _, tc := range []struct {
+		meta       ArtifactMetadata
+		uri, vpath string
+	}{
+		{ArtifactMetadata{ID: "abc"}, "artifact:///abc", "/abc"},
+		{ArtifactMetadata{ID: "abc", UserID: "u1", VirtualPath: "/docs/plan.md"}, "artifact://u1/docs/plan.md", "/docs/plan.md"},
+		{ArtifactMetadata{ID: "abc", UserID: "a@b:c", VirtualPath: "/my docs/50%.md"}, "artifact://a%40b%3Ac/my%20docs/50%25.md", "/my docs/50%.md"},
+	} {
+		assert.Equal(t, tc.uri, tc.meta.URI())
+		userID, path, err := ParseURI(tc.uri)
+		require.NoError(t, err)
+		assert.Equal(t, tc.meta.UserID, userID)
+		assert.Equal(t, tc.vpath, path)
+	}
+
+	for _, uri := range []string{"file:///etc/passwd", "artifact://u1", "artifact://../x", "artifact://u1/%zz"} {
+		_, _, err := ParseURI(uri)
+		assert.ErrorIs(t, err, ErrInvalidPath, uri)
+	}
+}
+
+func TestStore_StreamReplace(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-stream-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := NewStore(tempDir)
+
+	meta, err := store.WriteStream(t.Context(), "big.log", strings.NewReader("line 1\n"), WriteOptions{ExpiresHours: 1, Source: "src", VirtualPath: "/logs/big.log"})
+	require.NoError(t, err)
+
+	f, openMeta, err := store.Open(t.Context(), "/logs/big.log", OpenOptions{})
+	require.NoError(t, err)
+	data, _ := io.ReadAll(f)
+	f.Close()
+	assert.Equal(t, "line 1\n", string(data))
+	assert.Equal(t, meta.ID, openMeta.ID)
+
+	replaced, err := store.Replace(t.Context(), meta.ID, strings.NewReader("line 2\n"), ReplaceOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, meta.ID, replaced.ID)
+
+	data, _, err = store.Read(t.Context(), "/logs/big.log", ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "line 2\n", string(data))
+
+	_, err = store.Replace(t.Context(), "missing", strings.NewReader("x"), ReplaceOptions{})
+	assert.Error(t, err)
+}
+
+func TestStore_SetExpiry(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-expiry-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := NewStore(tempDir)
+	meta, err := store.Write(t.Context(), "ttl.txt", []byte("x"), WriteOptions{ExpiresHours: 1, Source: "src", UserID: "u1", VirtualPath: "/ttl.txt"})
+	require.NoError(t, err)
+
+	updated, err := store.SetExpiry(t.Context(), "/ttl.txt", 72, SetExpiryOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.True(t, updated.ExpiresAt.After(meta.ExpiresAt.Add(70*time.Hour)))
+
+	_, readMeta, err := store.Read(t.Context(), meta.ID, ReadOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.WithinDuration(t, updated.ExpiresAt, readMeta.ExpiresAt, time.Second)
+
+	_, err = store.SetExpiry(t.Context(), "missing", 1, SetExpiryOptions{UserID: "u1"})
+	assert.Error(t, err)
+}
+
+func TestStore_MoveMkdirDeleteTree(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := NewStore(tempDir)
+	_, err = store.Write(t.Context(), "a.md", []byte("a"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "u1", VirtualPath: "/docs/a.md"})
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "b.txt", []byte("b"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "u1", VirtualPath: "/docs/sub/b.txt"})
+	require.NoError(t, err)
+
+	// Mkdir creates an empty directory that shows up in listings
+	require.NoError(t, store.Mkdir("u1", "/empty"))
+	assert.True(t, store.IsDir("u1", "/empty"))
+	assert.False(t, store.IsDir("u2", "/empty"))
+	items, err := store.ListVFS(t.Context(), "/", ListVFSOptions{UserID: "u1"})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "/docs", items[0].VirtualPath)
+	assert.Equal(t, "/empty", items[1].VirtualPath)
+	assert.Error(t, store.Mkdir("u1", "/docs/a.md"))
+
+	// Move a single file renames it on disk and keeps the ID
+	_, before, err := store.Read(t.Context(), "/docs/a.md", ReadOptions{UserID: "u1"})
+	require.NoError(t, err)
+	require.NoError(t, store.Move(t.Context(), "/docs/a.md", "/docs/renamed.txt", MoveOptions{UserID: "u1"}))
+	data, meta, err := store.Read(t.Context(), "/docs/renamed.txt", ReadOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), data)
+	assert.Equal(t, before.ID, meta.ID)
+	assert.Equal(t, "renamed.txt", meta.Filename)
+	assert.Equal(t, "text/plain", meta.MimeType)
+	_, _, err = store.Read(t.Context(), "/docs/a.md", ReadOptions{UserID: "u1"})
+	assert.Error(t, err)
+
+	// Move a directory moves everything below it, also after a restart
+	require.NoError(t, store.Move(t.Context(), "/docs", "/archive/docs", MoveOptions{UserID: "u1"}))
+	assert.Error(t, store.Move(t.Context(), "/archive", "/archive/docs/nested", MoveOptions{UserID: "u1"}))
+	store2 := NewStore(tempDir)
+	_, _, err = store2.Read(t.Context(), "/archive/docs/sub/b.txt", ReadOptions{UserID: "u1"})
+	require.NoError(t, err)
+	_, _, err = store2.Read(t.Context(), "/archive/docs/renamed.txt", ReadOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.False(t, store2.IsDir("u1", "/docs"))
+
+	// Moving onto an existing path fails
+	require.NoError(t, store.Mkdir("u1", "/taken"))
+	assert.Error(t, store.Move(t.Context(), "/archive", "/taken", MoveOptions{UserID: "u1"}))
+
+	// DeleteTree removes the whole subtree
+	n, err := store.DeleteTree(t.Context(), "/archive", DeleteTreeOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.False(t, store.IsDir("u1", "/archive"))
+	list, err := store.List(t.Context(), ListOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestStore_CompletePath(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for _, path := range []string{"/projects/alpha/plan.md", "/projects/alpha/src/main.go", "/projects/beta.md", "/notes.txt"} {
+		_, err := store.Write(t.Context(), "f", []byte("x"), WriteOptions{ExpiresHours: 1, UserID: "u1", VirtualPath: path})
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Mkdir("u1", "/projects/gamma"))
+
+	assert.Equal(t, []string{"/notes.txt", "/projects/"}, store.CompletePath("u1", ""))
+	assert.Equal(t, []string{"/projects/"}, store.CompletePath("u1", "/pro"))
+	assert.Equal(t, []string{"/projects/alpha/", "/projects/beta.md", "/projects/gamma/"}, store.CompletePath("u1", "/projects/"))
+	assert.Equal(t, []string{"/projects/alpha/plan.md", "/projects/alpha/src/"}, store.CompletePath("u1", "projects/alpha/"))
+	assert.Empty(t, store.CompletePath("u1", "/projects/delta"))
+	assert.Empty(t, store.CompletePath("u2", "/"))
+}
+
+func TestStore_Progress(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for _, path := range []string{"/a/1.txt", "/a/2.txt", "/a/3.txt"} {
+		_, err := store.Write(t.Context(), "f.txt", []byte(strings.Repeat("x", 1<<20+1)), WriteOptions{ExpiresHours: 1, UserID: "u1", VirtualPath: path})
+		require.NoError(t, err)
+	}
+
+	var reports [][2]int64
+	ctx := WithProgress(t.Context(), func(done, total int64) { reports = append(reports, [2]int64{done, total}) })
+	_, _, err := store.Read(ctx, "/a/1.txt", ReadOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int64{{1 << 20, 1<<20 + 1}, {1<<20 + 1, 1<<20 + 1}}, reports)
+
+	reports = nil
+	_, err = store.Find(ctx, "/a/*", FindOptions{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int64{{1, 3}, {2, 3}, {3, 3}}, reports)
+
+	// Cancelling from the callback stops the operation
+	cctx, cancel := context.WithCancel(t.Context())
+	n, err := store.DeleteTree(WithProgress(cctx, func(done, total int64) {
+		if done == 1 {
+			cancel()
+		}
+	}), "/a", DeleteTreeOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, n)
+}
+
+func TestStore_TypedErrors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "artifact-errors-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	store := NewStore(tempDir)
+	_, err = store.Write(t.Context(), "a.md", []byte("# A"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "u1", VirtualPath: "/docs/a.md"})
+	require.NoError(t, err)
+
+	// Unknown artifact and a scope nothing was ever written to
+	_, _, err = store.Read(t.Context(), "/docs/missing.md", ReadOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	var se *Error
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, "read", se.Op)
+	assert.Equal(t, "/docs/missing.md", se.Path)
+	assert.Equal(t, ReasonNotFound, Reason(err))
+
+	_, err = store.Patch(t.Context(), "a.md", []byte("x"), PatchOptions{UserID: "nobody", Append: true})
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, "patch", se.Op)
+
+	_, err = store.Write(t.Context(), "x.txt", []byte("x"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "../escape"})
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	_, err = store.Write(t.Context(), "", []byte("x"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "u1"})
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	_, err = store.Write(t.Context(), "x.txt", []byte("x"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "u1", Description: "\xff"})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	_, err = store.Write(t.Context(), "b.md", []byte("# B"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: "u1", VirtualPath: "/docs/b.md"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, store.Move(t.Context(), "/docs/a.md", "/docs/b.md", MoveOptions{UserID: "u1"}), ErrAlreadyExists)
+	assert.ErrorIs(t, store.Move(t.Context(), "/docs", "/docs/sub", MoveOptions{UserID: "u1"}), ErrInvalidPath)
+	assert.ErrorIs(t, store.Mkdir("u1", "/docs/a.md"), ErrConflict)
+
+	assert.Equal(t, ReasonInternal, Reason(fmt.Errorf("disk on fire")))
+}
+
+func TestStore_Cancellation(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for i := 0; i < 3; i++ {
+		_, err := store.Write(t.Context(), fmt.Sprintf("f%d.txt", i), []byte("x"), WriteOptions{ExpiresHours: 1, UserID: "u1", VirtualPath: fmt.Sprintf("/d/f%d.txt", i)})
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := store.List(ctx, ListOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = store.Find(ctx, "f", FindOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = store.Read(ctx, "f1.txt", ReadOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, store.Cleanup(ctx), context.Canceled)
+	_, err = store.Usage(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// A cancelled upload leaves nothing behind
+	_, err = store.WriteStream(ctx, "big.bin", strings.NewReader("data"), WriteOptions{UserID: "u1", VirtualPath: "/big.bin"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, store.IsDir("u1", "/big.bin"))
+	_, _, err = store.Read(t.Context(), "/big.bin", ReadOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	n, err := store.DeleteTree(ctx, "/d", DeleteTreeOptions{UserID: "u1"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, n)
+	items, err := store.List(t.Context(), ListOptions{UserID: "u1", DirPath: "/d"})
+	require.NoError(t, err)
+	assert.Len(t, items, 3)
+}
+
+func TestStore_MaxArtifactSize(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.SetMaxArtifactSize(4)
+
+	_, err := store.Write(t.Context(), "ok.txt", []byte("1234"), WriteOptions{VirtualPath: "/ok.txt"})
+	require.NoError(t, err)
+
+	_, err = store.Write(t.Context(), "big.txt", []byte("12345"), WriteOptions{VirtualPath: "/big.txt"})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.Equal(t, ReasonQuotaExceeded, Reason(err))
+	assert.False(t, store.IsDir("", "/big.txt"))
+	items, err := store.List(t.Context(), ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	_, err = store.Replace(t.Context(), "/ok.txt", strings.NewReader("123456"), ReplaceOptions{})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	_, err = store.Patch(t.Context(), "/ok.txt", []byte("5"), PatchOptions{Append: true})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	data, _, err := store.Read(t.Context(), "/ok.txt", ReadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "1234", string(data))
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer returns the tracer of store operations from the global provider,
+// which is a no-op unless tracing has been set up.
+func tracer() trace.Tracer {
+	return otel.Tracer("github.com/hmsoft0815/mlcartifact/internal/storage")
+}
+
+// startSpan starts the span "storage.<op>" for an operation on ref (an ID,
+// filename, virtual path or pattern) in the given user scope.
+func startSpan(ctx context.Context, op, ref, userID string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "storage."+op, trace.WithAttributes(
+		attribute.String("artifact.ref", ref),
+		attribute.String("artifact.user_id", userID)))
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ctxReader fails reads once its context is done, so that copying a large
+// upload stops when the caller goes away.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URIScheme is the scheme of artifact URIs:
+//
+//	artifact://{user}/{path}
+//
+// The host is the user scope, empty for the global scope, and the path the
+// virtual path of the artifact, or its ID if it has none. The MCP server
+// resolves these URIs as resources.
+const URIScheme = "artifact"
+
+// hostEscaper escapes the characters that url.PathEscape keeps but that
+// would end or split the host of a URI.
+var hostEscaper = strings.NewReplacer("@", "%40", ":", "%3A")
+
+// URI returns the artifact URI of meta.
+func (m *ArtifactMetadata) URI() string {
+	if m.VirtualPath == "" {
+		return URI(m.UserID, "/"+m.ID)
+	}
+	return URI(m.UserID, m.VirtualPath)
+}
+
+// URI returns the artifact URI of an absolute path in a user scope.
+func URI(userID, path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return URIScheme + "://" + hostEscaper.Replace(url.PathEscape(userID)) + "/" + strings.Join(segments, "/")
+}
+
+// ParseURI splits an artifact URI into the user scope and the absolute path
+// after the host, which is a virtual path or "/" followed by an artifact ID.
+func ParseURI(uri string) (userID, path string, err error) {
+	rest, ok := strings.CutPrefix(uri, URIScheme+"://")
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q is not an %s:// URI", ErrInvalidPath, uri, URIScheme)
+	}
+	host, p, _ := strings.Cut(rest, "/")
+	if userID, err = url.PathUnescape(host); err != nil {
+		return "", "", fmt.Errorf("%w: %q: %v", ErrInvalidPath, uri, err)
+	}
+	if path, err = url.PathUnescape(p); err != nil {
+		return "", "", fmt.Errorf("%w: %q: %v", ErrInvalidPath, uri, err)
+	}
+	if path == "" {
+		return "", "", fmt.Errorf("%w: %q names no artifact", ErrInvalidPath, uri)
+	}
+	if err := checkScope(userID); err != nil {
+		return "", "", err
+	}
+	return userID, NormalizePath("/" + path), nil
+}
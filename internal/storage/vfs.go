@@ -0,0 +1,344 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StatOptions configure Stat.
+type StatOptions struct {
+	UserID string // User scope ("" = global)
+}
+
+// Stat returns the on-disk file information and the metadata of an artifact
+// without reading its content.
+func (s *Store) Stat(ctx context.Context, idOrPath string, opts StatOptions) (_ os.FileInfo, _ *ArtifactMetadata, err error) {
+	userID := opts.UserID
+	ctx, span := startSpan(ctx, "Stat", idOrPath, userID)
+	defer func() { endSpan(span, err) }()
+
+	fullPath, meta, err := s.locate(ctx, idOrPath, userID)
+	if err != nil {
+		return nil, nil, wrapError("stat", idOrPath, userID, err)
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, nil, wrapError("stat", idOrPath, userID, err)
+	}
+	return info, meta, nil
+}
+
+// IsDir reports whether dirPath is a directory in the user's virtual file
+// system, either because artifacts live below it or because it was created
+// with Mkdir. The root directory always exists.
+func (s *Store) IsDir(userID string, dirPath string) bool {
+	dir := NormalizePath(dirPath)
+	if dir == "/" {
+		return true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	uID := scopeKey(userID)
+	prefix := dir + "/"
+	for path := range s.dirs[uID] {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	for path := range s.index[uID] {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompletePath returns the virtual paths of a user scope that complete
+// prefix, sorted: the files and directories in the directory prefix ends in
+// whose names start with the rest of prefix. Directories end with "/", so
+// that completing them again lists their children.
+func (s *Store) CompletePath(userID string, prefix string) []string {
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	uID := scopeKey(userID)
+	found := make(map[string]bool)
+	add := func(path string, isDir bool) {
+		if !strings.HasPrefix(path, prefix) {
+			return
+		}
+		name, _, below := strings.Cut(path[len(dir):], "/")
+		if name == "" {
+			return
+		}
+		if below || isDir {
+			found[dir+name+"/"] = true
+		} else {
+			found[dir+name] = true
+		}
+	}
+	for path := range s.index[uID] {
+		add(path, false)
+	}
+	for path := range s.dirs[uID] {
+		add(path, true)
+	}
+
+	paths := make([]string, 0, len(found))
+	for path := range found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Mkdir creates an empty virtual directory. Virtual directories only exist
+// through the artifacts below them, so a directory created this way is kept
+// in memory until an artifact is written into it or the server restarts.
+func (s *Store) Mkdir(userID string, dirPath string) error {
+	dir := NormalizePath(dirPath)
+	if dir == "/" {
+		return newError("mkdir", dir, userID, ErrAlreadyExists, "directory already exists")
+	}
+	if err := checkScope(userID); err != nil {
+		return wrapError("mkdir", dir, userID, err)
+	}
+
+	uID := scopeKey(userID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.index[uID][dir]; exists {
+		return newError("mkdir", dir, userID, ErrConflict, "an artifact exists at this path")
+	}
+	if s.dirs[uID] == nil {
+		s.dirs[uID] = make(map[string]bool)
+	}
+	s.dirs[uID][dir] = true
+	return nil
+}
+
+// MoveOptions configure Move.
+type MoveOptions struct {
+	UserID string // User scope ("" = global)
+}
+
+// Move renames the artifact or virtual directory at oldPath to newPath.
+// Moving a directory moves every artifact below it. The storage file of a
+// moved artifact is renamed to the new base name, while its ID is kept.
+// Move fails if newPath is already taken. If ctx is cancelled while moving a
+// directory, the artifacts moved so far stay at their new path.
+func (s *Store) Move(ctx context.Context, oldPath string, newPath string, opts MoveOptions) (err error) {
+	userID := opts.UserID
+	ctx, span := startSpan(ctx, "Move", oldPath, userID)
+	defer func() { endSpan(span, err) }()
+	op := Operation{Name: "Move", Ref: oldPath, UserID: userID}
+	defer func() { s.finish(ctx, &op, err) }()
+
+	from := NormalizePath(oldPath)
+	to := NormalizePath(newPath)
+	if from == "/" || to == "/" {
+		return newError("move", oldPath, userID, ErrInvalidPath, "cannot move the root directory")
+	}
+	if from == to {
+		return nil
+	}
+	if strings.HasPrefix(to, from+"/") {
+		return newError("move", oldPath, userID, ErrInvalidPath, "cannot move a directory into itself")
+	}
+
+	uID := scopeKey(userID)
+
+	// Collect the artifacts to move: the file itself or the directory contents.
+	s.mu.RLock()
+	moves := make(map[string]string) // old virtual path -> new virtual path
+	if _, ok := s.index[uID][from]; ok {
+		moves[from] = to
+	} else {
+		for path := range s.index[uID] {
+			if strings.HasPrefix(path, from+"/") {
+				moves[path] = to + strings.TrimPrefix(path, from)
+			}
+		}
+	}
+	var dirs []string
+	for path := range s.dirs[uID] {
+		if path == from || strings.HasPrefix(path, from+"/") {
+			dirs = append(dirs, path)
+		}
+	}
+	_, taken := s.index[uID][to]
+	s.mu.RUnlock()
+
+	if len(moves) == 0 && len(dirs) == 0 {
+		return newError("move", oldPath, userID, ErrNotFound, "")
+	}
+	if taken || s.IsDir(userID, to) {
+		return newError("move", newPath, userID, ErrAlreadyExists, "")
+	}
+
+	// Move in a stable order so failures are reproducible.
+	paths := make([]string, 0, len(moves))
+	for path := range moves {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return wrapError("move", path, userID, err)
+		}
+		meta, err := s.moveArtifact(ctx, userID, path, moves[path])
+		if err != nil {
+			return wrapError("move", path, userID, err)
+		}
+		if path == from {
+			op.Artifact = meta
+		}
+	}
+
+	s.mu.Lock()
+	for _, path := range dirs {
+		delete(s.dirs[uID], path)
+		s.dirs[uID][to+strings.TrimPrefix(path, from)] = true
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// moveArtifact gives a single artifact a new virtual path and filename and
+// returns its updated metadata.
+func (s *Store) moveArtifact(ctx context.Context, userID string, from string, to string) (*ArtifactMetadata, error) {
+	fullPath, meta, err := s.locate(ctx, from, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	newFilename := filepath.Base(to)
+	newPath := filepath.Join(filepath.Dir(fullPath), fmt.Sprintf("%s_%s", meta.ID, newFilename))
+	if newPath != fullPath {
+		if err := os.Rename(fullPath, newPath); err != nil {
+			return nil, fmt.Errorf("failed to rename data: %w", err)
+		}
+	}
+
+	old := *meta
+
+	// Keep explicitly provided MIME types, re-detect the ones derived from the name.
+	if meta.MimeType == DetectMimeType(meta.Filename) {
+		meta.MimeType = DetectMimeType(newFilename)
+	}
+	meta.Filename = newFilename
+	meta.VirtualPath = to
+
+	metaBytes, _ := json.MarshalIndent(meta, "", "  ")
+	if err := os.WriteFile(newPath+".json", metaBytes, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	}
+	if newPath != fullPath {
+		_ = os.Remove(fullPath + ".json")
+	}
+
+	uID := scopeKey(userID)
+	s.mu.Lock()
+	delete(s.index[uID], from)
+	s.index[uID][to] = meta.ID
+	s.mu.Unlock()
+
+	// Watchers see a move as the old path going away and the new one appearing
+	s.publish(EventDeleted, &old)
+	s.publish(EventCreated, meta)
+	return meta, nil
+}
+
+// DeleteTreeOptions configure DeleteTree.
+type DeleteTreeOptions struct {
+	UserID string // User scope ("" = global)
+}
+
+// DeleteTree removes the artifact at dirPath or, if dirPath is a virtual
+// directory, every artifact below it. It returns the number of artifacts
+// deleted, which is less than expected if ctx is cancelled midway.
+func (s *Store) DeleteTree(ctx context.Context, dirPath string, opts DeleteTreeOptions) (_ int, err error) {
+	userID := opts.UserID
+	ctx, span := startSpan(ctx, "DeleteTree", dirPath, userID)
+	defer func() { endSpan(span, err) }()
+
+	dir := NormalizePath(dirPath)
+	uID := scopeKey(userID)
+
+	s.mu.Lock()
+	var paths []string
+	for path := range s.index[uID] {
+		if dir == "/" || path == dir || strings.HasPrefix(path, dir+"/") {
+			paths = append(paths, path)
+		}
+	}
+	for path := range s.dirs[uID] {
+		if dir == "/" || path == dir || strings.HasPrefix(path, dir+"/") {
+			delete(s.dirs[uID], path)
+		}
+	}
+	s.mu.Unlock()
+
+	progress := progressFrom(ctx)
+	deleted := 0
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return deleted, wrapError("delete", dirPath, userID, err)
+		}
+		progress(int64(i), int64(len(paths)))
+		ok, err := s.Delete(ctx, path, DeleteOptions{UserID: userID})
+		if err != nil {
+			return deleted, err
+		}
+		if ok {
+			deleted++
+		}
+	}
+	progress(int64(len(paths)), int64(len(paths)))
+	return deleted, nil
+}
+
+// scopeKey returns the index key for a user scope.
+func scopeKey(userID string) string {
+	if userID == "" {
+		return "global"
+	}
+	return userID
+}
+
+// Scopes returns the user scopes that have artifacts with virtual paths,
+// sorted, with "" for the global scope.
+func (s *Store) Scopes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scopes := make([]string, 0, len(s.index))
+	for uID, userIdx := range s.index {
+		if len(userIdx) == 0 {
+			continue
+		}
+		if uID == "global" {
+			uID = ""
+		}
+		scopes = append(scopes, uID)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
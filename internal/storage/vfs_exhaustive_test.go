@@ -23,18 +23,18 @@ func TestVFS_Exhaustive(t *testing.T) {
 		// Create a file deep in the hierarchy
 		path := "/a/b/c/d/e/f/file.txt"
 		content := []byte("deep data")
-		_, err := store.Write("file.txt", content, "", 1, "test", userID, "", nil, path)
+		_, err := store.Write(t.Context(), "file.txt", content, WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: path})
 		require.NoError(t, err)
 
 		// Verify we can read it back
-		got, _, err := store.Read(path, userID)
+		got, _, err := store.Read(t.Context(), path, ReadOptions{UserID: userID})
 		require.NoError(t, err)
 		assert.Equal(t, content, got)
 
 		// Verify ListVFS at each level
 		levels := []string{"/", "/a", "/a/b", "/a/b/c", "/a/b/c/d", "/a/b/c/d/e", "/a/b/c/d/e/f"}
 		for _, lvl := range levels {
-			items, err := store.List(userID, 10, 0, lvl)
+			items, err := store.List(t.Context(), ListOptions{UserID: userID, DirPath: lvl, Limit: 10})
 			require.NoError(t, err)
 			assert.NotEmpty(t, items, "Level %s should not be empty", lvl)
 			if lvl == "/a/b/c/d/e/f" {
@@ -52,14 +52,14 @@ func TestVFS_Exhaustive(t *testing.T) {
 		c1 := []byte(`{"id": 1}`)
 		c2 := []byte(`{"id": 2}`)
 
-		_, err := store.Write("config.json", c1, "", 1, "test", userID, "", nil, p1)
+		_, err := store.Write(t.Context(), "config.json", c1, WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: p1})
 		require.NoError(t, err)
-		_, err = store.Write("config.json", c2, "", 1, "test", userID, "", nil, p2)
+		_, err = store.Write(t.Context(), "config.json", c2, WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: p2})
 		require.NoError(t, err)
 
 		// Ensure they are distinct
-		got1, _, _ := store.Read(p1, userID)
-		got2, _, _ := store.Read(p2, userID)
+		got1, _, _ := store.Read(t.Context(), p1, ReadOptions{UserID: userID})
+		got2, _, _ := store.Read(t.Context(), p2, ReadOptions{UserID: userID})
 		assert.Equal(t, c1, got1)
 		assert.Equal(t, c2, got2)
 		assert.NotEqual(t, got1, got2)
@@ -68,10 +68,10 @@ func TestVFS_Exhaustive(t *testing.T) {
 	t.Run("SpecialCharacters", func(t *testing.T) {
 		path := "/My Projects/🚀 Space/data @ v1.txt"
 		content := []byte("special")
-		_, err := store.Write("data.txt", content, "", 1, "test", userID, "", nil, path)
+		_, err := store.Write(t.Context(), "data.txt", content, WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: path})
 		require.NoError(t, err)
 
-		got, _, err := store.Read(path, userID)
+		got, _, err := store.Read(t.Context(), path, ReadOptions{UserID: userID})
 		require.NoError(t, err)
 		assert.Equal(t, content, got)
 	})
@@ -79,44 +79,44 @@ func TestVFS_Exhaustive(t *testing.T) {
 	t.Run("PatchEdgeCases", func(t *testing.T) {
 		path := "/patch/test.txt"
 		initial := []byte("line1\nline2\nline3")
-		_, _ = store.Write("test.txt", initial, "", 1, "test", userID, "", nil, path)
+		_, _ = store.Write(t.Context(), "test.txt", initial, WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: path})
 
 		// 1. Replace first line
-		_, err := store.Patch(path, userID, []byte("NEW1"), 0, 1, false)
+		_, err := store.Patch(t.Context(), path, []byte("NEW1"), PatchOptions{UserID: userID, LineEnd: 1})
 		require.NoError(t, err)
-		got, _, _ := store.Read(path, userID)
+		got, _, _ := store.Read(t.Context(), path, ReadOptions{UserID: userID})
 		assert.Equal(t, "NEW1\nline2\nline3", string(got))
 
 		// 2. Replace middle line
-		_, err = store.Patch(path, userID, []byte("NEW2"), 1, 2, false)
+		_, err = store.Patch(t.Context(), path, []byte("NEW2"), PatchOptions{UserID: userID, LineStart: 1, LineEnd: 2})
 		require.NoError(t, err)
-		got, _, _ = store.Read(path, userID)
+		got, _, _ = store.Read(t.Context(), path, ReadOptions{UserID: userID})
 		assert.Equal(t, "NEW1\nNEW2\nline3", string(got))
 
 		// 3. Replace last line
-		_, err = store.Patch(path, userID, []byte("NEW3"), 2, 3, false)
+		_, err = store.Patch(t.Context(), path, []byte("NEW3"), PatchOptions{UserID: userID, LineStart: 2, LineEnd: 3})
 		require.NoError(t, err)
-		got, _, _ = store.Read(path, userID)
+		got, _, _ = store.Read(t.Context(), path, ReadOptions{UserID: userID})
 		assert.Equal(t, "NEW1\nNEW2\nNEW3", string(got))
 
 		// 4. Out of bounds (start > len) -> should append
-		_, err = store.Patch(path, userID, []byte("EXTRA"), 10, 11, false)
+		_, err = store.Patch(t.Context(), path, []byte("EXTRA"), PatchOptions{UserID: userID, LineStart: 10, LineEnd: 11})
 		require.NoError(t, err)
-		got, _, _ = store.Read(path, userID)
+		got, _, _ = store.Read(t.Context(), path, ReadOptions{UserID: userID})
 		assert.Contains(t, string(got), "NEW3\nEXTRA")
 
 		// 5. Empty file patch
 		emptyPath := "/patch/empty.txt"
-		_, _ = store.Write("empty.txt", []byte(""), "", 1, "test", userID, "", nil, emptyPath)
-		_, err = store.Patch(emptyPath, userID, []byte("content"), 0, 0, false)
+		_, _ = store.Write(t.Context(), "empty.txt", []byte(""), WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: emptyPath})
+		_, err = store.Patch(t.Context(), emptyPath, []byte("content"), PatchOptions{UserID: userID})
 		require.NoError(t, err)
-		got, _, _ = store.Read(emptyPath, userID)
+		got, _, _ = store.Read(t.Context(), emptyPath, ReadOptions{UserID: userID})
 		assert.Equal(t, "content", string(got))
 	})
 
 	t.Run("Concurrency", func(t *testing.T) {
 		path := "/concurrent/shared.txt"
-		_, _ = store.Write("shared.txt", []byte("start"), "", 1, "test", userID, "", nil, path)
+		_, _ = store.Write(t.Context(), "shared.txt", []byte("start"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: userID, VirtualPath: path})
 
 		var wg sync.WaitGroup
 		numWorkers := 20
@@ -127,50 +127,50 @@ func TestVFS_Exhaustive(t *testing.T) {
 				defer wg.Done()
 				// Mix of reads and patches
 				if idx%2 == 0 {
-					_, _, _ = store.Read(path, userID)
+					_, _, _ = store.Read(t.Context(), path, ReadOptions{UserID: userID})
 				} else {
-					_, _ = store.Patch(path, userID, []byte(fmt.Sprintf("\nline %d", idx)), 0, 0, true)
+					_, _ = store.Patch(t.Context(), path, []byte(fmt.Sprintf("\nline %d", idx)), PatchOptions{UserID: userID, Append: true})
 				}
 			}(i)
 		}
 		wg.Wait()
 
 		// Final read to ensure lock didn't fail and data is consistent (not corrupted)
-		got, _, err := store.Read(path, userID)
+		got, _, err := store.Read(t.Context(), path, ReadOptions{UserID: userID})
 		require.NoError(t, err)
 		assert.NotEmpty(t, got)
 	})
 
 	t.Run("FindComplex", func(t *testing.T) {
 		// Setup files
-		store.Write("f1.txt", []byte("1"), "", 1, "t", userID, "", nil, "/logs/2026-03-17.log")
-		store.Write("f2.txt", []byte("2"), "", 1, "t", userID, "", nil, "/logs/2026-03-18.log")
-		store.Write("f3.txt", []byte("3"), "", 1, "t", userID, "", nil, "/reports/final.pdf")
+		store.Write(t.Context(), "f1.txt", []byte("1"), WriteOptions{ExpiresHours: 1, Source: "t", UserID: userID, VirtualPath: "/logs/2026-03-17.log"})
+		store.Write(t.Context(), "f2.txt", []byte("2"), WriteOptions{ExpiresHours: 1, Source: "t", UserID: userID, VirtualPath: "/logs/2026-03-18.log"})
+		store.Write(t.Context(), "f3.txt", []byte("3"), WriteOptions{ExpiresHours: 1, Source: "t", UserID: userID, VirtualPath: "/reports/final.pdf"})
 
 		// 1. Glob match
-		items, _ := store.Find(userID, "/logs/*.log")
+		items, _ := store.Find(t.Context(), "/logs/*.log", FindOptions{UserID: userID})
 		assert.Len(t, items, 2)
 
 		// 2. Substring match
-		items, _ = store.Find(userID, "final")
+		items, _ = store.Find(t.Context(), "final", FindOptions{UserID: userID})
 		assert.Len(t, items, 1)
 		assert.Equal(t, "f3.txt", items[0].Filename)
 
 		// 3. No match
-		items, _ = store.Find(userID, "non-existent")
+		items, _ = store.Find(t.Context(), "non-existent", FindOptions{UserID: userID})
 		assert.Empty(t, items)
 	})
 
 	t.Run("UserIsolation", func(t *testing.T) {
 		path := "/shared/secret.txt"
-		_, _ = store.Write("s.txt", []byte("private"), "", 1, "t", "user-a", "", nil, path)
+		_, _ = store.Write(t.Context(), "s.txt", []byte("private"), WriteOptions{ExpiresHours: 1, Source: "t", UserID: "user-a", VirtualPath: path})
 
 		// Try to read from user-b
-		_, _, err := store.Read(path, "user-b")
+		_, _, err := store.Read(t.Context(), path, ReadOptions{UserID: "user-b"})
 		assert.Error(t, err, "User B should not see User A's virtual path")
 
 		// List root for user-b
-		items, _ := store.List("user-b", 10, 0, "/")
+		items, _ := store.List(t.Context(), ListOptions{UserID: "user-b", DirPath: "/", Limit: 10})
 		assert.Empty(t, items)
 	})
 }
@@ -186,21 +186,21 @@ func TestVFS_Persistence(t *testing.T) {
 	
 	// 1. Write data
 	s1 := NewStore(tempDir)
-	_, err = s1.Write("save.txt", []byte("payload"), "", 1, "test", uID, "", nil, path)
+	_, err = s1.Write(t.Context(), "save.txt", []byte("payload"), WriteOptions{ExpiresHours: 1, Source: "test", UserID: uID, VirtualPath: path})
 	require.NoError(t, err)
 
 	// 2. Simulate server restart by creating new store instance
 	s2 := NewStore(tempDir)
 
 	// 3. Verify path is still valid
-	got, meta, err := s2.Read(path, uID)
+	got, meta, err := s2.Read(t.Context(), path, ReadOptions{UserID: uID})
 	require.NoError(t, err)
 	assert.Equal(t, []byte("payload"), got)
 	assert.Equal(t, path, meta.VirtualPath)
 
 	// 4. Verify Delete updates index across restarts
-	_, _ = s2.Delete(path, uID)
+	_, _ = s2.Delete(t.Context(), path, DeleteOptions{UserID: uID})
 	s3 := NewStore(tempDir)
-	_, _, err = s3.Read(path, uID)
+	_, _, err = s3.Read(t.Context(), path, ReadOptions{UserID: uID})
 	assert.Error(t, err, "Path should remain deleted after restart")
 }
@@ -0,0 +1,217 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package tracing configures OpenTelemetry tracing for the artifact server.
+//
+// Setup installs a global tracer provider with the configured exporter and the
+// W3C trace-context and baggage propagators. Connect handlers are traced by
+// Interceptor and MCP tool calls by ToolMiddleware; the storage package starts
+// its own spans below them. The client package traces its calls with
+// the global provider as well, so a trace follows a request from the calling MCP
+// server through the RPC into storage.
+//
+// Without Setup the global provider is a no-op and tracing costs next to
+// nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/otelconnect"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer used for spans of this module.
+const InstrumentationName = "github.com/hmsoft0815/mlcartifact"
+
+// Exporters supported by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Config selects the span exporter.
+type Config struct {
+	Exporter    string    // none (default), stdout, file or otlp
+	Writer      io.Writer // Output of the stdout exporter, os.Stderr if nil
+	File        string    // Output file of the file exporter
+	Endpoint    string    // OTLP/HTTP endpoint, e.g. "localhost:4318"; OTEL_EXPORTER_OTLP_* if empty
+	Insecure    bool      // Use plain HTTP for OTLP
+	ServiceName string    // Defaults to OTEL_SERVICE_NAME, then "mlcartifact"
+	SampleRatio float64   // Fraction of new traces to sample, 0 samples all
+}
+
+// ConfigFromEnv reads the configuration from ARTIFACT_TRACE_EXPORTER,
+// ARTIFACT_TRACE_FILE and ARTIFACT_TRACE_ENDPOINT. The standard OTEL_*
+// variables of the OTLP exporter and resource are honoured as well.
+func ConfigFromEnv() Config {
+	return Config{
+		Exporter: os.Getenv("ARTIFACT_TRACE_EXPORTER"),
+		File:     os.Getenv("ARTIFACT_TRACE_FILE"),
+		Endpoint: os.Getenv("ARTIFACT_TRACE_ENDPOINT"),
+	}
+}
+
+// Setup installs the global tracer provider and propagator. The returned
+// function flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var closer io.Closer
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		// Stdout itself carries the frames of the MCP stdio transport
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stderr
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, err
+		}
+		exporter = exp
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("file trace exporter needs a file")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		exporter, closer = exp, f
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		exporter = exp
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	tp := NewProvider(exporter, cfg)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// NewProvider creates a tracer provider exporting to exporter, e.g. an
+// in-memory exporter in tests. Only the service name and sample ratio of cfg
+// are used.
+func NewProvider(exporter sdktrace.SpanExporter, cfg Config) *sdktrace.TracerProvider {
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+
+	name := cfg.ServiceName
+	if name == "" {
+		name = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if name == "" {
+		name = "mlcartifact"
+	}
+	res := sdkresource.NewSchemaless(attribute.String("service.name", name))
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+}
+
+// Interceptor returns a Connect interceptor that starts a server span for
+// every RPC, continuing the trace of the caller.
+func Interceptor() connect.Interceptor {
+	// otelconnect only fails on invalid options
+	i, _ := otelconnect.NewInterceptor(otelconnect.WithTrustRemote(), otelconnect.WithoutMetrics())
+	return i
+}
+
+// ToolMiddleware returns an MCP tool handler middleware that starts a span for
+// every tool call. A W3C traceparent (and tracestate) in the request's _meta
+// continues the caller's trace.
+func ToolMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if meta := req.Params.Meta; meta != nil && len(meta.AdditionalFields) > 0 {
+				carrier := propagation.MapCarrier{}
+				for k, v := range meta.AdditionalFields {
+					if s, ok := v.(string); ok {
+						carrier[k] = s
+					}
+				}
+				ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+			}
+
+			ctx, span := tracer().Start(ctx, "mcp.tool/"+req.Params.Name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attribute.String("mcp.tool.name", req.Params.Name)))
+
+			res, err := next(ctx, req)
+			if err == nil && res != nil && res.IsError {
+				span.SetStatus(codes.Error, "tool returned an error result")
+			}
+			End(span, err)
+			return res, err
+		}
+	}
+}
+
+// Start starts an internal span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
@@ -0,0 +1,183 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/hmsoft0815/mlcartifact/client"
+	artifactgrpc "github.com/hmsoft0815/mlcartifact/internal/grpc"
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/hmsoft0815/mlcartifact/internal/tracing"
+	"github.com/hmsoft0815/mlcartifact/proto/protoconnect"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a synchronous in-memory tracer provider for the test.
+func record(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	return exporter
+}
+
+func spanNamed(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	require.Failf(t, "span not found", "no span %q in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestTracing_ClientServerStorage(t *testing.T) {
+	exporter := record(t)
+
+	tempDir, err := os.MkdirTemp("", "artifact-tracing-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	mux := http.NewServeMux()
+	mux.Handle(protoconnect.NewArtifactServiceHandler(
+		artifactgrpc.NewConnectServer(storage.NewStore(tempDir)),
+		connect.WithInterceptors(tracing.Interceptor())))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := client.NewClientWithAddr(srv.URL, client.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "pipeline")
+	_, err = c.Write(ctx, "a.txt", []byte("hello"))
+	require.NoError(t, err)
+	_, err = c.Read(ctx, "missing")
+	require.Error(t, err)
+	root.End()
+
+	spans := exporter.GetSpans()
+	traceID := root.SpanContext().TraceID()
+	for _, s := range spans {
+		assert.Equal(t, traceID, s.SpanContext.TraceID(), s.Name)
+	}
+
+	var clientSpan, serverSpan tracetest.SpanStub
+	for _, s := range spans {
+		if s.Name == "artifact.v1.ArtifactService/Write" && s.SpanKind == trace.SpanKindClient {
+			clientSpan = s
+		}
+		if s.Name == "artifact.v1.ArtifactService/Write" && s.SpanKind == trace.SpanKindServer {
+			serverSpan = s
+		}
+	}
+	// pipeline -> client RPC -> server RPC -> storage
+	assert.Equal(t, root.SpanContext().SpanID(), clientSpan.Parent.SpanID())
+	assert.Equal(t, clientSpan.SpanContext.SpanID(), serverSpan.Parent.SpanID())
+	assert.Equal(t, serverSpan.SpanContext.SpanID(), spanNamed(t, spans, "storage.Write").Parent.SpanID())
+	read := spanNamed(t, spans, "storage.Read")
+	assert.Equal(t, codes.Error, read.Status.Code)
+}
+
+func TestTracing_StorageOpen(t *testing.T) {
+	exporter := record(t)
+
+	store := storage.NewStore(t.TempDir())
+	meta, err := store.Write(t.Context(), "a.txt", []byte("hello"), storage.WriteOptions{})
+	require.NoError(t, err)
+	f, _, err := store.Open(t.Context(), meta.ID, storage.OpenOptions{})
+	require.NoError(t, err)
+	f.Close()
+
+	open := spanNamed(t, exporter.GetSpans(), "storage.Open")
+	assert.Contains(t, open.Attributes, attribute.Int64("artifact.size", 5))
+}
+
+func TestToolMiddleware_ContinuesTrace(t *testing.T) {
+	exporter := record(t)
+
+	_, parent := otel.Tracer("test").Start(context.Background(), "agent")
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(trace.ContextWithSpan(context.Background(), parent), carrier)
+	parent.End()
+
+	handler := tracing.ToolMiddleware()(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		_, span := tracing.Start(ctx, "storage.Read")
+		span.End()
+		return mcp.NewToolResultError("not found"), nil
+	})
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "read_artifact"
+	req.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{"traceparent": carrier.Get("traceparent")}}
+	_, err := handler(context.Background(), req)
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	tool := spanNamed(t, spans, "mcp.tool/read_artifact")
+	assert.Equal(t, parent.SpanContext().TraceID(), tool.SpanContext.TraceID())
+	assert.Equal(t, parent.SpanContext().SpanID(), tool.Parent.SpanID())
+	assert.Equal(t, codes.Error, tool.Status.Code)
+	storageSpan := spanNamed(t, spans, "storage.Read")
+	assert.Equal(t, tool.SpanContext.SpanID(), storageSpan.Parent.SpanID())
+}
+
+func TestSetup_FileExporter(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterFile, File: path, ServiceName: "test-svc"})
+	require.NoError(t, err)
+
+	_, span := tracing.Start(context.Background(), "storage.Read")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"storage.Read"`)
+	assert.Contains(t, string(data), "test-svc")
+
+	_, err = tracing.Setup(context.Background(), tracing.Config{Exporter: "carrier-pigeon"})
+	assert.Error(t, err)
+}
+
+func TestSetup_StdoutExporter(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	var buf bytes.Buffer
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterStdout, Writer: &buf})
+	require.NoError(t, err)
+
+	_, span := tracing.Start(context.Background(), "storage.Write")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, buf.String(), `"Name":"storage.Write"`)
+}
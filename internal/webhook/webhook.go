@@ -0,0 +1,357 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+// Package webhook delivers artifact lifecycle events to HTTP endpoints.
+//
+// A Dispatcher watches the store and POSTs a JSON payload for every created,
+// patched, deleted and expired artifact that matches a webhook's filters.
+// Payloads are signed with HMAC-SHA256 over the request body, sent as
+//
+//	X-Artifact-Signature: sha256=<hex>
+//
+// so receivers can verify them with Verify. Failed deliveries are retried with
+// exponential backoff; events that still cannot be delivered are written to
+// the dead-letter log. Events of one webhook are delivered in order.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of the body.
+	SignatureHeader = "X-Artifact-Signature"
+	// EventHeader carries the event type.
+	EventHeader = "X-Artifact-Event"
+	// DeliveryHeader carries the event cursor, unique per event and stable
+	// across retries, so receivers can deduplicate.
+	DeliveryHeader = "X-Artifact-Delivery"
+)
+
+// Config describes one webhook endpoint.
+type Config struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`      // HMAC key; unsigned if empty
+	UserID     string   `json:"user_id,omitempty"`     // User scope, "*" for all scopes
+	PathPrefix string   `json:"path_prefix,omitempty"` // Only artifacts at or below this virtual path
+	Source     string   `json:"source,omitempty"`      // Only artifacts from this source
+	Events     []string `json:"events,omitempty"`      // Event types to deliver, all if empty
+}
+
+// LoadFile reads webhook configurations from a JSON file of the form
+//
+//	{"webhooks": [{"url": "https://...", "secret": "...", "path_prefix": "/projects"}]}
+func LoadFile(path string) ([]Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Webhooks []Config `json:"webhooks"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid webhooks file: %w", err)
+	}
+	for i, h := range cfg.Webhooks {
+		if !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+			return nil, fmt.Errorf("invalid webhooks file: entry %d needs an http(s) url", i)
+		}
+	}
+	return cfg.Webhooks, nil
+}
+
+// Payload is the JSON body POSTed to webhooks.
+type Payload struct {
+	ID       string   `json:"id"`   // Event cursor, same as the delivery header
+	Type     string   `json:"type"` // "created", "patched", "deleted" or "expired"
+	Time     string   `json:"time"` // RFC 3339
+	Artifact Artifact `json:"artifact"`
+}
+
+// Artifact describes the artifact an event refers to.
+type Artifact struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	MimeType    string `json:"mime_type"`
+	Source      string `json:"source,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+	Description string `json:"description,omitempty"`
+	VirtualPath string `json:"virtual_path,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	ExpiresAt   string `json:"expires_at"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+// DeadLetterFile is the usual name of the dead-letter log.
+const DeadLetterFile = "webhooks-dead-letter.jsonl"
+
+// deadLetter is a line of the dead-letter log.
+type deadLetter struct {
+	Time     string  `json:"time"`
+	URL      string  `json:"url"`
+	Attempts int     `json:"attempts"`
+	Error    string  `json:"error"`
+	Payload  Payload `json:"payload"`
+}
+
+// Sign returns the signature header value for body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body.
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Dispatcher delivers store events to the configured webhooks.
+type Dispatcher struct {
+	store      *storage.Store
+	hooks      []Config
+	client     *http.Client
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	mu         sync.Mutex // guards deadLetter
+	deadLetter io.Writer
+
+	wg sync.WaitGroup // running deliveries
+}
+
+// Option is a functional option for configuring the Dispatcher.
+type Option func(*Dispatcher)
+
+// WithHTTPClient sets the client used for deliveries.
+func WithHTTPClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// WithRetry sets the number of delivery attempts per event and the delay
+// before the first retry, which doubles with every further attempt.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.attempts = attempts
+		d.backoff = backoff
+	}
+}
+
+// WithDeadLetter writes events that could not be delivered to w, one JSON
+// object per line. Without it they are only logged.
+func WithDeadLetter(w io.Writer) Option {
+	return func(d *Dispatcher) {
+		d.deadLetter = w
+	}
+}
+
+// NewDispatcher creates a Dispatcher for the given webhooks. By default every
+// event is attempted 5 times, starting with a one second backoff.
+func NewDispatcher(store *storage.Store, hooks []Config, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		store:      store,
+		hooks:      hooks,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		attempts:   5,
+		backoff:    time.Second,
+		maxBackoff: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Start subscribes every webhook to the store and delivers events in the
+// background until ctx is cancelled. Events that happen after Start returns
+// are guaranteed to be seen. If a webhook cannot be subscribed, Start
+// returns the error and delivers nothing.
+func (d *Dispatcher) Start(ctx context.Context) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	subs := make([]*storage.Subscription, len(d.hooks))
+	for i, hook := range d.hooks {
+		if subs[i], err = d.store.Watch(ctx, hook.filter(), ""); err != nil {
+			return fmt.Errorf("failed to subscribe webhook %s: %w", hook.URL, err)
+		}
+	}
+	for i, hook := range d.hooks {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.run(ctx, hook, subs[i])
+		}()
+	}
+	return nil
+}
+
+// Wait blocks until the deliveries started by Start have stopped after its
+// context was cancelled. Events still being retried are dead-lettered.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// run delivers the events of one webhook. If delivery falls behind the event
+// stream, the watch is resumed from the last delivered event.
+func (d *Dispatcher) run(ctx context.Context, hook Config, sub *storage.Subscription) {
+	cursor := ""
+	for {
+		for ev := range sub.C {
+			if hook.wants(ev.Type) {
+				d.deliver(ctx, hook, ev)
+			}
+			cursor = ev.Cursor
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		var err error
+		if sub, err = d.store.Watch(ctx, hook.filter(), cursor); err != nil {
+			slog.Warn("Webhook events lost, resuming with new events", "url", hook.URL, "error", err)
+			if sub, err = d.store.Watch(ctx, hook.filter(), ""); err != nil {
+				slog.Error("Webhook stopped", "url", hook.URL, "error", err)
+				return
+			}
+		}
+	}
+}
+
+// filter returns the watch filter selecting the events of the webhook.
+func (h Config) filter() storage.WatchFilter {
+	return storage.WatchFilter{
+		UserID:     h.UserID,
+		AnyUser:    h.UserID == "*",
+		PathPrefix: h.PathPrefix,
+		Source:     h.Source,
+	}
+}
+
+func (h Config) wants(typ storage.EventType) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == string(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver POSTs one event, retrying with backoff, and dead-letters it if all
+// attempts fail.
+func (d *Dispatcher) deliver(ctx context.Context, hook Config, ev storage.Event) {
+	payload := newPayload(ev)
+	body, _ := json.Marshal(payload)
+
+	var err error
+	attempt := 0
+	backoff := d.backoff
+retry:
+	for {
+		attempt++
+		var retryable bool
+		if retryable, err = d.post(ctx, hook, ev, body); err == nil {
+			return
+		}
+		if !retryable || attempt >= d.attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break retry
+		case <-time.After(backoff):
+			backoff = min(2*backoff, d.maxBackoff)
+		}
+	}
+
+	slog.Warn("Webhook delivery failed", "url", hook.URL, "event", ev.Type, "id", ev.Artifact.ID, "attempts", attempt, "error", err)
+	if d.deadLetter == nil {
+		return
+	}
+	line, _ := json.Marshal(deadLetter{
+		Time:     time.Now().Format(time.RFC3339),
+		URL:      hook.URL,
+		Attempts: attempt,
+		Error:    err.Error(),
+		Payload:  payload,
+	})
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, _ = d.deadLetter.Write(append(line, '\n'))
+}
+
+// post sends a single delivery attempt and reports whether a failure is
+// worth retrying.
+func (d *Dispatcher) post(ctx context.Context, hook Config, ev storage.Event, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mlcartifact-webhook")
+	req.Header.Set(EventHeader, string(ev.Type))
+	req.Header.Set(DeliveryHeader, ev.Cursor)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign([]byte(hook.Secret), body))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("receiver responded %s", res.Status)
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusRequestTimeout || res.StatusCode == http.StatusTooManyRequests
+	return retry, err
+}
+
+func newPayload(ev storage.Event) Payload {
+	meta := ev.Artifact
+	return Payload{
+		ID:   ev.Cursor,
+		Type: string(ev.Type),
+		Time: ev.Time.Format(time.RFC3339Nano),
+		Artifact: Artifact{
+			ID:          meta.ID,
+			Filename:    meta.Filename,
+			MimeType:    meta.MimeType,
+			Source:      meta.Source,
+			UserID:      meta.UserID,
+			Description: meta.Description,
+			VirtualPath: meta.VirtualPath,
+			CreatedAt:   meta.CreatedAt.Format(time.RFC3339),
+			ExpiresAt:   meta.ExpiresAt.Format(time.RFC3339),
+			SizeBytes:   ev.SizeBytes,
+		},
+	}
+}
@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hmsoft0815/mlcartifact/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver records webhook deliveries and answers with the queued status codes.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	payloads []Payload
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	if status < 300 {
+		var p Payload
+		_ = json.Unmarshal(body, &p)
+		rc.payloads = append(rc.payloads, p)
+		rc.bodies = append(rc.bodies, body)
+		rc.headers = append(rc.headers, r.Header.Clone())
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.payloads)
+}
+
+func newStore(t *testing.T) *storage.Store {
+	tempDir, err := os.MkdirTemp("", "artifact-webhook-test-*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+	return storage.NewStore(tempDir)
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	store := newStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	secret := "s3cret"
+	err := NewDispatcher(store, []Config{{
+		URL:        srv.URL,
+		Secret:     secret,
+		PathPrefix: "/projects",
+		Source:     "agent",
+	}}, WithRetry(3, time.Millisecond)).Start(ctx)
+	require.NoError(t, err)
+
+	meta, err := store.Write(t.Context(), "a.md", []byte("# A"), storage.WriteOptions{ExpiresHours: 1, Source: "agent", VirtualPath: "/projects/a.md"})
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "b.md", []byte("# B"), storage.WriteOptions{ExpiresHours: 1, Source: "other", VirtualPath: "/projects/b.md"})
+	require.NoError(t, err)
+	_, err = store.Write(t.Context(), "c.md", []byte("# C"), storage.WriteOptions{ExpiresHours: 1, Source: "agent", VirtualPath: "/elsewhere/c.md"})
+	require.NoError(t, err)
+	_, err = store.Patch(t.Context(), meta.ID, []byte("more"), storage.PatchOptions{Append: true})
+	require.NoError(t, err)
+	_, err = store.Delete(t.Context(), meta.ID, storage.DeleteOptions{})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return rc.count() == 3 }, 2*time.Second, 5*time.Millisecond)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	var types []string
+	for i, p := range rc.payloads {
+		types = append(types, p.Type)
+		assert.Equal(t, meta.ID, p.Artifact.ID)
+		assert.True(t, Verify([]byte(secret), rc.bodies[i], rc.headers[i].Get(SignatureHeader)))
+		assert.Equal(t, p.ID, rc.headers[i].Get(DeliveryHeader))
+		assert.Equal(t, p.Type, rc.headers[i].Get(EventHeader))
+	}
+	// The first delivery was retried after the 503, and order is preserved
+	assert.Equal(t, []string{"created", "patched", "deleted"}, types)
+	assert.Equal(t, int64(3), rc.payloads[0].Artifact.SizeBytes)
+	assert.False(t, Verify([]byte("wrong"), rc.bodies[0], rc.headers[0].Get(SignatureHeader)))
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusBadRequest}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	store := newStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var dead bytes.Buffer
+	err := NewDispatcher(store, []Config{
+		{URL: srv.URL, UserID: "*", Events: []string{"created"}},
+	}, WithRetry(2, time.Millisecond), WithDeadLetter(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return dead.Write(p)
+	}))).Start(ctx)
+	require.NoError(t, err)
+
+	// 500 twice: retries exhausted. 400: not retried. Third one is delivered.
+	for _, user := range []string{"u1", "u2", "u3"} {
+		_, err = store.Write(t.Context(), "x.txt", []byte("x"), storage.WriteOptions{ExpiresHours: 1, Source: "test", UserID: user})
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool { return rc.count() == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	lines := bytes.Split(bytes.TrimSpace(dead.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var first, second deadLetter
+	require.NoError(t, json.Unmarshal(lines[0], &first))
+	require.NoError(t, json.Unmarshal(lines[1], &second))
+	assert.Equal(t, 2, first.Attempts)
+	assert.Equal(t, "u1", first.Payload.Artifact.UserID)
+	assert.Contains(t, first.Error, "500")
+	assert.Equal(t, 1, second.Attempts)
+	assert.Equal(t, "u2", second.Payload.Artifact.UserID)
+	assert.Equal(t, "u3", rc.payloads[0].Artifact.UserID)
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "webhooks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"webhooks":[{"url":"https://example.com/hook","secret":"x","events":["created"]}]}`), 0600))
+	hooks, err := LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, []string{"created"}, hooks[0].Events)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"webhooks":[{"url":"ftp://example.com"}]}`), 0600))
+	_, err = LoadFile(path)
+	assert.Error(t, err)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -133,7 +133,7 @@ type WriteResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                      // unique artifact ID
 	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`                          // sanitized filename
-	Uri           string                 `protobuf:"bytes,3,opt,name=uri,proto3" json:"uri,omitempty"`                                    // artifact://filename for LLM reference
+	Uri           string                 `protobuf:"bytes,3,opt,name=uri,proto3" json:"uri,omitempty"`                                    // artifact://{user}/{path}, readable as an MCP resource
 	ExpiresAt     string                 `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`       // ISO 8601
 	VirtualPath   string                 `protobuf:"bytes,5,opt,name=virtual_path,json=virtualPath,proto3" json:"virtual_path,omitempty"` // The normalized path saved
 	unknownFields protoimpl.UnknownFields
@@ -861,6 +861,626 @@ func (x *FindRequest) GetPattern() string {
 	return ""
 }
 
+type CreateShareLinkRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                                // artifact ID or filename OR virtual_path (if starts with /)
+	UserId         string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                          // optional, scopes lookup to user
+	ExpiresMinutes int32                  `protobuf:"varint,3,opt,name=expires_minutes,json=expiresMinutes,proto3" json:"expires_minutes,omitempty"` // optional, default 60, capped by the artifact's own expiry
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateShareLinkRequest) Reset() {
+	*x = CreateShareLinkRequest{}
+	mi := &file_artifact_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateShareLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateShareLinkRequest) ProtoMessage() {}
+
+func (x *CreateShareLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateShareLinkRequest.ProtoReflect.Descriptor instead.
+func (*CreateShareLinkRequest) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CreateShareLinkRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateShareLinkRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateShareLinkRequest) GetExpiresMinutes() int32 {
+	if x != nil {
+		return x.ExpiresMinutes
+	}
+	return 0
+}
+
+type CreateShareLinkResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`                              // signed HTTP download URL
+	ExpiresAt     string                 `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // ISO 8601, when the link stops working
+	Filename      string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
+	MimeType      string                 `protobuf:"bytes,4,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateShareLinkResponse) Reset() {
+	*x = CreateShareLinkResponse{}
+	mi := &file_artifact_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateShareLinkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateShareLinkResponse) ProtoMessage() {}
+
+func (x *CreateShareLinkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateShareLinkResponse.ProtoReflect.Descriptor instead.
+func (*CreateShareLinkResponse) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CreateShareLinkResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateShareLinkResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *CreateShareLinkResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *CreateShareLinkResponse) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+type SetExpiryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                          // artifact ID or filename OR virtual_path (if starts with /)
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                    // optional, scopes lookup to user
+	ExpiresHours  int32                  `protobuf:"varint,3,opt,name=expires_hours,json=expiresHours,proto3" json:"expires_hours,omitempty"` // new lifetime counted from now, default 24
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetExpiryRequest) Reset() {
+	*x = SetExpiryRequest{}
+	mi := &file_artifact_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetExpiryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetExpiryRequest) ProtoMessage() {}
+
+func (x *SetExpiryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetExpiryRequest.ProtoReflect.Descriptor instead.
+func (*SetExpiryRequest) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SetExpiryRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetExpiryRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SetExpiryRequest) GetExpiresHours() int32 {
+	if x != nil {
+		return x.ExpiresHours
+	}
+	return 0
+}
+
+type SetExpiryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExpiresAt     string                 `protobuf:"bytes,1,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // ISO 8601
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetExpiryResponse) Reset() {
+	*x = SetExpiryResponse{}
+	mi := &file_artifact_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetExpiryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetExpiryResponse) ProtoMessage() {}
+
+func (x *SetExpiryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetExpiryResponse.ProtoReflect.Descriptor instead.
+func (*SetExpiryResponse) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SetExpiryResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`             // optional, scopes events to user
+	PathPrefix    string                 `protobuf:"bytes,2,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"` // optional, only artifacts at or below this virtual path
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`                           // optional, only artifacts from this source server
+	Cursor        string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`                           // optional, resume after the event with this cursor
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_artifact_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`         // "created", "patched", "deleted" or "expired"
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`     // pass as WatchRequest.cursor to resume after this event
+	Time          string                 `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`         // ISO 8601
+	Artifact      *ArtifactInfo          `protobuf:"bytes,4,opt,name=artifact,proto3" json:"artifact,omitempty"` // the artifact as of this event
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_artifact_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetArtifact() *ArtifactInfo {
+	if x != nil {
+		return x.Artifact
+	}
+	return nil
+}
+
+type QueryAuditRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ArtifactId    string                 `protobuf:"bytes,1,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"` // optional, artifact ID or virtual path
+	Scope         string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"`                             // optional, user scope ("global" for the global scope), all if empty
+	Principal     string                 `protobuf:"bytes,3,opt,name=principal,proto3" json:"principal,omitempty"`                     // optional, authenticated caller
+	Operation     string                 `protobuf:"bytes,4,opt,name=operation,proto3" json:"operation,omitempty"`                     // optional, e.g. "Read" or "Delete"
+	Since         string                 `protobuf:"bytes,5,opt,name=since,proto3" json:"since,omitempty"`                             // optional, ISO 8601
+	Until         string                 `protobuf:"bytes,6,opt,name=until,proto3" json:"until,omitempty"`                             // optional, ISO 8601
+	Limit         int32                  `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`                            // optional, newest N entries, default 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryAuditRequest) Reset() {
+	*x = QueryAuditRequest{}
+	mi := &file_artifact_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryAuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryAuditRequest) ProtoMessage() {}
+
+func (x *QueryAuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryAuditRequest.ProtoReflect.Descriptor instead.
+func (*QueryAuditRequest) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *QueryAuditRequest) GetArtifactId() string {
+	if x != nil {
+		return x.ArtifactId
+	}
+	return ""
+}
+
+func (x *QueryAuditRequest) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+func (x *QueryAuditRequest) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *QueryAuditRequest) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *QueryAuditRequest) GetSince() string {
+	if x != nil {
+		return x.Since
+	}
+	return ""
+}
+
+func (x *QueryAuditRequest) GetUntil() string {
+	if x != nil {
+		return x.Until
+	}
+	return ""
+}
+
+func (x *QueryAuditRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type QueryAuditResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*AuditEntry          `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryAuditResponse) Reset() {
+	*x = QueryAuditResponse{}
+	mi := &file_artifact_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryAuditResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryAuditResponse) ProtoMessage() {}
+
+func (x *QueryAuditResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryAuditResponse.ProtoReflect.Descriptor instead.
+func (*QueryAuditResponse) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *QueryAuditResponse) GetEntries() []*AuditEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type AuditEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          string                 `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"` // ISO 8601
+	Operation     string                 `protobuf:"bytes,2,opt,name=operation,proto3" json:"operation,omitempty"`
+	Principal     string                 `protobuf:"bytes,3,opt,name=principal,proto3" json:"principal,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ArtifactId    string                 `protobuf:"bytes,5,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"`
+	VirtualPath   string                 `protobuf:"bytes,6,opt,name=virtual_path,json=virtualPath,proto3" json:"virtual_path,omitempty"`
+	Source        string                 `protobuf:"bytes,7,opt,name=source,proto3" json:"source,omitempty"`
+	Bytes         int64                  `protobuf:"varint,8,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Outcome       string                 `protobuf:"bytes,9,opt,name=outcome,proto3" json:"outcome,omitempty"` // "ok", "not_found", "denied" or "error"
+	Error         string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEntry) Reset() {
+	*x = AuditEntry{}
+	mi := &file_artifact_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEntry) ProtoMessage() {}
+
+func (x *AuditEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_artifact_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEntry.ProtoReflect.Descriptor instead.
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return file_artifact_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AuditEntry) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetArtifactId() string {
+	if x != nil {
+		return x.ArtifactId
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetVirtualPath() string {
+	if x != nil {
+		return x.VirtualPath
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_artifact_proto protoreflect.FileDescriptor
 
 const file_artifact_proto_rawDesc = "" +
@@ -938,14 +1558,73 @@ const file_artifact_proto_rawDesc = "" +
 	"updated_at\x18\x03 \x01(\tR\tupdatedAt\"@\n" +
 	"\vFindRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x18\n" +
-	"\apattern\x18\x02 \x01(\tR\apattern2\x8b\x03\n" +
+	"\apattern\x18\x02 \x01(\tR\apattern\"j\n" +
+	"\x16CreateShareLinkRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12'\n" +
+	"\x0fexpires_minutes\x18\x03 \x01(\x05R\x0eexpiresMinutes\"\x83\x01\n" +
+	"\x17CreateShareLinkResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\tR\texpiresAt\x12\x1a\n" +
+	"\bfilename\x18\x03 \x01(\tR\bfilename\x12\x1b\n" +
+	"\tmime_type\x18\x04 \x01(\tR\bmimeType\"`\n" +
+	"\x10SetExpiryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12#\n" +
+	"\rexpires_hours\x18\x03 \x01(\x05R\fexpiresHours\"2\n" +
+	"\x11SetExpiryResponse\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x01 \x01(\tR\texpiresAt\"x\n" +
+	"\fWatchRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vpath_prefix\x18\x02 \x01(\tR\n" +
+	"pathPrefix\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"\x83\x01\n" +
+	"\n" +
+	"WatchEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\tR\x04time\x125\n" +
+	"\bartifact\x18\x04 \x01(\v2\x19.artifact.v1.ArtifactInfoR\bartifact\"\xc8\x01\n" +
+	"\x11QueryAuditRequest\x12\x1f\n" +
+	"\vartifact_id\x18\x01 \x01(\tR\n" +
+	"artifactId\x12\x14\n" +
+	"\x05scope\x18\x02 \x01(\tR\x05scope\x12\x1c\n" +
+	"\tprincipal\x18\x03 \x01(\tR\tprincipal\x12\x1c\n" +
+	"\toperation\x18\x04 \x01(\tR\toperation\x12\x14\n" +
+	"\x05since\x18\x05 \x01(\tR\x05since\x12\x14\n" +
+	"\x05until\x18\x06 \x01(\tR\x05until\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\"G\n" +
+	"\x12QueryAuditResponse\x121\n" +
+	"\aentries\x18\x01 \x03(\v2\x17.artifact.v1.AuditEntryR\aentries\"\x97\x02\n" +
+	"\n" +
+	"AuditEntry\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\tR\x04time\x12\x1c\n" +
+	"\toperation\x18\x02 \x01(\tR\toperation\x12\x1c\n" +
+	"\tprincipal\x18\x03 \x01(\tR\tprincipal\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vartifact_id\x18\x05 \x01(\tR\n" +
+	"artifactId\x12!\n" +
+	"\fvirtual_path\x18\x06 \x01(\tR\vvirtualPath\x12\x16\n" +
+	"\x06source\x18\a \x01(\tR\x06source\x12\x14\n" +
+	"\x05bytes\x18\b \x01(\x03R\x05bytes\x12\x18\n" +
+	"\aoutcome\x18\t \x01(\tR\aoutcome\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error2\xc3\x05\n" +
 	"\x0fArtifactService\x12>\n" +
 	"\x05Write\x12\x19.artifact.v1.WriteRequest\x1a\x1a.artifact.v1.WriteResponse\x12;\n" +
 	"\x04Read\x12\x18.artifact.v1.ReadRequest\x1a\x19.artifact.v1.ReadResponse\x12A\n" +
 	"\x06Delete\x12\x1a.artifact.v1.DeleteRequest\x1a\x1b.artifact.v1.DeleteResponse\x12;\n" +
 	"\x04List\x12\x18.artifact.v1.ListRequest\x1a\x19.artifact.v1.ListResponse\x12>\n" +
 	"\x05Patch\x12\x19.artifact.v1.PatchRequest\x1a\x1a.artifact.v1.PatchResponse\x12;\n" +
-	"\x04Find\x12\x18.artifact.v1.FindRequest\x1a\x19.artifact.v1.ListResponseB)Z'github.com/hmsoft0815/mlcartifact/protob\x06proto3"
+	"\x04Find\x12\x18.artifact.v1.FindRequest\x1a\x19.artifact.v1.ListResponse\x12J\n" +
+	"\tSetExpiry\x12\x1d.artifact.v1.SetExpiryRequest\x1a\x1e.artifact.v1.SetExpiryResponse\x12\\\n" +
+	"\x0fCreateShareLink\x12#.artifact.v1.CreateShareLinkRequest\x1a$.artifact.v1.CreateShareLinkResponse\x12=\n" +
+	"\x05Watch\x12\x19.artifact.v1.WatchRequest\x1a\x17.artifact.v1.WatchEvent0\x01\x12M\n" +
+	"\n" +
+	"QueryAudit\x12\x1e.artifact.v1.QueryAuditRequest\x1a\x1f.artifact.v1.QueryAuditResponseB)Z'github.com/hmsoft0815/mlcartifact/protob\x06proto3"
 
 var (
 	file_artifact_proto_rawDescOnce sync.Once
@@ -959,42 +1638,61 @@ func file_artifact_proto_rawDescGZIP() []byte {
 	return file_artifact_proto_rawDescData
 }
 
-var file_artifact_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_artifact_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_artifact_proto_goTypes = []any{
-	(*WriteRequest)(nil),   // 0: artifact.v1.WriteRequest
-	(*WriteResponse)(nil),  // 1: artifact.v1.WriteResponse
-	(*ReadRequest)(nil),    // 2: artifact.v1.ReadRequest
-	(*ReadResponse)(nil),   // 3: artifact.v1.ReadResponse
-	(*DeleteRequest)(nil),  // 4: artifact.v1.DeleteRequest
-	(*DeleteResponse)(nil), // 5: artifact.v1.DeleteResponse
-	(*ListRequest)(nil),    // 6: artifact.v1.ListRequest
-	(*ListResponse)(nil),   // 7: artifact.v1.ListResponse
-	(*ArtifactInfo)(nil),   // 8: artifact.v1.ArtifactInfo
-	(*PatchRequest)(nil),   // 9: artifact.v1.PatchRequest
-	(*PatchResponse)(nil),  // 10: artifact.v1.PatchResponse
-	(*FindRequest)(nil),    // 11: artifact.v1.FindRequest
-	nil,                    // 12: artifact.v1.WriteRequest.MetadataEntry
+	(*WriteRequest)(nil),            // 0: artifact.v1.WriteRequest
+	(*WriteResponse)(nil),           // 1: artifact.v1.WriteResponse
+	(*ReadRequest)(nil),             // 2: artifact.v1.ReadRequest
+	(*ReadResponse)(nil),            // 3: artifact.v1.ReadResponse
+	(*DeleteRequest)(nil),           // 4: artifact.v1.DeleteRequest
+	(*DeleteResponse)(nil),          // 5: artifact.v1.DeleteResponse
+	(*ListRequest)(nil),             // 6: artifact.v1.ListRequest
+	(*ListResponse)(nil),            // 7: artifact.v1.ListResponse
+	(*ArtifactInfo)(nil),            // 8: artifact.v1.ArtifactInfo
+	(*PatchRequest)(nil),            // 9: artifact.v1.PatchRequest
+	(*PatchResponse)(nil),           // 10: artifact.v1.PatchResponse
+	(*FindRequest)(nil),             // 11: artifact.v1.FindRequest
+	(*CreateShareLinkRequest)(nil),  // 12: artifact.v1.CreateShareLinkRequest
+	(*CreateShareLinkResponse)(nil), // 13: artifact.v1.CreateShareLinkResponse
+	(*SetExpiryRequest)(nil),        // 14: artifact.v1.SetExpiryRequest
+	(*SetExpiryResponse)(nil),       // 15: artifact.v1.SetExpiryResponse
+	(*WatchRequest)(nil),            // 16: artifact.v1.WatchRequest
+	(*WatchEvent)(nil),              // 17: artifact.v1.WatchEvent
+	(*QueryAuditRequest)(nil),       // 18: artifact.v1.QueryAuditRequest
+	(*QueryAuditResponse)(nil),      // 19: artifact.v1.QueryAuditResponse
+	(*AuditEntry)(nil),              // 20: artifact.v1.AuditEntry
+	nil,                             // 21: artifact.v1.WriteRequest.MetadataEntry
 }
 var file_artifact_proto_depIdxs = []int32{
-	12, // 0: artifact.v1.WriteRequest.metadata:type_name -> artifact.v1.WriteRequest.MetadataEntry
+	21, // 0: artifact.v1.WriteRequest.metadata:type_name -> artifact.v1.WriteRequest.MetadataEntry
 	8,  // 1: artifact.v1.ListResponse.items:type_name -> artifact.v1.ArtifactInfo
-	0,  // 2: artifact.v1.ArtifactService.Write:input_type -> artifact.v1.WriteRequest
-	2,  // 3: artifact.v1.ArtifactService.Read:input_type -> artifact.v1.ReadRequest
-	4,  // 4: artifact.v1.ArtifactService.Delete:input_type -> artifact.v1.DeleteRequest
-	6,  // 5: artifact.v1.ArtifactService.List:input_type -> artifact.v1.ListRequest
-	9,  // 6: artifact.v1.ArtifactService.Patch:input_type -> artifact.v1.PatchRequest
-	11, // 7: artifact.v1.ArtifactService.Find:input_type -> artifact.v1.FindRequest
-	1,  // 8: artifact.v1.ArtifactService.Write:output_type -> artifact.v1.WriteResponse
-	3,  // 9: artifact.v1.ArtifactService.Read:output_type -> artifact.v1.ReadResponse
-	5,  // 10: artifact.v1.ArtifactService.Delete:output_type -> artifact.v1.DeleteResponse
-	7,  // 11: artifact.v1.ArtifactService.List:output_type -> artifact.v1.ListResponse
-	10, // 12: artifact.v1.ArtifactService.Patch:output_type -> artifact.v1.PatchResponse
-	7,  // 13: artifact.v1.ArtifactService.Find:output_type -> artifact.v1.ListResponse
-	8,  // [8:14] is the sub-list for method output_type
-	2,  // [2:8] is the sub-list for method input_type
-	2,  // [2:2] is the sub-list for extension type_name
-	2,  // [2:2] is the sub-list for extension extendee
-	0,  // [0:2] is the sub-list for field type_name
+	8,  // 2: artifact.v1.WatchEvent.artifact:type_name -> artifact.v1.ArtifactInfo
+	20, // 3: artifact.v1.QueryAuditResponse.entries:type_name -> artifact.v1.AuditEntry
+	0,  // 4: artifact.v1.ArtifactService.Write:input_type -> artifact.v1.WriteRequest
+	2,  // 5: artifact.v1.ArtifactService.Read:input_type -> artifact.v1.ReadRequest
+	4,  // 6: artifact.v1.ArtifactService.Delete:input_type -> artifact.v1.DeleteRequest
+	6,  // 7: artifact.v1.ArtifactService.List:input_type -> artifact.v1.ListRequest
+	9,  // 8: artifact.v1.ArtifactService.Patch:input_type -> artifact.v1.PatchRequest
+	11, // 9: artifact.v1.ArtifactService.Find:input_type -> artifact.v1.FindRequest
+	14, // 10: artifact.v1.ArtifactService.SetExpiry:input_type -> artifact.v1.SetExpiryRequest
+	12, // 11: artifact.v1.ArtifactService.CreateShareLink:input_type -> artifact.v1.CreateShareLinkRequest
+	16, // 12: artifact.v1.ArtifactService.Watch:input_type -> artifact.v1.WatchRequest
+	18, // 13: artifact.v1.ArtifactService.QueryAudit:input_type -> artifact.v1.QueryAuditRequest
+	1,  // 14: artifact.v1.ArtifactService.Write:output_type -> artifact.v1.WriteResponse
+	3,  // 15: artifact.v1.ArtifactService.Read:output_type -> artifact.v1.ReadResponse
+	5,  // 16: artifact.v1.ArtifactService.Delete:output_type -> artifact.v1.DeleteResponse
+	7,  // 17: artifact.v1.ArtifactService.List:output_type -> artifact.v1.ListResponse
+	10, // 18: artifact.v1.ArtifactService.Patch:output_type -> artifact.v1.PatchResponse
+	7,  // 19: artifact.v1.ArtifactService.Find:output_type -> artifact.v1.ListResponse
+	15, // 20: artifact.v1.ArtifactService.SetExpiry:output_type -> artifact.v1.SetExpiryResponse
+	13, // 21: artifact.v1.ArtifactService.CreateShareLink:output_type -> artifact.v1.CreateShareLinkResponse
+	17, // 22: artifact.v1.ArtifactService.Watch:output_type -> artifact.v1.WatchEvent
+	19, // 23: artifact.v1.ArtifactService.QueryAudit:output_type -> artifact.v1.QueryAuditResponse
+	14, // [14:24] is the sub-list for method output_type
+	4,  // [4:14] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_artifact_proto_init() }
@@ -1008,7 +1706,7 @@ func file_artifact_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_artifact_proto_rawDesc), len(file_artifact_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   13,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
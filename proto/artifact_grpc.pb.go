@@ -19,12 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ArtifactService_Write_FullMethodName  = "/artifact.v1.ArtifactService/Write"
-	ArtifactService_Read_FullMethodName   = "/artifact.v1.ArtifactService/Read"
-	ArtifactService_Delete_FullMethodName = "/artifact.v1.ArtifactService/Delete"
-	ArtifactService_List_FullMethodName   = "/artifact.v1.ArtifactService/List"
-	ArtifactService_Patch_FullMethodName  = "/artifact.v1.ArtifactService/Patch"
-	ArtifactService_Find_FullMethodName   = "/artifact.v1.ArtifactService/Find"
+	ArtifactService_Write_FullMethodName           = "/artifact.v1.ArtifactService/Write"
+	ArtifactService_Read_FullMethodName            = "/artifact.v1.ArtifactService/Read"
+	ArtifactService_Delete_FullMethodName          = "/artifact.v1.ArtifactService/Delete"
+	ArtifactService_List_FullMethodName            = "/artifact.v1.ArtifactService/List"
+	ArtifactService_Patch_FullMethodName           = "/artifact.v1.ArtifactService/Patch"
+	ArtifactService_Find_FullMethodName            = "/artifact.v1.ArtifactService/Find"
+	ArtifactService_SetExpiry_FullMethodName       = "/artifact.v1.ArtifactService/SetExpiry"
+	ArtifactService_CreateShareLink_FullMethodName = "/artifact.v1.ArtifactService/CreateShareLink"
+	ArtifactService_Watch_FullMethodName           = "/artifact.v1.ArtifactService/Watch"
+	ArtifactService_QueryAudit_FullMethodName      = "/artifact.v1.ArtifactService/QueryAudit"
 )
 
 // ArtifactServiceClient is the client API for ArtifactService service.
@@ -38,6 +42,14 @@ type ArtifactServiceClient interface {
 	// VFS: Hierarchical Virtual File System operations
 	Patch(ctx context.Context, in *PatchRequest, opts ...grpc.CallOption) (*PatchResponse, error)
 	Find(ctx context.Context, in *FindRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Lifecycle: change the expiry (TTL) of an existing artifact
+	SetExpiry(ctx context.Context, in *SetExpiryRequest, opts ...grpc.CallOption) (*SetExpiryResponse, error)
+	// Sharing: signed, expiring HTTP download links
+	CreateShareLink(ctx context.Context, in *CreateShareLinkRequest, opts ...grpc.CallOption) (*CreateShareLinkResponse, error)
+	// Events: stream create, patch, delete and expire events instead of polling List
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+	// Compliance: query the audit log of artifact operations (admins only)
+	QueryAudit(ctx context.Context, in *QueryAuditRequest, opts ...grpc.CallOption) (*QueryAuditResponse, error)
 }
 
 type artifactServiceClient struct {
@@ -108,6 +120,55 @@ func (c *artifactServiceClient) Find(ctx context.Context, in *FindRequest, opts
 	return out, nil
 }
 
+func (c *artifactServiceClient) SetExpiry(ctx context.Context, in *SetExpiryRequest, opts ...grpc.CallOption) (*SetExpiryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetExpiryResponse)
+	err := c.cc.Invoke(ctx, ArtifactService_SetExpiry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *artifactServiceClient) CreateShareLink(ctx context.Context, in *CreateShareLinkRequest, opts ...grpc.CallOption) (*CreateShareLinkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateShareLinkResponse)
+	err := c.cc.Invoke(ctx, ArtifactService_CreateShareLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *artifactServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ArtifactService_ServiceDesc.Streams[0], ArtifactService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ArtifactService_WatchClient = grpc.ServerStreamingClient[WatchEvent]
+
+func (c *artifactServiceClient) QueryAudit(ctx context.Context, in *QueryAuditRequest, opts ...grpc.CallOption) (*QueryAuditResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryAuditResponse)
+	err := c.cc.Invoke(ctx, ArtifactService_QueryAudit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ArtifactServiceServer is the server API for ArtifactService service.
 // All implementations must embed UnimplementedArtifactServiceServer
 // for forward compatibility.
@@ -119,6 +180,14 @@ type ArtifactServiceServer interface {
 	// VFS: Hierarchical Virtual File System operations
 	Patch(context.Context, *PatchRequest) (*PatchResponse, error)
 	Find(context.Context, *FindRequest) (*ListResponse, error)
+	// Lifecycle: change the expiry (TTL) of an existing artifact
+	SetExpiry(context.Context, *SetExpiryRequest) (*SetExpiryResponse, error)
+	// Sharing: signed, expiring HTTP download links
+	CreateShareLink(context.Context, *CreateShareLinkRequest) (*CreateShareLinkResponse, error)
+	// Events: stream create, patch, delete and expire events instead of polling List
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	// Compliance: query the audit log of artifact operations (admins only)
+	QueryAudit(context.Context, *QueryAuditRequest) (*QueryAuditResponse, error)
 	mustEmbedUnimplementedArtifactServiceServer()
 }
 
@@ -147,6 +216,18 @@ func (UnimplementedArtifactServiceServer) Patch(context.Context, *PatchRequest)
 func (UnimplementedArtifactServiceServer) Find(context.Context, *FindRequest) (*ListResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Find not implemented")
 }
+func (UnimplementedArtifactServiceServer) SetExpiry(context.Context, *SetExpiryRequest) (*SetExpiryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetExpiry not implemented")
+}
+func (UnimplementedArtifactServiceServer) CreateShareLink(context.Context, *CreateShareLinkRequest) (*CreateShareLinkResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateShareLink not implemented")
+}
+func (UnimplementedArtifactServiceServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedArtifactServiceServer) QueryAudit(context.Context, *QueryAuditRequest) (*QueryAuditResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method QueryAudit not implemented")
+}
 func (UnimplementedArtifactServiceServer) mustEmbedUnimplementedArtifactServiceServer() {}
 func (UnimplementedArtifactServiceServer) testEmbeddedByValue()                         {}
 
@@ -276,6 +357,71 @@ func _ArtifactService_Find_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ArtifactService_SetExpiry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetExpiryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArtifactServiceServer).SetExpiry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArtifactService_SetExpiry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArtifactServiceServer).SetExpiry(ctx, req.(*SetExpiryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArtifactService_CreateShareLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateShareLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArtifactServiceServer).CreateShareLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArtifactService_CreateShareLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArtifactServiceServer).CreateShareLink(ctx, req.(*CreateShareLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArtifactService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ArtifactServiceServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ArtifactService_WatchServer = grpc.ServerStreamingServer[WatchEvent]
+
+func _ArtifactService_QueryAudit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryAuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArtifactServiceServer).QueryAudit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ArtifactService_QueryAudit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArtifactServiceServer).QueryAudit(ctx, req.(*QueryAuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ArtifactService_ServiceDesc is the grpc.ServiceDesc for ArtifactService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -307,7 +453,25 @@ var ArtifactService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Find",
 			Handler:    _ArtifactService_Find_Handler,
 		},
+		{
+			MethodName: "SetExpiry",
+			Handler:    _ArtifactService_SetExpiry_Handler,
+		},
+		{
+			MethodName: "CreateShareLink",
+			Handler:    _ArtifactService_CreateShareLink_Handler,
+		},
+		{
+			MethodName: "QueryAudit",
+			Handler:    _ArtifactService_QueryAudit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _ArtifactService_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "artifact.proto",
 }
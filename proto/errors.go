@@ -0,0 +1,8 @@
+// Copyright (c) 2026 Michael Lechner. All rights reserved.
+
+package proto
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details the server
+// attaches to errors. Their Reason is machine-readable, such as "NOT_FOUND"
+// or "QUOTA_EXCEEDED".
+const ErrorDomain = "mlcartifact"
@@ -45,6 +45,17 @@ const (
 	ArtifactServicePatchProcedure = "/artifact.v1.ArtifactService/Patch"
 	// ArtifactServiceFindProcedure is the fully-qualified name of the ArtifactService's Find RPC.
 	ArtifactServiceFindProcedure = "/artifact.v1.ArtifactService/Find"
+	// ArtifactServiceSetExpiryProcedure is the fully-qualified name of the ArtifactService's SetExpiry
+	// RPC.
+	ArtifactServiceSetExpiryProcedure = "/artifact.v1.ArtifactService/SetExpiry"
+	// ArtifactServiceCreateShareLinkProcedure is the fully-qualified name of the ArtifactService's
+	// CreateShareLink RPC.
+	ArtifactServiceCreateShareLinkProcedure = "/artifact.v1.ArtifactService/CreateShareLink"
+	// ArtifactServiceWatchProcedure is the fully-qualified name of the ArtifactService's Watch RPC.
+	ArtifactServiceWatchProcedure = "/artifact.v1.ArtifactService/Watch"
+	// ArtifactServiceQueryAuditProcedure is the fully-qualified name of the ArtifactService's
+	// QueryAudit RPC.
+	ArtifactServiceQueryAuditProcedure = "/artifact.v1.ArtifactService/QueryAudit"
 )
 
 // ArtifactServiceClient is a client for the artifact.v1.ArtifactService service.
@@ -56,6 +67,14 @@ type ArtifactServiceClient interface {
 	// VFS: Hierarchical Virtual File System operations
 	Patch(context.Context, *connect.Request[proto.PatchRequest]) (*connect.Response[proto.PatchResponse], error)
 	Find(context.Context, *connect.Request[proto.FindRequest]) (*connect.Response[proto.ListResponse], error)
+	// Lifecycle: change the expiry (TTL) of an existing artifact
+	SetExpiry(context.Context, *connect.Request[proto.SetExpiryRequest]) (*connect.Response[proto.SetExpiryResponse], error)
+	// Sharing: signed, expiring HTTP download links
+	CreateShareLink(context.Context, *connect.Request[proto.CreateShareLinkRequest]) (*connect.Response[proto.CreateShareLinkResponse], error)
+	// Events: stream create, patch, delete and expire events instead of polling List
+	Watch(context.Context, *connect.Request[proto.WatchRequest]) (*connect.ServerStreamForClient[proto.WatchEvent], error)
+	// Compliance: query the audit log of artifact operations (admins only)
+	QueryAudit(context.Context, *connect.Request[proto.QueryAuditRequest]) (*connect.Response[proto.QueryAuditResponse], error)
 }
 
 // NewArtifactServiceClient constructs a client for the artifact.v1.ArtifactService service. By
@@ -105,17 +124,45 @@ func NewArtifactServiceClient(httpClient connect.HTTPClient, baseURL string, opt
 			connect.WithSchema(artifactServiceMethods.ByName("Find")),
 			connect.WithClientOptions(opts...),
 		),
+		setExpiry: connect.NewClient[proto.SetExpiryRequest, proto.SetExpiryResponse](
+			httpClient,
+			baseURL+ArtifactServiceSetExpiryProcedure,
+			connect.WithSchema(artifactServiceMethods.ByName("SetExpiry")),
+			connect.WithClientOptions(opts...),
+		),
+		createShareLink: connect.NewClient[proto.CreateShareLinkRequest, proto.CreateShareLinkResponse](
+			httpClient,
+			baseURL+ArtifactServiceCreateShareLinkProcedure,
+			connect.WithSchema(artifactServiceMethods.ByName("CreateShareLink")),
+			connect.WithClientOptions(opts...),
+		),
+		watch: connect.NewClient[proto.WatchRequest, proto.WatchEvent](
+			httpClient,
+			baseURL+ArtifactServiceWatchProcedure,
+			connect.WithSchema(artifactServiceMethods.ByName("Watch")),
+			connect.WithClientOptions(opts...),
+		),
+		queryAudit: connect.NewClient[proto.QueryAuditRequest, proto.QueryAuditResponse](
+			httpClient,
+			baseURL+ArtifactServiceQueryAuditProcedure,
+			connect.WithSchema(artifactServiceMethods.ByName("QueryAudit")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // artifactServiceClient implements ArtifactServiceClient.
 type artifactServiceClient struct {
-	write  *connect.Client[proto.WriteRequest, proto.WriteResponse]
-	read   *connect.Client[proto.ReadRequest, proto.ReadResponse]
-	delete *connect.Client[proto.DeleteRequest, proto.DeleteResponse]
-	list   *connect.Client[proto.ListRequest, proto.ListResponse]
-	patch  *connect.Client[proto.PatchRequest, proto.PatchResponse]
-	find   *connect.Client[proto.FindRequest, proto.ListResponse]
+	write           *connect.Client[proto.WriteRequest, proto.WriteResponse]
+	read            *connect.Client[proto.ReadRequest, proto.ReadResponse]
+	delete          *connect.Client[proto.DeleteRequest, proto.DeleteResponse]
+	list            *connect.Client[proto.ListRequest, proto.ListResponse]
+	patch           *connect.Client[proto.PatchRequest, proto.PatchResponse]
+	find            *connect.Client[proto.FindRequest, proto.ListResponse]
+	setExpiry       *connect.Client[proto.SetExpiryRequest, proto.SetExpiryResponse]
+	createShareLink *connect.Client[proto.CreateShareLinkRequest, proto.CreateShareLinkResponse]
+	watch           *connect.Client[proto.WatchRequest, proto.WatchEvent]
+	queryAudit      *connect.Client[proto.QueryAuditRequest, proto.QueryAuditResponse]
 }
 
 // Write calls artifact.v1.ArtifactService.Write.
@@ -148,6 +195,26 @@ func (c *artifactServiceClient) Find(ctx context.Context, req *connect.Request[p
 	return c.find.CallUnary(ctx, req)
 }
 
+// SetExpiry calls artifact.v1.ArtifactService.SetExpiry.
+func (c *artifactServiceClient) SetExpiry(ctx context.Context, req *connect.Request[proto.SetExpiryRequest]) (*connect.Response[proto.SetExpiryResponse], error) {
+	return c.setExpiry.CallUnary(ctx, req)
+}
+
+// CreateShareLink calls artifact.v1.ArtifactService.CreateShareLink.
+func (c *artifactServiceClient) CreateShareLink(ctx context.Context, req *connect.Request[proto.CreateShareLinkRequest]) (*connect.Response[proto.CreateShareLinkResponse], error) {
+	return c.createShareLink.CallUnary(ctx, req)
+}
+
+// Watch calls artifact.v1.ArtifactService.Watch.
+func (c *artifactServiceClient) Watch(ctx context.Context, req *connect.Request[proto.WatchRequest]) (*connect.ServerStreamForClient[proto.WatchEvent], error) {
+	return c.watch.CallServerStream(ctx, req)
+}
+
+// QueryAudit calls artifact.v1.ArtifactService.QueryAudit.
+func (c *artifactServiceClient) QueryAudit(ctx context.Context, req *connect.Request[proto.QueryAuditRequest]) (*connect.Response[proto.QueryAuditResponse], error) {
+	return c.queryAudit.CallUnary(ctx, req)
+}
+
 // ArtifactServiceHandler is an implementation of the artifact.v1.ArtifactService service.
 type ArtifactServiceHandler interface {
 	Write(context.Context, *connect.Request[proto.WriteRequest]) (*connect.Response[proto.WriteResponse], error)
@@ -157,6 +224,14 @@ type ArtifactServiceHandler interface {
 	// VFS: Hierarchical Virtual File System operations
 	Patch(context.Context, *connect.Request[proto.PatchRequest]) (*connect.Response[proto.PatchResponse], error)
 	Find(context.Context, *connect.Request[proto.FindRequest]) (*connect.Response[proto.ListResponse], error)
+	// Lifecycle: change the expiry (TTL) of an existing artifact
+	SetExpiry(context.Context, *connect.Request[proto.SetExpiryRequest]) (*connect.Response[proto.SetExpiryResponse], error)
+	// Sharing: signed, expiring HTTP download links
+	CreateShareLink(context.Context, *connect.Request[proto.CreateShareLinkRequest]) (*connect.Response[proto.CreateShareLinkResponse], error)
+	// Events: stream create, patch, delete and expire events instead of polling List
+	Watch(context.Context, *connect.Request[proto.WatchRequest], *connect.ServerStream[proto.WatchEvent]) error
+	// Compliance: query the audit log of artifact operations (admins only)
+	QueryAudit(context.Context, *connect.Request[proto.QueryAuditRequest]) (*connect.Response[proto.QueryAuditResponse], error)
 }
 
 // NewArtifactServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -202,6 +277,30 @@ func NewArtifactServiceHandler(svc ArtifactServiceHandler, opts ...connect.Handl
 		connect.WithSchema(artifactServiceMethods.ByName("Find")),
 		connect.WithHandlerOptions(opts...),
 	)
+	artifactServiceSetExpiryHandler := connect.NewUnaryHandler(
+		ArtifactServiceSetExpiryProcedure,
+		svc.SetExpiry,
+		connect.WithSchema(artifactServiceMethods.ByName("SetExpiry")),
+		connect.WithHandlerOptions(opts...),
+	)
+	artifactServiceCreateShareLinkHandler := connect.NewUnaryHandler(
+		ArtifactServiceCreateShareLinkProcedure,
+		svc.CreateShareLink,
+		connect.WithSchema(artifactServiceMethods.ByName("CreateShareLink")),
+		connect.WithHandlerOptions(opts...),
+	)
+	artifactServiceWatchHandler := connect.NewServerStreamHandler(
+		ArtifactServiceWatchProcedure,
+		svc.Watch,
+		connect.WithSchema(artifactServiceMethods.ByName("Watch")),
+		connect.WithHandlerOptions(opts...),
+	)
+	artifactServiceQueryAuditHandler := connect.NewUnaryHandler(
+		ArtifactServiceQueryAuditProcedure,
+		svc.QueryAudit,
+		connect.WithSchema(artifactServiceMethods.ByName("QueryAudit")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/artifact.v1.ArtifactService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case ArtifactServiceWriteProcedure:
@@ -216,6 +315,14 @@ func NewArtifactServiceHandler(svc ArtifactServiceHandler, opts ...connect.Handl
 			artifactServicePatchHandler.ServeHTTP(w, r)
 		case ArtifactServiceFindProcedure:
 			artifactServiceFindHandler.ServeHTTP(w, r)
+		case ArtifactServiceSetExpiryProcedure:
+			artifactServiceSetExpiryHandler.ServeHTTP(w, r)
+		case ArtifactServiceCreateShareLinkProcedure:
+			artifactServiceCreateShareLinkHandler.ServeHTTP(w, r)
+		case ArtifactServiceWatchProcedure:
+			artifactServiceWatchHandler.ServeHTTP(w, r)
+		case ArtifactServiceQueryAuditProcedure:
+			artifactServiceQueryAuditHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -248,3 +355,19 @@ func (UnimplementedArtifactServiceHandler) Patch(context.Context, *connect.Reque
 func (UnimplementedArtifactServiceHandler) Find(context.Context, *connect.Request[proto.FindRequest]) (*connect.Response[proto.ListResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("artifact.v1.ArtifactService.Find is not implemented"))
 }
+
+func (UnimplementedArtifactServiceHandler) SetExpiry(context.Context, *connect.Request[proto.SetExpiryRequest]) (*connect.Response[proto.SetExpiryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("artifact.v1.ArtifactService.SetExpiry is not implemented"))
+}
+
+func (UnimplementedArtifactServiceHandler) CreateShareLink(context.Context, *connect.Request[proto.CreateShareLinkRequest]) (*connect.Response[proto.CreateShareLinkResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("artifact.v1.ArtifactService.CreateShareLink is not implemented"))
+}
+
+func (UnimplementedArtifactServiceHandler) Watch(context.Context, *connect.Request[proto.WatchRequest], *connect.ServerStream[proto.WatchEvent]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("artifact.v1.ArtifactService.Watch is not implemented"))
+}
+
+func (UnimplementedArtifactServiceHandler) QueryAudit(context.Context, *connect.Request[proto.QueryAuditRequest]) (*connect.Response[proto.QueryAuditResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("artifact.v1.ArtifactService.QueryAudit is not implemented"))
+}